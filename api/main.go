@@ -27,7 +27,24 @@ func Run(cfg *config.Config) error {
 	}
 
 	// Initialize the match service
-	matchService := match.NewService(cfg, weaviateClient, embeddingService)
+	matchService, err := match.NewService(cfg, weaviateClient, embeddingService)
+	if err != nil {
+		return fmt.Errorf("failed to initialize match service: %w", err)
+	}
+
+	// Load the trained scoring model if configured
+	if cfg.Matching.ModelPath != "" {
+		if err := matchService.LoadModel(cfg.Matching.ModelPath); err != nil {
+			return fmt.Errorf("failed to load scoring model: %w", err)
+		}
+	}
+
+	// Load the TF-IDF statistics if enabled
+	if cfg.TFIDF.Enabled {
+		if err := matchService.LoadTFIDF(cfg.TFIDF.Path); err != nil {
+			return fmt.Errorf("failed to load TF-IDF statistics: %w", err)
+		}
+	}
 
 	// Create server
 	server := NewServer(cfg, weaviateClient, matchService, cfg.Embedding.EmbeddingDim)
@@ -1,17 +1,23 @@
 package api
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/TFMV/resolve/internal/config"
 	"github.com/TFMV/resolve/internal/match"
-	"github.com/TFMV/resolve/internal/weaviate"
+	"github.com/TFMV/resolve/internal/override"
+	"github.com/TFMV/resolve/internal/review"
+	"github.com/TFMV/resolve/internal/store"
 	"github.com/gorilla/mux"
 )
 
@@ -23,14 +29,15 @@ var timeNow = time.Now
 
 // MatchRequest represents a request to match an entity
 type MatchRequest struct {
-	Entity            *weaviate.EntityRecord `json:"entity"`
-	Text              string                 `json:"text,omitempty"`
-	Threshold         float64                `json:"threshold"`
-	Limit             int                    `json:"limit"`
-	UseCluster        bool                   `json:"use_clustering,omitempty"`
-	IncludeScores     bool                   `json:"include_scores,omitempty"`
-	FieldWeights      map[string]float32     `json:"field_weights,omitempty"`
-	FieldTypeMappings map[string]string      `json:"field_type_mappings,omitempty"`
+	Entity            *store.EntityRecord `json:"entity"`
+	Text              string              `json:"text,omitempty"`
+	Threshold         float64             `json:"threshold"`
+	Limit             int                 `json:"limit"`
+	UseCluster        bool                `json:"use_clustering,omitempty"`
+	IncludeScores     bool                `json:"include_scores,omitempty"`
+	FieldWeights      map[string]float32  `json:"field_weights,omitempty"`
+	FieldTypeMappings map[string]string   `json:"field_type_mappings,omitempty"`
+	Scorer            string              `json:"scorer,omitempty"` // "vector" (default), "fellegi_sunter" or "logistic"
 }
 
 // MatchGroupRequest represents a request to retrieve a match group
@@ -38,23 +45,24 @@ type MatchGroupRequest struct {
 	ThresholdOverride float32            `json:"threshold_override,omitempty"`
 	MaxSize           int                `json:"max_size,omitempty"`
 	IncludeScores     bool               `json:"include_scores,omitempty"`
-	Strategy          string             `json:"strategy,omitempty"` // "direct", "transitive", or "hybrid"
+	Strategy          string             `json:"strategy,omitempty"` // "direct", "transitive", "hybrid", or "correlation"
 	HopsLimit         int                `json:"hops_limit,omitempty"`
 	FieldWeights      map[string]float32 `json:"field_weights,omitempty"`
+	MinSimilarity     float32            `json:"min_similarity,omitempty"` // Flag the group below this internal minimum score
 }
 
 // Server represents the API server
 type Server struct {
 	router       *mux.Router
 	config       *config.Config
-	vdbClient    *weaviate.Client
+	vdbClient    store.VectorStore
 	matchService *match.Service
 	httpServer   *http.Server
 	embeddingDim int
 }
 
 // NewServer creates a new API server
-func NewServer(cfg *config.Config, vdbClient *weaviate.Client, matchService *match.Service, embeddingDim int) *Server {
+func NewServer(cfg *config.Config, vdbClient store.VectorStore, matchService *match.Service, embeddingDim int) *Server {
 	return &Server{
 		config:       cfg,
 		vdbClient:    vdbClient,
@@ -70,6 +78,7 @@ func (s *Server) registerRoutes() {
 	s.router.HandleFunc("/health", s.handleHealth).Methods(http.MethodGet)
 
 	// Entity endpoints
+	s.router.HandleFunc("/entities", s.handleGetEntities).Methods(http.MethodGet)
 	s.router.HandleFunc("/entities", s.handleAddEntity).Methods(http.MethodPost)
 	s.router.HandleFunc("/entities/{id}", s.handleGetEntity).Methods(http.MethodGet)
 	s.router.HandleFunc("/entities/{id}", s.handleUpdateEntity).Methods(http.MethodPut)
@@ -87,6 +96,21 @@ func (s *Server) registerRoutes() {
 
 	// Clustering endpoints
 	s.router.HandleFunc("/clusters/recompute", s.handleRecomputeClusters).Methods(http.MethodPost)
+
+	// Resolved entity endpoints
+	s.router.HandleFunc("/resolved/recompute", s.handleRecomputeResolved).Methods(http.MethodPost)
+	s.router.HandleFunc("/resolved/{id}", s.handleGetResolvedEntity).Methods(http.MethodGet)
+
+	// Override endpoints
+	s.router.HandleFunc("/overrides", s.handleListOverrides).Methods(http.MethodGet)
+	s.router.HandleFunc("/overrides", s.handleAddOverride).Methods(http.MethodPost)
+	s.router.HandleFunc("/overrides/{id}", s.handleRemoveOverride).Methods(http.MethodDelete)
+
+	// Review endpoints
+	s.router.HandleFunc("/review", s.handleListReviewPairs).Methods(http.MethodGet)
+	s.router.HandleFunc("/review/export", s.handleExportReviewLabels).Methods(http.MethodGet)
+	s.router.HandleFunc("/review/{id}/accept", s.handleDecideReviewPair(true)).Methods(http.MethodPost)
+	s.router.HandleFunc("/review/{id}/reject", s.handleDecideReviewPair(false)).Methods(http.MethodPost)
 }
 
 // Start starts the API server
@@ -135,16 +159,187 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 
 // Entity handlers
 
+// Listing defaults
+const (
+	defaultListLimit = 50
+	maxListLimit     = 1000
+)
+
+// listCursor is the decoded form of the opaque pagination cursor returned by GET /entities.
+// Unfiltered, unsorted listings page with the store's ID cursor; other listings fall back to offsets.
+type listCursor struct {
+	After  string `json:"after,omitempty"`
+	Offset int    `json:"offset,omitempty"`
+}
+
 // handleGetEntities handles GET /entities
+//
+// Query parameters:
+//   - limit: page size (default 50, max 1000)
+//   - cursor: next_cursor value from the previous page
+//   - filter: "path:op:value", repeatable; op is eq, ne, gt, gte, lt, lte or like
+//   - sort: comma-separated paths, prefixed with "-" for descending order
+//   - fields: comma-separated schema fields to return
+//   - include_vector: whether to return entity vectors
 func (s *Server) handleGetEntities(w http.ResponseWriter, r *http.Request) {
-	// Not implemented yet - will require pagination and possibly filtering
-	respondWithError(w, http.StatusNotImplemented, "Get all entities is not implemented")
+	query := r.URL.Query()
+	opts := store.ListOptions{Limit: defaultListLimit}
+
+	// Parse page size
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			respondWithError(w, http.StatusBadRequest, "Invalid limit parameter")
+			return
+		}
+		if limit > maxListLimit {
+			limit = maxListLimit
+		}
+		opts.Limit = limit
+	}
+
+	// Parse filters
+	for _, filterStr := range query["filter"] {
+		parts := strings.SplitN(filterStr, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || !store.ValidOperator(parts[1]) {
+			respondWithError(w, http.StatusBadRequest, "Invalid filter, expected path:op:value: "+filterStr)
+			return
+		}
+		opts.Filters = append(opts.Filters, store.Filter{Path: parts[0], Operator: parts[1], Value: parts[2]})
+	}
+
+	// Parse sort order
+	if sortStr := query.Get("sort"); sortStr != "" {
+		for _, path := range strings.Split(sortStr, ",") {
+			path = strings.TrimSpace(path)
+			descending := strings.HasPrefix(path, "-")
+			path = strings.TrimPrefix(path, "-")
+			if path == "" {
+				respondWithError(w, http.StatusBadRequest, "Invalid sort parameter")
+				return
+			}
+			opts.Sort = append(opts.Sort, store.SortField{Path: path, Descending: descending})
+		}
+	}
+
+	// Parse field projection
+	if fieldsStr := query.Get("fields"); fieldsStr != "" {
+		for _, field := range strings.Split(fieldsStr, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				opts.Fields = append(opts.Fields, field)
+			}
+		}
+	}
+	if includeVector := query.Get("include_vector"); includeVector != "" {
+		value, err := strconv.ParseBool(includeVector)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid include_vector parameter")
+			return
+		}
+		opts.IncludeVector = value
+	}
+
+	// Resume from the cursor
+	useIDCursor := len(opts.Filters) == 0 && len(opts.Sort) == 0
+	if cursorStr := query.Get("cursor"); cursorStr != "" {
+		cursor, err := decodeListCursor(cursorStr)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid cursor")
+			return
+		}
+		if useIDCursor {
+			opts.After = cursor.After
+		} else {
+			opts.Offset = cursor.Offset
+		}
+	}
+
+	// Query entities
+	entities, err := s.vdbClient.QueryEntities(r.Context(), opts)
+	if errors.Is(err, store.ErrUnsupportedFilter) {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to list entities: "+err.Error())
+		return
+	}
+
+	response := map[string]interface{}{
+		"entities": entities,
+		"count":    len(entities),
+	}
+
+	// A full page may be followed by more entities
+	if len(entities) == opts.Limit {
+		next := listCursor{Offset: opts.Offset + len(entities)}
+		if useIDCursor {
+			next = listCursor{After: entities[len(entities)-1].ID}
+		}
+		response["next_cursor"] = encodeListCursor(next)
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// encodeListCursor encodes a list cursor as an opaque URL-safe string
+func encodeListCursor(cursor listCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
 }
 
-// handleAddEntity handles POST /entities
+// decodeListCursor decodes a cursor produced by encodeListCursor
+func decodeListCursor(value string) (listCursor, error) {
+	var cursor listCursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, err
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return cursor, err
+	}
+	if cursor.Offset < 0 {
+		return cursor, fmt.Errorf("invalid cursor offset: %d", cursor.Offset)
+	}
+	return cursor, nil
+}
+
+// handleAddEntity handles POST /entities. The entity is normalized, embedded and
+// clustered by the match service unless ?raw=true is given, in which case the
+// record is stored as-is and must carry its own vector.
 func (s *Server) handleAddEntity(w http.ResponseWriter, r *http.Request) {
+	raw, err := parseRawParam(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if raw {
+		s.handleAddRawEntity(w, r)
+		return
+	}
+
 	// Parse request
-	var entity weaviate.EntityRecord
+	var entity match.EntityData
+	if err := json.NewDecoder(r.Body).Decode(&entity); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	// Add entity through the match service
+	id, err := s.matchService.AddEntity(r.Context(), entity)
+	if err != nil {
+		respondWithError(w, addErrorStatus(err), "Failed to add entity: "+err.Error())
+		return
+	}
+
+	// Return success response
+	respondWithJSON(w, http.StatusCreated, map[string]string{"id": id})
+}
+
+// handleAddRawEntity handles POST /entities?raw=true with a client-supplied vector
+func (s *Server) handleAddRawEntity(w http.ResponseWriter, r *http.Request) {
+	// Parse request
+	var entity store.EntityRecord
 	if err := json.NewDecoder(r.Body).Decode(&entity); err != nil {
 		respondWithError(w, http.StatusBadRequest, "Invalid request payload: "+err.Error())
 		return
@@ -190,14 +385,52 @@ func (s *Server) handleGetEntity(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, entity)
 }
 
-// handleUpdateEntity handles PUT /entities/{id}
+// handleUpdateEntity handles PUT /entities/{id}. As with POST /entities, the new fields
+// are normalized, embedded, clustered and resolved by the match service unless ?raw=true
+// is given, in which case the record is stored as-is.
 func (s *Server) handleUpdateEntity(w http.ResponseWriter, r *http.Request) {
+	raw, err := parseRawParam(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if raw {
+		s.handleUpdateRawEntity(w, r)
+		return
+	}
+
+	// Get ID from path
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	// Parse request
+	var entity match.EntityData
+	if err := json.NewDecoder(r.Body).Decode(&entity); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	// Set ID from path
+	entity.ID = id
+
+	// Update entity through the match service
+	if err := s.matchService.UpdateEntity(r.Context(), entity); err != nil {
+		respondWithError(w, addErrorStatus(err), "Failed to update entity: "+err.Error())
+		return
+	}
+
+	// Return success response
+	respondWithJSON(w, http.StatusOK, map[string]string{"status": "updated", "id": id})
+}
+
+// handleUpdateRawEntity handles PUT /entities/{id}?raw=true with an optional client-supplied vector
+func (s *Server) handleUpdateRawEntity(w http.ResponseWriter, r *http.Request) {
 	// Get ID from path
 	vars := mux.Vars(r)
 	id := vars["id"]
 
 	// Parse request
-	var entity weaviate.EntityRecord
+	var entity store.EntityRecord
 	if err := json.NewDecoder(r.Body).Decode(&entity); err != nil {
 		respondWithError(w, http.StatusBadRequest, "Invalid request payload: "+err.Error())
 		return
@@ -240,11 +473,54 @@ func (s *Server) handleDeleteEntity(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, map[string]string{"status": "deleted", "id": id})
 }
 
-// handleBatchAddEntities handles POST /entities/batch
+// handleBatchAddEntities handles POST /entities/batch. As with POST /entities,
+// ?raw=true stores pre-embedded records as-is.
 func (s *Server) handleBatchAddEntities(w http.ResponseWriter, r *http.Request) {
+	raw, err := parseRawParam(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if raw {
+		s.handleBatchAddRawEntities(w, r)
+		return
+	}
+
 	// Parse request
 	var request struct {
-		Entities []*weaviate.EntityRecord `json:"entities"`
+		Entities []match.EntityData `json:"entities"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	// Check if entities are provided
+	if len(request.Entities) == 0 {
+		respondWithError(w, http.StatusBadRequest, "No entities provided")
+		return
+	}
+
+	// Add entities in batch through the match service
+	ids, err := s.matchService.AddEntities(r.Context(), request.Entities)
+	if err != nil {
+		respondWithError(w, addErrorStatus(err), "Failed to add entities in batch: "+err.Error())
+		return
+	}
+
+	// Return success response
+	respondWithJSON(w, http.StatusCreated, map[string]interface{}{
+		"status": "added",
+		"count":  len(ids),
+		"ids":    ids,
+	})
+}
+
+// handleBatchAddRawEntities handles POST /entities/batch?raw=true with client-supplied vectors
+func (s *Server) handleBatchAddRawEntities(w http.ResponseWriter, r *http.Request) {
+	// Parse request
+	var request struct {
+		Entities []*store.EntityRecord `json:"entities"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		respondWithError(w, http.StatusBadRequest, "Invalid request payload: "+err.Error())
@@ -284,6 +560,28 @@ func (s *Server) handleBatchAddEntities(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+// parseRawParam reads the optional "raw" query parameter
+func parseRawParam(r *http.Request) (bool, error) {
+	value := r.URL.Query().Get("raw")
+	if value == "" {
+		return false, nil
+	}
+
+	raw, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid raw parameter: %s", value)
+	}
+	return raw, nil
+}
+
+// addErrorStatus maps an ingestion error to an HTTP status code
+func addErrorStatus(err error) int {
+	if errors.Is(err, match.ErrInvalidEntity) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
 // handleGetEntityCount handles GET /entities/count
 func (s *Server) handleGetEntityCount(w http.ResponseWriter, r *http.Request) {
 	// Get count
@@ -334,42 +632,11 @@ func (s *Server) handleMatchEntity(w http.ResponseWriter, r *http.Request) {
 
 	// Convert entity to match format
 	entityData := match.EntityData{
-		ID: request.Entity.ID,
-		Fields: map[string]string{
-			"name":    request.Entity.Name,
-			"address": request.Entity.Address,
-			"city":    request.Entity.City,
-			"state":   request.Entity.State,
-			"zip":     request.Entity.Zip,
-			"phone":   request.Entity.Phone,
-			"email":   request.Entity.Email,
-		},
+		ID:       request.Entity.ID,
+		Fields:   request.Entity.Fields,
 		Metadata: request.Entity.Metadata,
 	}
 
-	// Add normalized fields if available
-	if request.Entity.NameNormalized != "" {
-		entityData.Fields["name_normalized"] = request.Entity.NameNormalized
-	}
-	if request.Entity.AddressNormalized != "" {
-		entityData.Fields["address_normalized"] = request.Entity.AddressNormalized
-	}
-	if request.Entity.CityNormalized != "" {
-		entityData.Fields["city_normalized"] = request.Entity.CityNormalized
-	}
-	if request.Entity.StateNormalized != "" {
-		entityData.Fields["state_normalized"] = request.Entity.StateNormalized
-	}
-	if request.Entity.ZipNormalized != "" {
-		entityData.Fields["zip_normalized"] = request.Entity.ZipNormalized
-	}
-	if request.Entity.PhoneNormalized != "" {
-		entityData.Fields["phone_normalized"] = request.Entity.PhoneNormalized
-	}
-	if request.Entity.EmailNormalized != "" {
-		entityData.Fields["email_normalized"] = request.Entity.EmailNormalized
-	}
-
 	// Create match options
 	matchOpts := match.Options{
 		Limit:              request.Limit,
@@ -379,6 +646,7 @@ func (s *Server) handleMatchEntity(w http.ResponseWriter, r *http.Request) {
 		IncludeFieldScores: request.IncludeScores,
 		FieldWeights:       request.FieldWeights,
 		FieldTypeMappings:  request.FieldTypeMappings,
+		Scorer:             request.Scorer,
 	}
 
 	// Find matches
@@ -406,6 +674,7 @@ func (s *Server) handleMatchText(w http.ResponseWriter, r *http.Request) {
 		IncludeScores     bool               `json:"include_scores,omitempty"`
 		FieldWeights      map[string]float32 `json:"field_weights,omitempty"`
 		FieldTypeMappings map[string]string  `json:"field_type_mappings,omitempty"`
+		Scorer            string             `json:"scorer,omitempty"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		respondWithError(w, http.StatusBadRequest, "Invalid request payload: "+err.Error())
@@ -435,6 +704,7 @@ func (s *Server) handleMatchText(w http.ResponseWriter, r *http.Request) {
 		IncludeFieldScores: request.IncludeScores,
 		FieldWeights:       request.FieldWeights,
 		FieldTypeMappings:  request.FieldTypeMappings,
+		Scorer:             request.Scorer,
 	}
 
 	// Find matches
@@ -493,8 +763,8 @@ func (s *Server) handleGetMatchGroup(w http.ResponseWriter, r *http.Request) {
 	if strategy == "" {
 		strategy = "hybrid" // Default strategy
 	}
-	if strategy != "direct" && strategy != "transitive" && strategy != "hybrid" {
-		respondWithError(w, http.StatusBadRequest, "Invalid strategy parameter: must be 'direct', 'transitive', or 'hybrid'")
+	if strategy != "direct" && strategy != "transitive" && strategy != "hybrid" && strategy != "correlation" {
+		respondWithError(w, http.StatusBadRequest, "Invalid strategy parameter: must be 'direct', 'transitive', 'hybrid', or 'correlation'")
 		return
 	}
 
@@ -509,6 +779,17 @@ func (s *Server) handleGetMatchGroup(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Extract minimum internal similarity
+	var minSimilarity float64
+	if minSimilarityStr := queryParams.Get("min_similarity"); minSimilarityStr != "" {
+		var err error
+		minSimilarity, err = strconv.ParseFloat(minSimilarityStr, 32)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid min_similarity parameter")
+			return
+		}
+	}
+
 	// Create options
 	opts := match.MatchGroupOptions{
 		ThresholdOverride: threshold,
@@ -516,6 +797,7 @@ func (s *Server) handleGetMatchGroup(w http.ResponseWriter, r *http.Request) {
 		IncludeScores:     includeScores,
 		Strategy:          strategy,
 		HopsLimit:         hopsLimit,
+		MinSimilarity:     float32(minSimilarity),
 	}
 
 	// Get match group
@@ -550,6 +832,7 @@ func (s *Server) handleMatchGroupWithOptions(w http.ResponseWriter, r *http.Requ
 		Strategy:          request.Strategy,
 		HopsLimit:         request.HopsLimit,
 		FieldWeights:      request.FieldWeights,
+		MinSimilarity:     request.MinSimilarity,
 	}
 
 	// Apply defaults
@@ -568,26 +851,23 @@ func (s *Server) handleMatchGroupWithOptions(w http.ResponseWriter, r *http.Requ
 	respondWithJSON(w, http.StatusOK, group)
 }
 
-// handleRecomputeClusters handles POST /clusters/recompute
+// handleRecomputeClusters handles POST /clusters/recompute, rebuilding the clusters and
+// refreshing the TF-IDF statistics, as enabled, in the background
 func (s *Server) handleRecomputeClusters(w http.ResponseWriter, r *http.Request) {
 	// Validate if the service supports recompute
-	if s.matchService == nil || s.config == nil || !s.config.Clustering.Enabled {
-		respondWithError(w, http.StatusBadRequest, "Clustering is not enabled in the current configuration")
+	if s.matchService == nil || s.config == nil || (!s.config.Clustering.Enabled && !s.config.TFIDF.Enabled) {
+		respondWithError(w, http.StatusBadRequest, "Neither clustering nor TF-IDF is enabled in the current configuration")
 		return
 	}
 
 	// Start recomputing clusters in a goroutine
 	go func() {
-		// TODO: Implement recompute functionality in match service
 		log.Printf("Started cluster recomputation in background")
-
-		// This would be implemented in the match service
-		// err := s.matchService.RecomputeClusters(context.Background())
-		// if err != nil {
-		//    log.Printf("Error recomputing clusters: %v", err)
-		// } else {
-		//    log.Printf("Successfully recomputed clusters for all entities")
-		// }
+		if err := s.matchService.RecomputeClusters(context.Background()); err != nil {
+			log.Printf("Error recomputing clusters: %v", err)
+		} else {
+			log.Printf("Successfully recomputed clusters for all entities")
+		}
 	}()
 
 	// Return immediately with 202 Accepted
@@ -597,6 +877,210 @@ func (s *Server) handleRecomputeClusters(w http.ResponseWriter, r *http.Request)
 	})
 }
 
+// Resolved entity handlers
+
+// handleGetResolvedEntity handles GET /resolved/{id}
+func (s *Server) handleGetResolvedEntity(w http.ResponseWriter, r *http.Request) {
+	// Get resolved ID from path
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	// Get the member records
+	resolved, err := s.matchService.GetResolvedEntity(r.Context(), id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Resolved entity not found: "+err.Error())
+		return
+	}
+
+	// Return resolved entity
+	respondWithJSON(w, http.StatusOK, resolved)
+}
+
+// handleRecomputeResolved handles POST /resolved/recompute, rebuilding the resolved
+// entities from the accepted matches while keeping existing IDs where they overlap
+func (s *Server) handleRecomputeResolved(w http.ResponseWriter, r *http.Request) {
+	stats, err := s.matchService.RecomputeResolved(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to recompute resolved entities: "+err.Error())
+		return
+	}
+
+	// Return recomputation statistics
+	respondWithJSON(w, http.StatusOK, stats)
+}
+
+// Override handlers
+
+// handleListOverrides handles GET /overrides
+func (s *Server) handleListOverrides(w http.ResponseWriter, r *http.Request) {
+	overrides, err := s.matchService.ListOverrides()
+	if err != nil {
+		respondWithError(w, overrideErrorStatus(err), "Failed to list overrides: "+err.Error())
+		return
+	}
+
+	// Return overrides
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"overrides": overrides,
+		"count":     len(overrides),
+	})
+}
+
+// handleAddOverride handles POST /overrides, recording a must_link, cannot_link or split
+func (s *Server) handleAddOverride(w http.ResponseWriter, r *http.Request) {
+	// Parse request
+	var o override.Override
+	if err := json.NewDecoder(r.Body).Decode(&o); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload: "+err.Error())
+		return
+	}
+
+	// Record override; when only re-resolution failed the override stands, so report it
+	// as recorded with a warning instead of inviting a retry
+	o, err := s.matchService.AddOverride(r.Context(), o)
+	warning := reresolveWarning(err)
+	if err != nil && warning == "" {
+		respondWithError(w, overrideErrorStatus(err), "Failed to add override: "+err.Error())
+		return
+	}
+
+	// Return recorded override
+	respondWithJSON(w, http.StatusCreated, struct {
+		override.Override
+		Warning string `json:"warning,omitempty"`
+	}{o, warning})
+}
+
+// handleRemoveOverride handles DELETE /overrides/{id}
+func (s *Server) handleRemoveOverride(w http.ResponseWriter, r *http.Request) {
+	// Get ID from path
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	// Remove override
+	o, err := s.matchService.RemoveOverride(r.Context(), id)
+	warning := reresolveWarning(err)
+	if err != nil && warning == "" {
+		respondWithError(w, overrideErrorStatus(err), "Failed to remove override: "+err.Error())
+		return
+	}
+
+	// Return removed override
+	response := map[string]interface{}{"status": "removed", "override": o}
+	if warning != "" {
+		response["warning"] = warning
+	}
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// reresolveWarning returns the message of an error that left an override saved but the
+// resolved entities stale, or "" for any other error
+func reresolveWarning(err error) string {
+	if errors.Is(err, match.ErrReresolveFailed) {
+		log.Printf("Warning: %v", err)
+		return err.Error() + "; run resolve --recompute-resolved"
+	}
+	return ""
+}
+
+// overrideErrorStatus maps an override error to an HTTP status code
+func overrideErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, override.ErrInvalidOverride), errors.Is(err, match.ErrOverridesDisabled):
+		return http.StatusBadRequest
+	case errors.Is(err, override.ErrNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// Review handlers
+
+// handleListReviewPairs handles GET /review, listing pending pairs unless ?status= names
+// another status or is "all"
+func (s *Server) handleListReviewPairs(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = review.Pending
+	case "all":
+		status = ""
+	case review.Pending, review.Accepted, review.Rejected:
+	default:
+		respondWithError(w, http.StatusBadRequest, "Invalid status: "+status)
+		return
+	}
+
+	pairs, err := s.matchService.ListReviewPairs(status)
+	if err != nil {
+		respondWithError(w, reviewErrorStatus(err), "Failed to list review pairs: "+err.Error())
+		return
+	}
+
+	// Return review pairs
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"pairs": pairs,
+		"count": len(pairs),
+	})
+}
+
+// handleDecideReviewPair returns the handler of POST /review/{id}/accept or /review/{id}/reject
+func (s *Server) handleDecideReviewPair(accept bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Get ID from path
+		vars := mux.Vars(r)
+		id := vars["id"]
+
+		// Parse request
+		var decision review.Decision
+		if err := json.NewDecoder(r.Body).Decode(&decision); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid request payload: "+err.Error())
+			return
+		}
+
+		// Decide pair
+		pair, err := s.matchService.DecideReviewPair(r.Context(), id, accept, decision)
+		warning := reresolveWarning(err)
+		if err != nil && warning == "" {
+			respondWithError(w, reviewErrorStatus(err), "Failed to decide review pair: "+err.Error())
+			return
+		}
+
+		// Return decided pair
+		respondWithJSON(w, http.StatusOK, struct {
+			review.Pair
+			Warning string `json:"warning,omitempty"`
+		}{pair, warning})
+	}
+}
+
+// handleExportReviewLabels handles GET /review/export, returning the decided pairs as a
+// CSV of id_a,id_b,label rows for "resolve train --labels"
+func (s *Server) handleExportReviewLabels(w http.ResponseWriter, r *http.Request) {
+	var labels bytes.Buffer
+	if err := s.matchService.ExportReviewLabels(&labels); err != nil {
+		respondWithError(w, reviewErrorStatus(err), "Failed to export review labels: "+err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.WriteHeader(http.StatusOK)
+	w.Write(labels.Bytes())
+}
+
+// reviewErrorStatus maps a review error to an HTTP status code
+func reviewErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, review.ErrInvalidDecision), errors.Is(err, match.ErrReviewDisabled):
+		return http.StatusBadRequest
+	case errors.Is(err, review.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, review.ErrAlreadyDecided):
+		return http.StatusConflict
+	}
+	return overrideErrorStatus(err)
+}
+
 // Response helpers
 
 // respondWithError responds with an error
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TFMV/resolve/internal/cluster"
+	"github.com/TFMV/resolve/internal/config"
+	"github.com/TFMV/resolve/internal/dedupe"
+	"github.com/TFMV/resolve/internal/eval"
+	"github.com/TFMV/resolve/internal/match"
+	"github.com/TFMV/resolve/internal/normalize"
+	"github.com/TFMV/resolve/internal/store"
+)
+
+// runBlockingReport handles "resolve blocking-report", which runs the configured blocker
+// over the stored entities or an input file and reports block sizes, candidate pairs and
+// pair completeness
+func runBlockingReport(args []string) {
+	fs := flag.NewFlagSet("blocking-report", flag.ExitOnError)
+	configPath := fs.String("config", defaultConfigPath, "Path to configuration file")
+	storeType := fs.String("store", "weaviate", "Vector store to read entities from when --input is not set: weaviate or memory")
+	inputPath := fs.String("input", "", "CSV or JSON entity file to block instead of the stored entities")
+	idColumn := fs.String("id-column", "id", "CSV column holding record IDs; rows are numbered if it is absent")
+	truthPath := fs.String("truth", "", "CSV mapping record IDs to true cluster IDs (record_id,cluster_id) for pair completeness")
+	blocking := fs.String("blocking", dedupe.BlockingKey, "Blocking method: key or sorted_neighborhood")
+	maxBlockSize := fs.Int("max-block-size", 0, "Cap or split key blocks larger than this (default no limit)")
+	oversized := fs.String("oversized", cluster.OversizedCap, "Oversized key blocks: cap (sliding window over sorted names) or split")
+	window := fs.Int("window", 0, "Number of following records compared by sorted_neighborhood blocking (default from config)")
+	largest := fs.Int("largest", 10, "Number of largest blocks to list")
+	samples := fs.Int("samples", 3, "Number of sample record IDs per listed block and of missed true pairs")
+	jsonPath := fs.String("json", "", "Path to write the full report as JSON (- for stdout)")
+	fs.Parse(args)
+
+	// Load configuration
+	cfg := loadConfig(*configPath)
+	ctx := context.Background()
+
+	// Load the records
+	var ids []string
+	var records []map[string]string
+	if *inputPath != "" {
+		ids, records = readBlockingInput(cfg, *inputPath, *idColumn)
+	} else {
+		ids, records = readStoredEntities(ctx, cfg, *storeType)
+	}
+
+	// Read the ground truth
+	opts := eval.ReportOptions{Largest: *largest, Samples: *samples}
+	if *truthPath != "" {
+		file, err := os.Open(*truthPath)
+		if err != nil {
+			log.Fatalf("Error opening truth file: %v", err)
+		}
+		opts.Truth, err = eval.ReadTruth(file)
+		file.Close()
+		if err != nil {
+			log.Fatalf("Error parsing truth file: %v", err)
+		}
+	}
+
+	// Without a limit every block is compared in full
+	if *maxBlockSize <= 0 {
+		*maxBlockSize = len(records) + 1
+	}
+	blocker, err := dedupe.NewBlocker(cfg, dedupe.Options{
+		Blocking:     *blocking,
+		MaxBlockSize: *maxBlockSize,
+		Oversized:    *oversized,
+		Window:       *window,
+	})
+	if err != nil {
+		log.Fatalf("Error creating blocker: %v", err)
+	}
+
+	// Block the records
+	log.Printf("Blocking %d records", len(records))
+	startTime := time.Now()
+
+	report, err := eval.EvaluateBlocking(ctx, blocker, ids, records, opts)
+	if err != nil {
+		log.Fatalf("Error blocking records: %v", err)
+	}
+	log.Printf("Blocked %d records in %.2f seconds", report.Records, time.Since(startTime).Seconds())
+
+	// Write the report
+	if *jsonPath != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			log.Fatalf("Error encoding report: %v", err)
+		}
+		if *jsonPath == "-" {
+			fmt.Println(string(data))
+		} else if err := os.WriteFile(*jsonPath, data, 0644); err != nil {
+			log.Fatalf("Error writing report: %v", err)
+		}
+	}
+	if *jsonPath != "-" {
+		printBlockingReport(report)
+	}
+}
+
+// readBlockingInput reads and normalizes the records of a CSV or JSON entity file
+func readBlockingInput(cfg *config.Config, path, idColumn string) ([]string, []map[string]string) {
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("Error opening input file: %v", err)
+	}
+	defer file.Close()
+
+	var entities []match.EntityData
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		if err := json.NewDecoder(file).Decode(&entities); err != nil {
+			log.Fatalf("Error parsing input file: %v", err)
+		}
+	} else {
+		table, err := dedupe.ReadCSV(file, idColumn, cfg.SchemaFields())
+		if err != nil {
+			log.Fatalf("Error parsing input file: %v", err)
+		}
+		for _, record := range table.Records {
+			entities = append(entities, match.EntityData{ID: record.ID, Fields: record.Fields})
+		}
+	}
+
+	normalizer := normalize.NewNormalizer(cfg)
+	ids := make([]string, len(entities))
+	records := make([]map[string]string, len(entities))
+	for i, entity := range entities {
+		ids[i] = entity.ID
+		records[i] = normalizer.NormalizeEntity(entity.Fields)
+	}
+	return ids, records
+}
+
+// readStoredEntities reads the fields of every stored entity, which are already normalized
+func readStoredEntities(ctx context.Context, cfg *config.Config, storeType string) ([]string, []map[string]string) {
+	vectorStore, err := newVectorStore(ctx, cfg, storeType)
+	if err != nil {
+		log.Fatalf("Error initializing vector store: %v", err)
+	}
+
+	var ids []string
+	var records []map[string]string
+	err = store.ScanEntities(ctx, vectorStore, store.ListOptions{Limit: 100}, func(entities []*store.EntityRecord) error {
+		for _, entity := range entities {
+			ids = append(ids, entity.ID)
+			records = append(records, entity.Fields)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("Error listing entities: %v", err)
+	}
+	return ids, records
+}
+
+// printBlockingReport prints the block size histogram, the largest blocks and the pair statistics
+func printBlockingReport(report *eval.BlockingReport) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	if report.Histogram != nil {
+		fmt.Fprintln(w, "BLOCK SIZE\tBLOCKS\tRECORDS")
+		for _, bucket := range report.Histogram {
+			size := fmt.Sprintf("%d-%d", bucket.Min, bucket.Max)
+			switch {
+			case bucket.Max == 0:
+				size = fmt.Sprintf("%d+", bucket.Min)
+			case bucket.Min == bucket.Max:
+				size = fmt.Sprintf("%d", bucket.Min)
+			}
+			fmt.Fprintf(w, "%s\t%d\t%d\n", size, bucket.Blocks, bucket.Records)
+		}
+		fmt.Fprintln(w)
+
+		fmt.Fprintln(w, "LARGEST BLOCK\tSIZE\tSAMPLES")
+		for _, block := range report.Largest {
+			fmt.Fprintf(w, "%s\t%d\t%s\n", block.Key, block.Size, strings.Join(block.Samples, ", "))
+		}
+		fmt.Fprintln(w)
+	}
+	w.Flush()
+
+	fmt.Printf("Records:           %d\n", report.Records)
+	fmt.Printf("Blocks:            %d\n", report.Blocks)
+	if report.DefaultBlock > 0 {
+		fmt.Printf("Default block:     %d records have no blocking key\n", report.DefaultBlock)
+	}
+	fmt.Printf("Candidate pairs:   %d of %d\n", report.CandidatePairs, report.TotalPairs)
+	fmt.Printf("Reduction ratio:   %.4f\n", report.ReductionRatio)
+	if report.HasTruth {
+		fmt.Printf("Pair completeness: %.4f (%d of %d true pairs)\n", report.PairCompleteness, report.FoundTruePairs, report.TruePairs)
+		fmt.Printf("Pair quality:      %.4f\n", report.PairQuality)
+		for _, pair := range report.MissedExamples {
+			fmt.Printf("  missed: %s - %s\n", pair[0], pair[1])
+		}
+	}
+}
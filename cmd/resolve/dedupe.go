@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/TFMV/resolve/internal/cluster"
+	"github.com/TFMV/resolve/internal/dedupe"
+	"github.com/TFMV/resolve/internal/embed"
+	"github.com/TFMV/resolve/internal/override"
+)
+
+// runDedupe handles "resolve dedupe input.csv", which clusters the duplicate records of
+// a CSV file in process, without a vector database
+func runDedupe(args []string) {
+	fs := flag.NewFlagSet("dedupe", flag.ExitOnError)
+	configPath := fs.String("config", defaultConfigPath, "Path to configuration file")
+	outPath := fs.String("out", "clusters.csv", "Path to write records with their cluster IDs (- for stdout)")
+	idColumn := fs.String("id-column", "id", "Input column holding record IDs; rows are numbered if it is absent")
+	threshold := fs.Float64("threshold", 0, "Minimum pair score to link two records (default from config)")
+	blocking := fs.String("blocking", dedupe.BlockingKey, "Blocking method: key or sorted_neighborhood")
+	maxBlockSize := fs.Int("max-block-size", dedupe.DefaultMaxBlockSize, "Larger key blocks are capped or split to this size")
+	oversized := fs.String("oversized", cluster.OversizedCap, "Oversized key blocks: cap (sliding window over sorted names) or split")
+	window := fs.Int("window", 0, "Number of following records compared by sorted_neighborhood blocking (default from config)")
+	useEmbeddings := fs.Bool("embeddings", false, "Blend in embedding similarity from the configured embedding service")
+	embeddingWeight := fs.Float64("embedding-weight", dedupe.DefaultEmbeddingWeight, "Share of the pair score taken by embedding similarity")
+	overridesPath := fs.String("overrides", "", "Overrides file whose must-link, cannot-link and split overrides are honored (default from config)")
+	fs.Parse(args)
+
+	// Allow the input file before the flags
+	if fs.NArg() > 0 && len(args) > 0 && args[0] == fs.Arg(0) {
+		fs.Parse(args[1:])
+		args = append([]string{args[0]}, fs.Args()...)
+	} else {
+		args = fs.Args()
+	}
+	if len(args) != 1 {
+		log.Fatalf("Usage: resolve dedupe input.csv [--out clusters.csv]")
+	}
+	inputPath := args[0]
+
+	// Load configuration
+	cfg := loadConfig(*configPath)
+	if *threshold <= 0 {
+		*threshold = float64(cfg.Matching.SimilarityThreshold)
+	}
+
+	// Read the input
+	file, err := os.Open(inputPath)
+	if err != nil {
+		log.Fatalf("Error opening input file: %v", err)
+	}
+	table, err := dedupe.ReadCSV(file, *idColumn, cfg.SchemaFields())
+	file.Close()
+	if err != nil {
+		log.Fatalf("Error parsing input file: %v", err)
+	}
+
+	opts := dedupe.Options{
+		Threshold:       *threshold,
+		Blocking:        *blocking,
+		MaxBlockSize:    *maxBlockSize,
+		Oversized:       *oversized,
+		Window:          *window,
+		EmbeddingWeight: *embeddingWeight,
+	}
+	if *useEmbeddings {
+		opts.Embeddings = embed.NewHTTPClient(cfg)
+	}
+
+	// Honor the steward overrides
+	if *overridesPath == "" {
+		*overridesPath = cfg.Overrides.Path
+	}
+	if *overridesPath != "" {
+		opts.Constraints, err = override.NewStore(*overridesPath).Constraints()
+		if err != nil {
+			log.Fatalf("Error loading overrides: %v", err)
+		}
+	}
+
+	deduper, err := dedupe.NewDeduper(cfg, opts)
+	if err != nil {
+		log.Fatalf("Error creating deduper: %v", err)
+	}
+
+	// Deduplicate
+	log.Printf("Deduplicating %d records", len(table.Records))
+	startTime := time.Now()
+
+	result, err := deduper.Dedupe(context.Background(), table.Records)
+	if err != nil {
+		log.Fatalf("Error deduplicating records: %v", err)
+	}
+
+	duration := time.Since(startTime)
+	log.Printf("Compared %d pairs in %d blocks and found %d clusters in %.2f seconds",
+		result.Comparisons, result.Blocks, result.Clusters, duration.Seconds())
+
+	// Write the output
+	out := os.Stdout
+	if *outPath != "-" {
+		out, err = os.Create(*outPath)
+		if err != nil {
+			log.Fatalf("Error creating output file: %v", err)
+		}
+		defer out.Close()
+	}
+	if err := dedupe.WriteCSV(out, table, result); err != nil {
+		log.Fatalf("Error writing output: %v", err)
+	}
+	if *outPath != "-" {
+		log.Printf("Clusters written to %s", *outPath)
+	}
+}
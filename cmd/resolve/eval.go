@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TFMV/resolve/internal/eval"
+	"github.com/TFMV/resolve/internal/match"
+)
+
+// runEval handles "resolve eval", which scores the stored entities against a ground-truth
+// clustering and reports precision, recall and F1 over a range of thresholds
+func runEval(args []string) {
+	fs := flag.NewFlagSet("eval", flag.ExitOnError)
+	configPath := fs.String("config", defaultConfigPath, "Path to configuration file")
+	storeType := fs.String("store", "weaviate", "Vector store: weaviate or memory")
+	ingestPath := fs.String("ingest", "", "Path to JSON file with entities to ingest before evaluating")
+	truthPath := fs.String("truth", "", "CSV mapping record IDs to true cluster IDs (record_id,cluster_id)")
+	scorer := fs.String("scorer", "", "Scoring model: vector, fellegi_sunter or logistic (default from config)")
+	minThreshold := fs.Float64("min-threshold", 0.5, "Lowest threshold of the precision-recall curve")
+	step := fs.Float64("step", 0.01, "Threshold step of the precision-recall curve")
+	limit := fs.Int("limit", 50, "Maximum number of candidates scored per entity")
+	jsonPath := fs.String("json", "", "Path to write the full report as JSON (- for stdout)")
+	minF1 := fs.Float64("min-f1", 0, "Exit with status 1 if the pairwise F1 at the recommended threshold is below this")
+	fs.Parse(args)
+
+	if *truthPath == "" {
+		log.Fatalf("--truth is required")
+	}
+
+	// Read the ground truth
+	file, err := os.Open(*truthPath)
+	if err != nil {
+		log.Fatalf("Error opening truth file: %v", err)
+	}
+	truth, err := eval.ReadTruth(file)
+	file.Close()
+	if err != nil {
+		log.Fatalf("Error parsing truth file: %v", err)
+	}
+
+	// Load configuration and services
+	cfg := loadConfig(*configPath)
+	ctx := context.Background()
+	matchService := newMatchService(ctx, cfg, *storeType)
+
+	// Optionally load entities first, e.g. into the in-memory store
+	if *ingestPath != "" {
+		processIngest(ctx, matchService, *ingestPath)
+	}
+
+	// Score every candidate pair once at the lowest threshold
+	log.Printf("Scoring candidate pairs")
+	startTime := time.Now()
+
+	// A zero threshold means the configured default to the matcher, so keep every pair instead
+	threshold := float32(*minThreshold)
+	if threshold <= 0 {
+		threshold = match.NoThreshold
+	}
+	ids, scored, err := matchService.ScoreAllPairs(ctx, match.Options{
+		Limit:     *limit,
+		Threshold: threshold,
+		Scorer:    *scorer,
+	})
+	if err != nil {
+		log.Fatalf("Error scoring pairs: %v", err)
+	}
+
+	pairs := make([]eval.ScoredPair, len(scored))
+	for i, pair := range scored {
+		pairs[i] = eval.ScoredPair{A: pair.IDA, B: pair.IDB, Score: float64(pair.Score)}
+	}
+
+	report := eval.Evaluate(ids, pairs, truth, eval.Thresholds(*minThreshold, *step))
+	log.Printf("Scored %d pairs between %d labeled entities in %.2f seconds", report.Pairs, report.Records, time.Since(startTime).Seconds())
+	if report.Records == 0 {
+		log.Fatalf("No stored entity IDs appear in the truth file")
+	}
+
+	// Write the report
+	if *jsonPath != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			log.Fatalf("Error encoding report: %v", err)
+		}
+		if *jsonPath == "-" {
+			fmt.Println(string(data))
+		} else if err := os.WriteFile(*jsonPath, data, 0644); err != nil {
+			log.Fatalf("Error writing report: %v", err)
+		}
+	}
+	if *jsonPath != "-" {
+		printEvalReport(report)
+	}
+
+	// Gate on quality
+	if report.Recommended.Pairwise.F1 < *minF1 {
+		log.Printf("Pairwise F1 %.4f is below the minimum %.4f", report.Recommended.Pairwise.F1, *minF1)
+		os.Exit(1)
+	}
+}
+
+// printEvalReport prints the precision-recall curve and the recommended threshold
+func printEvalReport(report eval.Report) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "THRESHOLD\tPAIR P\tPAIR R\tPAIR F1\tB3 P\tB3 R\tB3 F1\tCLUSTER P\tCLUSTER R\tCLUSTER F1\tCLUSTERS")
+	for _, m := range report.Curve {
+		fmt.Fprintf(w, "%.2f\t%s\t%s\t%s\t%d/%d\n", m.Threshold, formatPRF(m.Pairwise), formatPRF(m.BCubed), formatPRF(m.Cluster), m.PredictedClusters, m.TrueClusters)
+	}
+	w.Flush()
+
+	best := report.Recommended
+	fmt.Printf("\nRecommended threshold: %.2f\n", best.Threshold)
+	fmt.Printf("  pairwise  precision %.4f  recall %.4f  f1 %.4f\n", best.Pairwise.Precision, best.Pairwise.Recall, best.Pairwise.F1)
+	fmt.Printf("  b-cubed   precision %.4f  recall %.4f  f1 %.4f\n", best.BCubed.Precision, best.BCubed.Recall, best.BCubed.F1)
+	fmt.Printf("  cluster   precision %.4f  recall %.4f  f1 %.4f\n", best.Cluster.Precision, best.Cluster.Recall, best.Cluster.F1)
+}
+
+// formatPRF formats precision, recall and F1 as tab-separated columns
+func formatPRF(prf eval.PRF) string {
+	return fmt.Sprintf("%.4f\t%.4f\t%.4f", prf.Precision, prf.Recall, prf.F1)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/TFMV/resolve/internal/generate"
+)
+
+// runGen handles "resolve gen", which writes a synthetic dataset of seed entities and
+// corrupted duplicates along with its ground-truth clustering
+func runGen(args []string) {
+	defaults := generate.DefaultCorruption()
+
+	fs := flag.NewFlagSet("gen", flag.ExitOnError)
+	configPath := fs.String("config", defaultConfigPath, "Path to configuration file (for the entity schema)")
+	outPath := fs.String("out", "entities.json", "Path to write the generated entities as JSON")
+	truthPath := fs.String("truth", "truth.csv", "Path to write the ground truth (record_id,cluster_id,generated_id)")
+	seeds := fs.Int("seeds", generate.DefaultSeeds, "Number of distinct seed entities")
+	duplicateRate := fs.Float64("duplicate-rate", generate.DefaultDuplicateRate, "Fraction of seeds that receive duplicates")
+	maxDuplicates := fs.Int("max-duplicates", generate.DefaultMaxDuplicates, "Maximum duplicates per duplicated seed")
+	seed := fs.Int64("seed", 1, "Random seed")
+	typo := fs.Float64("typo", defaults.Typo, "Probability of a typo in a duplicate")
+	abbreviate := fs.Float64("abbreviate", defaults.Abbreviate, "Probability of abbreviating a name or address word")
+	legalSuffix := fs.Float64("legal-suffix", defaults.LegalSuffix, "Probability of changing the legal suffix")
+	phoneFormat := fs.Float64("phone-format", defaults.PhoneFormat, "Probability of reformatting the phone number")
+	missing := fs.Float64("missing", defaults.Missing, "Probability of dropping a field")
+	swapTokens := fs.Float64("swap-tokens", defaults.SwapTokens, "Probability of swapping two name tokens")
+	addressUnit := fs.Float64("address-unit", defaults.AddressUnit, "Probability of changing the address unit")
+	fs.Parse(args)
+
+	cfg := loadConfig(*configPath)
+	generator := generate.NewGenerator(generate.Options{
+		Seeds:         *seeds,
+		DuplicateRate: *duplicateRate,
+		MaxDuplicates: *maxDuplicates,
+		Fields:        cfg.SchemaFields(),
+		Seed:          *seed,
+		Corruption: generate.Corruption{
+			Typo:        *typo,
+			Abbreviate:  *abbreviate,
+			LegalSuffix: *legalSuffix,
+			PhoneFormat: *phoneFormat,
+			Missing:     *missing,
+			SwapTokens:  *swapTokens,
+			AddressUnit: *addressUnit,
+		},
+	})
+
+	// Create output files
+	outFile, err := os.Create(*outPath)
+	if err != nil {
+		log.Fatalf("Error creating output file: %v", err)
+	}
+	defer outFile.Close()
+	truthFile, err := os.Create(*truthPath)
+	if err != nil {
+		log.Fatalf("Error creating truth file: %v", err)
+	}
+	defer truthFile.Close()
+
+	out := bufio.NewWriter(outFile)
+	truth := csv.NewWriter(truthFile)
+	truth.Write([]string{"record_id", "cluster_id", generate.GeneratedIDMetadataKey})
+
+	log.Printf("Generating %d seed entities", *seeds)
+	startTime := time.Now()
+
+	// Stream records as a JSON array
+	records := 0
+	out.WriteString("[\n")
+	err = generator.Generate(func(record generate.Record, clusterID string) error {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("failed to encode record %s: %w", record.ID, err)
+		}
+		if records > 0 {
+			out.WriteString(",\n")
+		}
+		out.WriteString("  ")
+		out.Write(data)
+		records++
+
+		return truth.Write([]string{record.ID, clusterID, record.Metadata[generate.GeneratedIDMetadataKey].(string)})
+	})
+	if err != nil {
+		log.Fatalf("Error generating records: %v", err)
+	}
+	out.WriteString("\n]\n")
+
+	// Flush output
+	if err := out.Flush(); err != nil {
+		log.Fatalf("Error writing output file: %v", err)
+	}
+	truth.Flush()
+	if err := truth.Error(); err != nil {
+		log.Fatalf("Error writing truth file: %v", err)
+	}
+
+	duration := time.Since(startTime)
+	log.Printf("Wrote %d records (%d duplicates) to %s and ground truth to %s in %.2f seconds",
+		records, records-*seeds, *outPath, *truthPath, duration.Seconds())
+}
@@ -12,6 +12,8 @@ import (
 	"github.com/TFMV/resolve/internal/config"
 	"github.com/TFMV/resolve/internal/embed"
 	"github.com/TFMV/resolve/internal/match"
+	"github.com/TFMV/resolve/internal/similarity"
+	"github.com/TFMV/resolve/internal/store"
 	"github.com/TFMV/resolve/internal/weaviate"
 )
 
@@ -31,13 +33,35 @@ var (
 	withDetails       bool
 	showHelp          bool
 	recomputeClusters bool
+	recomputeResolved bool
 	groupID           string
 	groupStrategy     string
 	groupHopsLimit    int
 	fieldScores       bool
+	storeType         string
+	scorer            string
 )
 
+// commands maps subcommand names to their handlers. Each handler parses its own flags.
+var commands = map[string]func(args []string){
+	"train":           runTrain,
+	"eval":            runEval,
+	"gen":             runGen,
+	"dedupe":          runDedupe,
+	"blocking-report": runBlockingReport,
+	"override":        runOverride,
+	"review":          runReview,
+}
+
 func main() {
+	// Dispatch subcommands before parsing the global flags
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			command(os.Args[2:])
+			return
+		}
+	}
+
 	// Define command-line flags
 	flag.StringVar(&configPath, "config", defaultConfigPath, "Path to configuration file")
 	flag.BoolVar(&showVersion, "version", false, "Show version information")
@@ -48,11 +72,14 @@ func main() {
 	flag.IntVar(&limit, "limit", 0, "Maximum number of matches to return")
 	flag.BoolVar(&withDetails, "details", false, "Include match details")
 	flag.BoolVar(&showHelp, "help", false, "Show help information")
-	flag.BoolVar(&recomputeClusters, "recompute-clusters", false, "Recompute clusters for all entities")
+	flag.BoolVar(&recomputeClusters, "recompute-clusters", false, "Recompute clusters and TF-IDF statistics for all entities")
+	flag.BoolVar(&recomputeResolved, "recompute-resolved", false, "Recompute resolved entity IDs for all entities")
 	flag.StringVar(&groupID, "group", "", "Find match group for the specified entity ID")
-	flag.StringVar(&groupStrategy, "group-strategy", "direct", "Group strategy: direct, transitive, or hybrid")
+	flag.StringVar(&groupStrategy, "group-strategy", "direct", "Group strategy: direct, transitive, hybrid, or correlation")
 	flag.IntVar(&groupHopsLimit, "group-hops", 2, "Maximum number of hops for transitive matching")
 	flag.BoolVar(&fieldScores, "field-scores", false, "Enable field-level similarity scoring")
+	flag.StringVar(&storeType, "store", "weaviate", "Vector store: weaviate or memory")
+	flag.StringVar(&scorer, "scorer", "", "Scoring model: vector, fellegi_sunter or logistic (default from config)")
 	flag.Parse()
 
 	// Check for help flag
@@ -68,42 +95,19 @@ func main() {
 	}
 
 	// Ensure at least one command is specified
-	if ingestFile == "" && matchFile == "" && matchString == "" && !recomputeClusters && groupID == "" {
+	if ingestFile == "" && matchFile == "" && matchString == "" && !recomputeClusters && !recomputeResolved && groupID == "" {
 		log.Fatal("Error: No command specified. Use --help for usage information.")
 	}
 
 	// Load configuration
-	cfg, err := config.Load(configPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			log.Printf("Config file not found at %s, using defaults", configPath)
-			cfg = defaultConfig()
-		} else {
-			log.Fatalf("Error loading config: %v", err)
-		}
-	}
+	cfg := loadConfig(configPath)
 
 	// Set up context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	// Initialize embedding service
-	embeddingService := embed.NewHTTPClient(cfg)
-
-	// Initialize Weaviate client
-	weaviateClient, err := weaviate.NewClient(cfg, cfg.Embedding.EmbeddingDim)
-	if err != nil {
-		log.Fatalf("Error initializing Weaviate client: %v", err)
-	}
-
-	// Check connection to Weaviate
-	healthy, err := weaviateClient.Health(ctx)
-	if err != nil || !healthy {
-		log.Fatalf("Error connecting to Weaviate: %v", err)
-	}
-
 	// Initialize matching service
-	matchService := match.NewService(cfg, weaviateClient, embeddingService)
+	matchService := newMatchService(ctx, cfg, storeType)
 
 	// Process commands
 	if ingestFile != "" {
@@ -111,22 +115,99 @@ func main() {
 	}
 
 	if matchFile != "" {
-		processMatchFile(ctx, matchService, matchFile, threshold, limit, withDetails, fieldScores)
+		processMatchFile(ctx, matchService, matchFile, threshold, limit, withDetails, fieldScores, scorer)
 	}
 
 	if matchString != "" {
-		processMatchString(ctx, matchService, matchString, threshold, limit, withDetails, fieldScores)
+		processMatchString(ctx, matchService, matchString, threshold, limit, withDetails, fieldScores, scorer)
 	}
 
 	if recomputeClusters {
 		processRecomputeClusters(ctx, matchService)
 	}
 
+	if recomputeResolved {
+		processRecomputeResolved(ctx, matchService)
+	}
+
 	if groupID != "" {
 		processMatchGroup(ctx, matchService, groupID, threshold, groupStrategy, groupHopsLimit)
 	}
 }
 
+// loadConfig loads the configuration file, falling back to defaults if it does not exist
+func loadConfig(path string) *config.Config {
+	cfg, err := config.Load(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			log.Printf("Config file not found at %s, using defaults", path)
+			return defaultConfig()
+		}
+		log.Fatalf("Error loading config: %v", err)
+	}
+	return cfg
+}
+
+// newMatchService initializes the embedding service, vector store and matching service
+func newMatchService(ctx context.Context, cfg *config.Config, storeType string) *match.Service {
+	// Initialize embedding service
+	embeddingService := embed.NewHTTPClient(cfg)
+
+	// Initialize vector store
+	vectorStore, err := newVectorStore(ctx, cfg, storeType)
+	if err != nil {
+		log.Fatalf("Error initializing vector store: %v", err)
+	}
+
+	// Initialize matching service
+	matchService, err := match.NewService(cfg, vectorStore, embeddingService)
+	if err != nil {
+		log.Fatalf("Error initializing matching service: %v", err)
+	}
+
+	// Load the trained scoring model if configured
+	if cfg.Matching.ModelPath != "" {
+		if err := matchService.LoadModel(cfg.Matching.ModelPath); err != nil {
+			log.Fatalf("Error loading scoring model: %v", err)
+		}
+	}
+
+	// Load the TF-IDF statistics if enabled
+	if cfg.TFIDF.Enabled {
+		if err := matchService.LoadTFIDF(cfg.TFIDF.Path); err != nil {
+			log.Fatalf("Error loading TF-IDF statistics: %v", err)
+		}
+	}
+
+	return matchService
+}
+
+// newVectorStore creates the vector store selected on the command line
+func newVectorStore(ctx context.Context, cfg *config.Config, storeType string) (store.VectorStore, error) {
+	switch storeType {
+	case "memory":
+		// The in-memory store only lives for the duration of this process
+		log.Printf("Using in-memory vector store; data is not persisted between runs")
+		return store.NewMemoryStore(), nil
+	case "weaviate", "":
+		// Initialize Weaviate client
+		weaviateClient, err := weaviate.NewClient(cfg, cfg.Embedding.EmbeddingDim)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize Weaviate client: %w", err)
+		}
+
+		// Check connection to Weaviate
+		healthy, err := weaviateClient.Health(ctx)
+		if err != nil || !healthy {
+			return nil, fmt.Errorf("failed to connect to Weaviate: %v", err)
+		}
+
+		return weaviateClient, nil
+	default:
+		return nil, fmt.Errorf("unknown vector store: %s", storeType)
+	}
+}
+
 // processIngest processes entity ingestion
 func processIngest(ctx context.Context, matchService *match.Service, filePath string) {
 	// Read and parse the ingest file
@@ -145,7 +226,7 @@ func processIngest(ctx context.Context, matchService *match.Service, filePath st
 	startTime := time.Now()
 
 	// Process entities
-	err = matchService.AddEntities(ctx, entities)
+	_, err = matchService.AddEntities(ctx, entities)
 	if err != nil {
 		log.Fatalf("Error ingesting entities: %v", err)
 	}
@@ -156,7 +237,7 @@ func processIngest(ctx context.Context, matchService *match.Service, filePath st
 }
 
 // processMatchFile matches entities from a file
-func processMatchFile(ctx context.Context, matchService *match.Service, filePath string, threshold float64, limit int, withDetails bool, fieldScores bool) {
+func processMatchFile(ctx context.Context, matchService *match.Service, filePath string, threshold float64, limit int, withDetails bool, fieldScores bool, scorer string) {
 	// Read and parse the match file
 	data, err := os.ReadFile(filePath)
 	if err != nil {
@@ -174,6 +255,7 @@ func processMatchFile(ctx context.Context, matchService *match.Service, filePath
 		Limit:              limit,
 		IncludeDetails:     withDetails,
 		IncludeFieldScores: fieldScores,
+		Scorer:             scorer,
 	}
 
 	// Search for matches
@@ -197,13 +279,14 @@ func processMatchFile(ctx context.Context, matchService *match.Service, filePath
 }
 
 // processMatchString matches a string query
-func processMatchString(ctx context.Context, matchService *match.Service, queryString string, threshold float64, limit int, withDetails bool, fieldScores bool) {
+func processMatchString(ctx context.Context, matchService *match.Service, queryString string, threshold float64, limit int, withDetails bool, fieldScores bool, scorer string) {
 	// Set up match options
 	opts := match.Options{
 		Threshold:          float32(threshold),
 		Limit:              limit,
 		IncludeDetails:     withDetails,
 		IncludeFieldScores: fieldScores,
+		Scorer:             scorer,
 	}
 
 	// Search for matches
@@ -275,6 +358,24 @@ func processRecomputeClusters(ctx context.Context, matchService *match.Service)
 	log.Printf("Successfully recomputed clusters in %.2f seconds", duration.Seconds())
 }
 
+// processRecomputeResolved handles recomputing resolved entity IDs for all entities
+func processRecomputeResolved(ctx context.Context, matchService *match.Service) {
+	// Log start
+	log.Printf("Starting resolved entity recomputation for all entities")
+	startTime := time.Now()
+
+	// Recompute resolved entities
+	stats, err := matchService.RecomputeResolved(ctx)
+	if err != nil {
+		log.Fatalf("Error recomputing resolved entities: %v", err)
+	}
+
+	// Log completion
+	duration := time.Since(startTime)
+	log.Printf("Resolved %d entities into %d resolved entities (%d kept their ID, %d new, %d records changed) in %.2f seconds",
+		stats.Entities, stats.Resolved, stats.Kept, stats.Created, stats.Changed, duration.Seconds())
+}
+
 // printMatches outputs match results in JSON format
 func printMatches(matches []match.MatchResult) {
 	output, err := json.MarshalIndent(matches, "", "  ")
@@ -307,6 +408,8 @@ func defaultConfig() *config.Config {
 	// Matching defaults
 	cfg.Matching.SimilarityThreshold = 0.85
 	cfg.Matching.DefaultLimit = 10
+	cfg.Matching.MinGroupSimilarity = 0
+	cfg.Matching.NamePhoneticWeight = 0.2
 	cfg.Matching.FieldWeights = map[string]float32{
 		"name":    0.4,
 		"address": 0.2,
@@ -336,6 +439,31 @@ func defaultConfig() *config.Config {
 		"lowercase_domain": true,
 	}
 
+	// Survivorship defaults
+	cfg.Survivorship.DefaultRule = "most_frequent"
+	cfg.Survivorship.SourceField = "source"
+
+	// Override defaults
+	cfg.Overrides.Path = "overrides.json"
+
+	// Review defaults
+	cfg.Review.AutoMatch = 0.95
+	cfg.Review.AutoReject = 0.75
+	cfg.Review.Path = "review.json"
+
+	// TF-IDF defaults
+	cfg.TFIDF.Fields = []string{"name", "address"}
+	cfg.TFIDF.Threshold = similarity.DefaultSoftTFIDFThreshold
+	cfg.TFIDF.Path = "tfidf.json"
+
+	// Similarity defaults
+	cfg.Similarity.NameBlend = similarity.DefaultNameBlend
+	cfg.Similarity.AddressBlend = similarity.DefaultAddressBlend
+	cfg.Similarity.Numeric.RelativeScale = similarity.DefaultNumericRelativeScale
+	cfg.Similarity.Date.ToleranceDays = similarity.DefaultDateToleranceDays
+	cfg.Similarity.Date.YearTolerance = similarity.DefaultDateYearTolerance
+	cfg.Similarity.Year.Tolerance = similarity.DefaultYearTolerance
+
 	return cfg
 }
 
@@ -345,6 +473,16 @@ func printUsage() {
 	fmt.Println()
 	fmt.Println("Usage:")
 	fmt.Println("  resolve [flags]")
+	fmt.Println("  resolve <command> [flags]")
+	fmt.Println()
+	fmt.Println("Commands:")
+	fmt.Println("  train                      Train a scoring model from the stored entities")
+	fmt.Println("  eval                       Evaluate matching quality against ground-truth clusters")
+	fmt.Println("  gen                        Generate a synthetic dataset with ground truth")
+	fmt.Println("  dedupe                     Deduplicate a CSV file in process, without a vector database")
+	fmt.Println("  blocking-report            Report block sizes, candidate pairs and pair completeness")
+	fmt.Println("  override                   Force-link, force-separate or split records, list or remove overrides")
+	fmt.Println("  review                     List, accept or reject borderline pairs, export labeled pairs")
 	fmt.Println()
 	fmt.Println("Flags:")
 	fmt.Println("  --config string            Path to configuration file (default \"config.yaml\")")
@@ -355,10 +493,13 @@ func printUsage() {
 	fmt.Println("  --limit int                Maximum number of matches to return")
 	fmt.Println("  --details                  Include match details")
 	fmt.Println("  --field-scores             Include field-level similarity scores")
-	fmt.Println("  --recompute-clusters       Recompute clusters for all entities")
+	fmt.Println("  --recompute-clusters       Recompute clusters and TF-IDF statistics for all entities")
+	fmt.Println("  --recompute-resolved       Recompute resolved entity IDs for all entities")
 	fmt.Println("  --group string             Find match group for the specified entity ID")
-	fmt.Println("  --group-strategy string    Group strategy: direct, transitive, or hybrid (default \"direct\")")
+	fmt.Println("  --group-strategy string    Group strategy: direct, transitive, hybrid, or correlation (default \"direct\")")
 	fmt.Println("  --group-hops int           Maximum number of hops for transitive matching (default 2)")
+	fmt.Println("  --store string             Vector store: weaviate or memory (default \"weaviate\")")
+	fmt.Println("  --scorer string            Scoring model: vector, fellegi_sunter or logistic (default from config)")
 	fmt.Println("  --version                  Show version information")
 	fmt.Println("  --help                     Show this help information")
 	fmt.Println()
@@ -367,6 +508,22 @@ func printUsage() {
 	fmt.Println("  resolve --match-file query.json --threshold 0.8 --limit 5")
 	fmt.Println("  resolve --match \"Acme Corporation\" --threshold 0.7")
 	fmt.Println("  resolve --recompute-clusters")
+	fmt.Println("  resolve --recompute-resolved")
 	fmt.Println("  resolve --group entity-123 --group-strategy transitive --group-hops 3")
+	fmt.Println("  resolve --group entity-123 --group-strategy correlation")
 	fmt.Println("  resolve --match-file query.json --field-scores")
+	fmt.Println("  resolve --store memory --ingest entities.json --match-file query.json")
+	fmt.Println("  resolve --match-file query.json --scorer fellegi_sunter --field-scores")
+	fmt.Println("  resolve train --out model.json")
+	fmt.Println("  resolve train --labels pairs.csv --out model.json")
+	fmt.Println("  resolve eval --truth truth.csv --json report.json --min-f1 0.9")
+	fmt.Println("  resolve gen --seeds 10000 --out entities.json --truth truth.csv")
+	fmt.Println("  resolve dedupe input.csv --out clusters.csv")
+	fmt.Println("  resolve blocking-report --input entities.json --truth truth.csv")
+	fmt.Println("  resolve override link rec-1 rec-2 --reason \"same vendor after merger\"")
+	fmt.Println("  resolve override split --subset rec-1,rec-2 --subset rec-3 --reason \"different branches\"")
+	fmt.Println("  resolve review accept {pair_id} --reason \"same tax ID\"")
+	fmt.Println("  resolve review export --out labels.csv")
+	fmt.Println()
+	fmt.Println("Run 'resolve <command> --help' for command flags.")
 }
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/TFMV/resolve/internal/match"
+	"github.com/TFMV/resolve/internal/override"
+)
+
+// subsetFlags collects repeated --subset values
+type subsetFlags [][]string
+
+func (s *subsetFlags) String() string {
+	return fmt.Sprint(*s)
+}
+
+func (s *subsetFlags) Set(value string) error {
+	var subset []string
+	for _, id := range strings.Split(value, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			subset = append(subset, id)
+		}
+	}
+	*s = append(*s, subset)
+	return nil
+}
+
+// runOverride handles "resolve override", which records, lists and removes steward
+// overrides: must-links, cannot-links and splits
+func runOverride(args []string) {
+	if len(args) == 0 {
+		log.Fatalf("Usage: resolve override link|unlink|split|list|remove [flags]")
+	}
+	action := args[0]
+
+	fs := flag.NewFlagSet("override "+action, flag.ExitOnError)
+	configPath := fs.String("config", defaultConfigPath, "Path to configuration file")
+	storeType := fs.String("store", "weaviate", "Vector store holding the records: weaviate or memory")
+	author := fs.String("author", os.Getenv("USER"), "Who is making the override")
+	reason := fs.String("reason", "", "Why the override is made")
+	offline := fs.Bool("offline", false, "Only record the override, without checking or re-resolving the records in the vector store")
+	var subsets subsetFlags
+	fs.Var(&subsets, "subset", "Comma-separated record IDs kept together by a split (repeatable)")
+	fs.Parse(args[1:])
+
+	// Load configuration
+	cfg := loadConfig(*configPath)
+	if cfg.Overrides.Path == "" {
+		log.Fatalf("Error: overrides are not configured; set overrides.path")
+	}
+	ctx := context.Background()
+
+	// Build the override
+	o := override.Override{Author: *author, Reason: *reason}
+	switch action {
+	case "link", "unlink":
+		if fs.NArg() != 2 {
+			log.Fatalf("Usage: resolve override %s <record-id> <record-id> --reason <reason>", action)
+		}
+		o.Kind = override.MustLink
+		if action == "unlink" {
+			o.Kind = override.CannotLink
+		}
+		o.Records = fs.Args()
+	case "split":
+		o.Kind = override.Split
+		o.Subsets = subsets
+	case "list":
+		overrides, err := override.NewStore(cfg.Overrides.Path).List()
+		if err != nil {
+			log.Fatalf("Error listing overrides: %v", err)
+		}
+		printJSON(overrides)
+		return
+	case "remove":
+		if fs.NArg() != 1 {
+			log.Fatalf("Usage: resolve override remove <override-id>")
+		}
+		var removed override.Override
+		var err error
+		if *offline {
+			removed, err = override.NewStore(cfg.Overrides.Path).Remove(fs.Arg(0))
+		} else {
+			removed, err = newMatchService(ctx, cfg, *storeType).RemoveOverride(ctx, fs.Arg(0))
+		}
+		if err != nil && !warnReresolve(err) {
+			log.Fatalf("Error removing override: %v", err)
+		}
+		log.Printf("Removed %s override %s", removed.Kind, removed.ID)
+		return
+	default:
+		log.Fatalf("Unknown override action %q: expected link, unlink, split, list or remove", action)
+	}
+
+	// Record the override
+	var err error
+	if *offline {
+		o, err = override.NewStore(cfg.Overrides.Path).Add(o)
+	} else {
+		o, err = newMatchService(ctx, cfg, *storeType).AddOverride(ctx, o)
+	}
+	if err != nil && !warnReresolve(err) {
+		log.Fatalf("Error recording override: %v", err)
+	}
+	log.Printf("Recorded %s override %s in %s", o.Kind, o.ID, cfg.Overrides.Path)
+	printJSON(o)
+}
+
+// warnReresolve logs a warning and reports true if err only means the override was saved
+// without updating the resolved entities
+func warnReresolve(err error) bool {
+	if !errors.Is(err, match.ErrReresolveFailed) {
+		return false
+	}
+	log.Printf("Warning: %v; run resolve --recompute-resolved", err)
+	return true
+}
+
+// printJSON prints a value as indented JSON
+func printJSON(value interface{}) {
+	output, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		log.Fatalf("Error formatting results: %v", err)
+	}
+	fmt.Println(string(output))
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"log"
+	"os"
+
+	"github.com/TFMV/resolve/internal/review"
+)
+
+// runReview handles "resolve review", which lists, accepts and rejects borderline pairs in
+// the clerical review queue and exports the decisions as labeled training pairs
+func runReview(args []string) {
+	if len(args) == 0 {
+		log.Fatalf("Usage: resolve review list|accept|reject|export [flags]")
+	}
+	action := args[0]
+
+	fs := flag.NewFlagSet("review "+action, flag.ExitOnError)
+	configPath := fs.String("config", defaultConfigPath, "Path to configuration file")
+	storeType := fs.String("store", "weaviate", "Vector store holding the records: weaviate or memory")
+	status := fs.String("status", review.Pending, "Status of the pairs to list: pending, accepted, rejected or all")
+	reviewer := fs.String("reviewer", os.Getenv("USER"), "Who is deciding the pair")
+	reason := fs.String("reason", "", "Why the pair is accepted or rejected")
+	outPath := fs.String("out", "", "Labeled pairs CSV to write (default stdout)")
+	fs.Parse(args[1:])
+
+	// Load configuration
+	cfg := loadConfig(*configPath)
+	if !cfg.Review.Enabled {
+		log.Fatalf("Error: review is not enabled; set review.enabled")
+	}
+	queue := review.NewStore(cfg.Review.Path)
+
+	switch action {
+	case "list":
+		if *status == "all" {
+			*status = ""
+		}
+		pairs, err := queue.List(*status)
+		if err != nil {
+			log.Fatalf("Error listing review pairs: %v", err)
+		}
+		printJSON(pairs)
+	case "accept", "reject":
+		if fs.NArg() != 1 {
+			log.Fatalf("Usage: resolve review %s <pair-id> [--reason <reason>]", action)
+		}
+		ctx := context.Background()
+		matchService := newMatchService(ctx, cfg, *storeType)
+		pair, err := matchService.DecideReviewPair(ctx, fs.Arg(0), action == "accept", review.Decision{Reviewer: *reviewer, Reason: *reason})
+		if err != nil && !warnReresolve(err) {
+			log.Fatalf("Error deciding review pair: %v", err)
+		}
+		log.Printf("Pair %s/%s %s", pair.A, pair.B, pair.Status)
+		printJSON(pair)
+	case "export":
+		pairs, err := queue.List("")
+		if err != nil {
+			log.Fatalf("Error listing review pairs: %v", err)
+		}
+		var out io.Writer = os.Stdout
+		if *outPath != "" {
+			file, err := os.Create(*outPath)
+			if err != nil {
+				log.Fatalf("Error creating output file: %v", err)
+			}
+			defer file.Close()
+			out = file
+		}
+		if err := review.WriteLabels(out, pairs); err != nil {
+			log.Fatalf("Error exporting labels: %v", err)
+		}
+	default:
+		log.Fatalf("Unknown review action %q: expected list, accept, reject or export", action)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TFMV/resolve/internal/match"
+)
+
+// runTrain handles "resolve train", which fits scoring model parameters to the stored entities.
+// Without labels it estimates Fellegi-Sunter weights with EM; with --labels it fits a
+// logistic regression to the reviewed pairs.
+func runTrain(args []string) {
+	fs := flag.NewFlagSet("train", flag.ExitOnError)
+	configPath := fs.String("config", defaultConfigPath, "Path to configuration file")
+	storeType := fs.String("store", "weaviate", "Vector store: weaviate or memory")
+	ingestPath := fs.String("ingest", "", "Path to JSON file with entities to ingest before training")
+	outPath := fs.String("out", "model.json", "Path to write the trained model")
+	maxPairs := fs.Int("max-pairs", match.DefaultTrainMaxPairs, "Maximum number of blocked pairs to sample")
+	maxIterations := fs.Int("max-iterations", match.DefaultTrainMaxIterations, "Maximum number of EM iterations")
+	seed := fs.Int64("seed", 1, "Random seed for pair sampling and cross-validation folds")
+	labelsPath := fs.String("labels", "", "CSV of labeled pairs (id_a,id_b,label) for supervised training")
+	folds := fs.Int("folds", match.DefaultLogisticFolds, "Number of cross-validation folds for supervised training")
+	fs.Parse(args)
+
+	// Load configuration and services
+	cfg := loadConfig(*configPath)
+	ctx := context.Background()
+	matchService := newMatchService(ctx, cfg, *storeType)
+
+	// Optionally load entities first, e.g. into the in-memory store
+	if *ingestPath != "" {
+		processIngest(ctx, matchService, *ingestPath)
+	}
+
+	if *labelsPath != "" {
+		trainLogistic(ctx, matchService, *labelsPath, *outPath, *folds, *seed)
+		return
+	}
+
+	// Train the model
+	log.Printf("Training Fellegi-Sunter model with expectation-maximization")
+	startTime := time.Now()
+
+	artifact, err := matchService.TrainFellegiSunter(ctx, match.TrainOptions{
+		MaxPairs:      *maxPairs,
+		MaxIterations: *maxIterations,
+		Seed:          *seed,
+	})
+	if err != nil {
+		log.Fatalf("Error training model: %v", err)
+	}
+
+	duration := time.Since(startTime)
+	log.Printf("Trained on %d pairs from %d entities in %.2f seconds (%d iterations, converged: %t)",
+		artifact.Stats.Pairs, artifact.Stats.Entities, duration.Seconds(), artifact.Stats.Iterations, artifact.Stats.Converged)
+
+	// Save the model
+	if err := artifact.Save(*outPath); err != nil {
+		log.Fatalf("Error saving model: %v", err)
+	}
+	log.Printf("Model written to %s; set matching.model_path to use it", *outPath)
+
+	printFellegiSunterWeights(artifact.FellegiSunter)
+}
+
+// printFellegiSunterWeights prints the match weight of every field comparison level
+func printFellegiSunterWeights(model *match.FellegiSunterModel) {
+	fields := make([]string, 0, len(model.Fields))
+	for field := range model.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	fmt.Printf("Match prior: %.4f\n\n", model.Prior)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprint(w, "FIELD")
+	for _, level := range match.ComparisonLevels {
+		fmt.Fprintf(w, "\t%s", level)
+	}
+	fmt.Fprintln(w)
+
+	for _, field := range fields {
+		fmt.Fprint(w, field)
+		for _, level := range match.ComparisonLevels {
+			fmt.Fprintf(w, "\t%+.2f", model.LevelWeight(field, level))
+		}
+		fmt.Fprintln(w)
+	}
+	w.Flush()
+}
+
+// trainLogistic fits a logistic regression model to labeled pairs and saves it
+func trainLogistic(ctx context.Context, matchService *match.Service, labelsPath, outPath string, folds int, seed int64) {
+	// Read labeled pairs
+	file, err := os.Open(labelsPath)
+	if err != nil {
+		log.Fatalf("Error opening labels file: %v", err)
+	}
+	pairs, err := match.ReadLabeledPairs(file)
+	file.Close()
+	if err != nil {
+		log.Fatalf("Error parsing labels file: %v", err)
+	}
+
+	// Train the model
+	log.Printf("Training logistic regression model on %d labeled pairs", len(pairs))
+	startTime := time.Now()
+
+	artifact, err := matchService.TrainLogistic(ctx, pairs, match.LogisticOptions{
+		Folds: folds,
+		Seed:  seed,
+	})
+	if err != nil {
+		log.Fatalf("Error training model: %v", err)
+	}
+
+	duration := time.Since(startTime)
+	log.Printf("Trained on %d pairs from %d entities in %.2f seconds", artifact.Stats.Pairs, artifact.Stats.Entities, duration.Seconds())
+
+	// Save the model
+	if err := artifact.Save(outPath); err != nil {
+		log.Fatalf("Error saving model: %v", err)
+	}
+	log.Printf("Model written to %s; set matching.model_path and matching.scorer: logistic to use it", outPath)
+
+	cv := artifact.Stats.CrossValidation
+	fmt.Printf("%d-fold cross-validation at threshold %.2f:\n", cv.Folds, cv.Threshold)
+	fmt.Printf("  precision  %.4f\n", cv.Precision)
+	fmt.Printf("  recall     %.4f\n", cv.Recall)
+	fmt.Printf("  f1         %.4f\n\n", cv.F1)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "FEATURE\tWEIGHT")
+	for i, feature := range artifact.Logistic.Features {
+		fmt.Fprintf(w, "%s\t%+.4f\n", feature, artifact.Logistic.Weights[i])
+	}
+	fmt.Fprintf(w, "bias\t%+.4f\n", artifact.Logistic.Bias)
+	w.Flush()
+}
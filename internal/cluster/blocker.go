@@ -0,0 +1,228 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/TFMV/resolve/internal/config"
+	"github.com/TFMV/resolve/internal/store"
+)
+
+// Batch blocking defaults
+const (
+	// DefaultMaxBlockSize is the block size above which KeyBlocker caps or splits blocks
+	DefaultMaxBlockSize = 1000
+	// DefaultWindow is the number of following records SortedNeighborhoodBlocker compares each record with
+	DefaultWindow = 10
+
+	// Ways of handling blocks larger than the maximum block size
+	OversizedCap   = "cap"   // Compare within a sliding window of the maximum block size
+	OversizedSplit = "split" // Split into consecutive sub-blocks of at most the maximum block size
+)
+
+// Blocker generates the candidate pairs of a batch of records for offline resolution
+type Blocker interface {
+	// Pairs calls visit once for every candidate pair of records, by index, and returns
+	// the number of blocks or passes the pairs were drawn from
+	Pairs(ctx context.Context, records []map[string]string, visit func(i, j int)) (int, error)
+}
+
+// BlockLister is implemented by blockers that partition records into blocks
+type BlockLister interface {
+	// Blocks returns the members of every block, by record index, keyed by "<pass>=<key>"
+	Blocks(ctx context.Context, records []map[string]string) (map[string][]int, error)
+}
+
+// KeyBlocker compares records sharing a block in any blocking pass of a clustering service
+type KeyBlocker struct {
+	service      *Service
+	maxBlockSize int
+	oversized    string
+}
+
+// NewKeyBlocker creates a key blocker. Blocks larger than maxBlockSize are capped to a
+// sliding window of that size over the sorted names or split into sub-blocks, depending
+// on oversized; a maxBlockSize below 2 uses the default.
+func NewKeyBlocker(service *Service, maxBlockSize int, oversized string) (*KeyBlocker, error) {
+	if maxBlockSize <= 1 {
+		maxBlockSize = DefaultMaxBlockSize
+	}
+	switch oversized {
+	case "":
+		oversized = OversizedCap
+	case OversizedCap, OversizedSplit:
+	default:
+		return nil, fmt.Errorf("unknown oversized block handling: %s", oversized)
+	}
+	return &KeyBlocker{service: service, maxBlockSize: maxBlockSize, oversized: oversized}, nil
+}
+
+// Blocks assigns every record as AssignCluster would and groups the records by the search
+// filters of every pass, so canopies and every LSH band are included. Records with no key share
+// the default block. Canopies are rebuilt from scratch on every call.
+func (b *KeyBlocker) Blocks(ctx context.Context, records []map[string]string) (map[string][]int, error) {
+	if b.service.isCanopy() {
+		b.service.resetCanopies()
+	}
+
+	blocks := make(map[string][]int)
+	for i, fields := range records {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		entity := &store.EntityRecord{Fields: fields}
+		if _, err := b.service.AssignCluster(ctx, entity); err != nil {
+			return nil, fmt.Errorf("failed to assign record %d: %w", i, err)
+		}
+
+		filters := b.service.clusterFilters(ctx, entity, 0)
+		if len(filters) == 0 {
+			key := ClusterMetadataKey + "=" + DefaultClusterID
+			blocks[key] = append(blocks[key], i)
+			continue
+		}
+		for _, filter := range filters {
+			for path, value := range filter {
+				key := strings.TrimPrefix(path, "metadata.") + "=" + value
+				blocks[key] = append(blocks[key], i)
+			}
+		}
+	}
+	return blocks, nil
+}
+
+// Pairs compares the records within every block
+func (b *KeyBlocker) Pairs(ctx context.Context, records []map[string]string, visit func(i, j int)) (int, error) {
+	blocks, err := b.Blocks(ctx, records)
+	if err != nil {
+		return 0, err
+	}
+
+	// Records sharing several blocks are compared once
+	memberships := 0
+	for _, members := range blocks {
+		memberships += len(members)
+	}
+	if memberships > len(records) {
+		visit = visitOnce(visit)
+	}
+
+	for _, members := range blocks {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		if len(members) <= b.maxBlockSize {
+			compareWindow(members, len(members), visit)
+			continue
+		}
+
+		// Oversized blocks are capped or split over the sorted names
+		sort.SliceStable(members, func(x, y int) bool {
+			return nameKey(records[members[x]]) < nameKey(records[members[y]])
+		})
+		if b.oversized == OversizedSplit {
+			for start := 0; start < len(members); start += b.maxBlockSize {
+				end := min(start+b.maxBlockSize, len(members))
+				compareWindow(members[start:end], b.maxBlockSize, visit)
+			}
+		} else {
+			compareWindow(members, b.maxBlockSize-1, visit)
+		}
+	}
+
+	return len(blocks), nil
+}
+
+// SortedNeighborhoodBlocker sorts the records by each sort key in turn and compares every
+// record with the next window records. The comparison count is linear in the number of
+// records, and near neighbors are found even when their keys differ slightly.
+type SortedNeighborhoodBlocker struct {
+	keys   []config.BlockingRule
+	window int
+}
+
+// NewSortedNeighborhoodBlocker creates a sorted-neighborhood blocker with one pass per sort
+// key. Without sort keys the records are sorted on the given fields.
+func NewSortedNeighborhoodBlocker(keys []config.BlockingRule, fields []string, window int) *SortedNeighborhoodBlocker {
+	if len(keys) == 0 {
+		if len(fields) == 0 {
+			fields = []string{"name"}
+		}
+		key := config.BlockingRule{Name: "default"}
+		for _, field := range fields {
+			key.Fields = append(key.Fields, config.BlockingField{Field: field})
+		}
+		keys = []config.BlockingRule{key}
+	}
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	return &SortedNeighborhoodBlocker{keys: keys, window: window}
+}
+
+// Pairs compares neighboring records in every pass. Records with no value for a
+// component of a sort key are left out of that pass.
+func (b *SortedNeighborhoodBlocker) Pairs(ctx context.Context, records []map[string]string, visit func(i, j int)) (int, error) {
+	// Records neighboring in several passes are compared once
+	if len(b.keys) > 1 {
+		visit = visitOnce(visit)
+	}
+
+	for _, key := range b.keys {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+
+		// Sort the records with a value for the key
+		members := make([]int, 0, len(records))
+		values := make([]string, len(records))
+		for i, fields := range records {
+			if value, ok := ruleValue(key, fields); ok {
+				members = append(members, i)
+				values[i] = value
+			}
+		}
+		sort.SliceStable(members, func(x, y int) bool {
+			return values[members[x]] < values[members[y]]
+		})
+
+		compareWindow(members, b.window, visit)
+	}
+
+	return len(b.keys), nil
+}
+
+// compareWindow visits every pair of members at most window positions apart
+func compareWindow(members []int, window int, visit func(i, j int)) {
+	for a := 0; a < len(members); a++ {
+		for b := a + 1; b < len(members) && b <= a+window; b++ {
+			visit(members[a], members[b])
+		}
+	}
+}
+
+// visitOnce wraps visit so that each unordered pair is visited once
+func visitOnce(visit func(i, j int)) func(i, j int) {
+	visited := make(map[[2]int]bool)
+	return func(i, j int) {
+		if i > j {
+			i, j = j, i
+		}
+		if visited[[2]int{i, j}] {
+			return
+		}
+		visited[[2]int{i, j}] = true
+		visit(i, j)
+	}
+}
+
+// nameKey orders records within an oversized block
+func nameKey(fields map[string]string) string {
+	if name := fields["name_normalized"]; name != "" {
+		return name
+	}
+	return fields["name"]
+}
@@ -0,0 +1,49 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	"github.com/TFMV/resolve/internal/config"
+)
+
+func TestSortedNeighborhoodBlocker(t *testing.T) {
+	ctx := context.Background()
+	records := []map[string]string{
+		{"name": "acme corp", "phone": "5551234567"},
+		{"name": "globex"},
+		{"name": "acme corporation", "phone": "5551234567"},
+		{"name": "initech", "phone": "5559876543"},
+		{"name": "acmee corp"},
+	}
+
+	keys := []config.BlockingRule{
+		{Name: "name", Fields: []config.BlockingField{{Field: "name"}}},
+		{Name: "phone", Fields: []config.BlockingField{{Field: "phone", Transform: "last_digits", Length: 4}}},
+	}
+	var pairs [][2]int
+	passes, err := NewSortedNeighborhoodBlocker(keys, nil, 1).Pairs(ctx, records, func(i, j int) {
+		pairs = append(pairs, [2]int{i, j})
+	})
+	if err != nil {
+		t.Fatalf("Pairs: %v", err)
+	}
+	if passes != 2 {
+		t.Errorf("expected 2 passes, got %d", passes)
+	}
+
+	// Name order: acme corp, acme corporation, acmee corp, globex, initech.
+	// Phone order: 1234 (0), 1234 (2), 6543 (3); the pair 0-2 is visited once.
+	want := map[[2]int]bool{{0, 2}: true, {2, 4}: true, {1, 4}: true, {1, 3}: true, {2, 3}: true}
+	if len(pairs) != len(want) {
+		t.Fatalf("expected %d pairs, got %v", len(want), pairs)
+	}
+	for _, pair := range pairs {
+		if pair[0] > pair[1] {
+			pair[0], pair[1] = pair[1], pair[0]
+		}
+		if !want[pair] {
+			t.Errorf("unexpected pair %v", pair)
+		}
+	}
+}
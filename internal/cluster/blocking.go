@@ -0,0 +1,172 @@
+package cluster
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/TFMV/resolve/internal/config"
+	"github.com/TFMV/resolve/internal/similarity"
+	"github.com/TFMV/resolve/internal/store"
+)
+
+// BlockMetadataPrefix prefixes the metadata keys holding each blocking rule's key
+const BlockMetadataPrefix = store.BlockMetadataPrefix
+
+// GenerateBlockingKeys returns the key of every configured blocking rule, keyed by
+// metadata key. Rules with a component the entity has no value for are skipped.
+func (s *Service) GenerateBlockingKeys(ctx context.Context, fields map[string]string) map[string]string {
+	if !s.config.Enabled || len(s.config.Rules) == 0 {
+		return nil
+	}
+
+	keys := make(map[string]string, len(s.config.Rules))
+	for _, rule := range s.config.Rules {
+		if key, ok := ruleKey(rule, fields); ok {
+			keys[BlockMetadataPrefix+rule.Name] = key
+		}
+	}
+	return keys
+}
+
+// BlockingKeys returns the keys of every blocking pass for the entity, keyed by metadata
+// key. With blocking rules configured there is one key per applicable rule, plus one per
+// band with the LSH method; otherwise the single cluster key is returned.
+func (s *Service) BlockingKeys(ctx context.Context, fields map[string]string) map[string]string {
+	if s.isLSH() {
+		keys := s.GenerateBlockingKeys(ctx, fields)
+		if keys == nil {
+			keys = make(map[string]string)
+		}
+		for band, bucket := range s.LSHBuckets(fields) {
+			keys[fmt.Sprintf("%s_%d", LSHMetadataKey, band)] = bucket
+		}
+		return keys
+	}
+	if len(s.config.Rules) > 0 {
+		return s.GenerateBlockingKeys(ctx, fields)
+	}
+
+	key := s.GenerateClusterKey(ctx, fields)
+	if key == DefaultClusterID {
+		return nil
+	}
+	return map[string]string{ClusterMetadataKey: key}
+}
+
+// GetClusterFiltersForEntity returns one search filter per blocking pass, read from the
+// keys stored in the entity metadata by AssignCluster. Candidates are the union of the
+// results of every filter. With canopy clustering there is one pass per canopy, up to
+// MaxClustersToSearch, and with LSH one per band, up to the configured MaxBandSearches,
+// in addition to any blocking rules.
+func (s *Service) GetClusterFiltersForEntity(ctx context.Context, entity *store.EntityRecord) []map[string]string {
+	return s.clusterFilters(ctx, entity, s.maxBandSearches())
+}
+
+// clusterFilters returns the filters of GetClusterFiltersForEntity, with one for each of
+// the first maxBands LSH bands, or for every band if maxBands is 0
+func (s *Service) clusterFilters(ctx context.Context, entity *store.EntityRecord, maxBands int) []map[string]string {
+	if !s.config.Enabled || entity.Metadata == nil {
+		return nil
+	}
+
+	var filters []map[string]string
+	if s.isCanopy() {
+		filters = canopyFilters(entity)
+	} else if s.isLSH() {
+		filters = lshFilters(entity, maxBands)
+	} else if len(s.config.Rules) == 0 {
+		if filter := s.GetClusterFilterForEntity(ctx, entity); filter != nil {
+			return []map[string]string{filter}
+		}
+		return nil
+	}
+
+	for _, rule := range s.config.Rules {
+		key := BlockMetadataPrefix + rule.Name
+		if value, ok := entity.Metadata[key].(string); ok && value != "" {
+			filters = append(filters, map[string]string{"metadata." + key: value})
+		}
+	}
+	return filters
+}
+
+// ruleKey builds the hashed key of a blocking rule
+func ruleKey(rule config.BlockingRule, fields map[string]string) (string, bool) {
+	value, ok := ruleValue(rule, fields)
+	if !ok {
+		return "", false
+	}
+
+	hash := md5.Sum([]byte(rule.Name + "|" + value))
+	return hex.EncodeToString(hash[:])[:16], true
+}
+
+// ruleValue concatenates the components of a blocking rule or sort key, or reports false
+// if the entity has no value for one of them
+func ruleValue(rule config.BlockingRule, fields map[string]string) (string, bool) {
+	var keyBuilder strings.Builder
+	for i, component := range rule.Fields {
+		value := fields[component.Field+"_normalized"]
+		if value == "" {
+			value = fields[component.Field]
+		}
+
+		part := blockingComponent(strings.TrimSpace(value), component)
+		if part == "" {
+			return "", false
+		}
+		if i > 0 {
+			keyBuilder.WriteString("|")
+		}
+		keyBuilder.WriteString(part)
+	}
+	return keyBuilder.String(), true
+}
+
+// blockingComponent reduces a field value with the component's transform
+func blockingComponent(value string, component config.BlockingField) string {
+	switch component.Transform {
+	case "prefix":
+		runes := []rune(value)
+		if len(runes) > component.Length {
+			runes = runes[:component.Length]
+		}
+		return string(runes)
+	case "suffix":
+		runes := []rune(value)
+		if len(runes) > component.Length {
+			runes = runes[len(runes)-component.Length:]
+		}
+		return string(runes)
+	case "digits":
+		return extractDigits(value)
+	case "last_digits":
+		digits := extractDigits(value)
+		if len(digits) < component.Length {
+			return "" // Too few digits to block on reliably
+		}
+		return digits[len(digits)-component.Length:]
+	case "first_token":
+		if tokens := strings.Fields(value); len(tokens) > 0 {
+			return tokens[0]
+		}
+		return ""
+	case "email_domain":
+		if at := strings.LastIndex(value, "@"); at >= 0 {
+			return value[at+1:]
+		}
+		return ""
+	case "email_local":
+		if at := strings.LastIndex(value, "@"); at > 0 {
+			return value[:at]
+		}
+		return ""
+	case "soundex", "metaphone", "nysiis":
+		return similarity.PhoneticKey(component.Transform, value)
+	default:
+		return value
+	}
+}
@@ -0,0 +1,93 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	"github.com/TFMV/resolve/internal/config"
+	"github.com/TFMV/resolve/internal/normalize"
+	"github.com/TFMV/resolve/internal/store"
+)
+
+func newBlockingService(rules []config.BlockingRule) *Service {
+	cfg := &config.Config{}
+	return NewService(&Config{Enabled: true, Fields: []string{"name", "zip"}, Rules: rules}, normalize.NewNormalizer(cfg))
+}
+
+var testRules = []config.BlockingRule{
+	{Name: "name_zip", Fields: []config.BlockingField{{Field: "name", Transform: "prefix", Length: 3}, {Field: "zip"}}},
+	{Name: "phone", Fields: []config.BlockingField{{Field: "phone", Transform: "last_digits", Length: 7}}},
+	{Name: "email", Fields: []config.BlockingField{{Field: "email", Transform: "email_domain"}, {Field: "email", Transform: "email_local"}}},
+}
+
+func TestGenerateBlockingKeys(t *testing.T) {
+	ctx := context.Background()
+	s := newBlockingService(testRules)
+
+	a := s.GenerateBlockingKeys(ctx, map[string]string{"name": "acme corp", "zip": "10001", "phone": "+1 555 123 4567", "email": "info@acme.com"})
+	b := s.GenerateBlockingKeys(ctx, map[string]string{"name": "akme corp", "zip": "10001", "phone": "(555) 123-4567"})
+
+	if len(a) != 3 {
+		t.Fatalf("expected 3 keys, got %v", a)
+	}
+	if _, ok := b[BlockMetadataPrefix+"email"]; ok {
+		t.Error("rule with a missing field should be skipped")
+	}
+	if a[BlockMetadataPrefix+"name_zip"] == b[BlockMetadataPrefix+"name_zip"] {
+		t.Error("expected different name keys after a typo in the prefix")
+	}
+	if a[BlockMetadataPrefix+"phone"] != b[BlockMetadataPrefix+"phone"] {
+		t.Error("expected equal phone keys for differently formatted numbers")
+	}
+}
+
+func TestAssignClusterStoresEveryPass(t *testing.T) {
+	ctx := context.Background()
+	s := newBlockingService(testRules)
+
+	entity := &store.EntityRecord{
+		Fields:   map[string]string{"name": "acme corp", "zip": "10001", "phone": "555-123-4567"},
+		Metadata: map[string]interface{}{BlockMetadataPrefix + "stale": "x"},
+	}
+	if _, err := s.AssignCluster(ctx, entity); err != nil {
+		t.Fatalf("AssignCluster: %v", err)
+	}
+	if _, ok := entity.Metadata[BlockMetadataPrefix+"stale"]; ok {
+		t.Error("stale blocking key was not removed")
+	}
+
+	filters := s.GetClusterFiltersForEntity(ctx, entity)
+	if len(filters) != 2 {
+		t.Fatalf("expected a filter for the name/zip and phone passes, got %v", filters)
+	}
+	if filters[0]["metadata."+BlockMetadataPrefix+"name_zip"] == "" || filters[1]["metadata."+BlockMetadataPrefix+"phone"] == "" {
+		t.Errorf("unexpected filters: %v", filters)
+	}
+}
+
+func TestBlockingKeysWithoutRules(t *testing.T) {
+	s := newBlockingService(nil)
+	keys := s.BlockingKeys(context.Background(), map[string]string{"name": "acme", "zip": "10001"})
+	if len(keys) != 1 || keys[ClusterMetadataKey] == "" {
+		t.Errorf("expected the single cluster key, got %v", keys)
+	}
+}
+
+func TestPhoneticBlocking(t *testing.T) {
+	ctx := context.Background()
+	s := newBlockingService([]config.BlockingRule{
+		{Name: "sound", Fields: []config.BlockingField{{Field: "name", Transform: "metaphone"}}},
+	})
+	a := s.GenerateBlockingKeys(ctx, map[string]string{"name": "catherine smith"})
+	b := s.GenerateBlockingKeys(ctx, map[string]string{"name": "kathryn smyth"})
+	if a[BlockMetadataPrefix+"sound"] != b[BlockMetadataPrefix+"sound"] {
+		t.Errorf("expected equal phonetic keys, got %v and %v", a, b)
+	}
+
+	s = newBlockingService(nil)
+	s.config.Phonetic = "soundex"
+	if s.GenerateClusterKey(ctx, map[string]string{"name": "smyth", "zip": "10001"}) !=
+		s.GenerateClusterKey(ctx, map[string]string{"name": "smith", "zip": "10001"}) {
+		t.Error("expected names that sound alike to share a cluster key")
+	}
+}
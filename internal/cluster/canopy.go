@@ -0,0 +1,414 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/TFMV/resolve/internal/store"
+)
+
+// Canopy metadata keys and defaults
+const (
+	// CanopyMetadataKey holds the IDs of the canopies an entity belongs to, most similar first
+	CanopyMetadataKey = "canopy_ids"
+	// CanopyCenterMetadataKey holds the canopy ID on the entity at the center of a canopy
+	CanopyCenterMetadataKey = "canopy_center"
+	// DefaultLooseThreshold is the loose canopy threshold used when no similarity threshold is configured
+	DefaultLooseThreshold = 0.5
+	// DefaultTightThreshold is the tight canopy threshold used when none is configured
+	DefaultTightThreshold = 0.9
+
+	// Canopy similarity measures
+	DistanceJaccard = "jaccard" // Token Jaccard similarity of the clustering fields
+	DistanceCosine  = "cosine"  // Cosine similarity of the entity vectors
+
+	canopyIDPrefix = "canopy-"
+)
+
+// canopy is a canopy center
+type canopy struct {
+	id     string
+	tokens map[string]bool
+	vector []float32
+}
+
+// canopyMatch is a canopy and its center's similarity to an entity
+type canopyMatch struct {
+	id         string
+	similarity float64
+}
+
+// isCanopy reports whether the service uses canopy clustering
+func (s *Service) isCanopy() bool {
+	return ClusterMethod(s.config.Method) == CanopyMethod
+}
+
+// IsCanopy reports whether the service uses canopy clustering
+func (s *Service) IsCanopy() bool {
+	return s.config.Enabled && s.isCanopy()
+}
+
+// thresholds returns the loose and tight canopy thresholds
+func (s *Service) thresholds() (float64, float64) {
+	loose := s.config.SimilarityThreshold
+	if loose <= 0 {
+		loose = DefaultLooseThreshold
+	}
+	tight := s.config.TightThreshold
+	if tight <= 0 {
+		tight = math.Max(DefaultTightThreshold, loose)
+	}
+	return loose, tight
+}
+
+// LoadCanopies reads the canopy centers from the store once, so that canopy
+// assignment continues across restarts. It does nothing for other methods.
+func (s *Service) LoadCanopies(ctx context.Context, client store.VectorStore) error {
+	if !s.IsCanopy() {
+		return nil
+	}
+
+	s.canopyMutex.Lock()
+	defer s.canopyMutex.Unlock()
+	if s.canopiesLoaded {
+		return nil
+	}
+
+	canopies, err := s.queryCanopies(ctx, client)
+	if errors.Is(err, store.ErrUnsupportedFilter) {
+		canopies, err = s.scanCanopies(ctx, client)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load canopy centers: %w", err)
+	}
+
+	s.canopies = canopies
+	s.canopiesLoaded = true
+	return nil
+}
+
+// queryCanopies reads the canopy centers with a metadata filter
+func (s *Service) queryCanopies(ctx context.Context, client store.VectorStore) ([]canopy, error) {
+	const pageSize = 100
+	var canopies []canopy
+	for offset := 0; ; offset += pageSize {
+		centers, err := client.QueryEntities(ctx, store.ListOptions{
+			Offset:        offset,
+			Limit:         pageSize,
+			Filters:       []store.Filter{{Path: "metadata." + CanopyCenterMetadataKey, Operator: store.OpLike, Value: canopyIDPrefix + "*"}},
+			IncludeVector: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		canopies = s.appendCenters(canopies, centers)
+		if len(centers) < pageSize {
+			return canopies, nil
+		}
+	}
+}
+
+// scanCanopies reads the canopy centers from every stored entity, for stores that cannot
+// filter on metadata
+func (s *Service) scanCanopies(ctx context.Context, client store.VectorStore) ([]canopy, error) {
+	var canopies []canopy
+	err := store.ScanEntities(ctx, client, store.ListOptions{Limit: 100, IncludeVector: true}, func(entities []*store.EntityRecord) error {
+		canopies = s.appendCenters(canopies, entities)
+		return nil
+	})
+	return canopies, err
+}
+
+// appendCenters appends a canopy for every entity at the center of one
+func (s *Service) appendCenters(canopies []canopy, entities []*store.EntityRecord) []canopy {
+	for _, entity := range entities {
+		if id, ok := entity.Metadata[CanopyCenterMetadataKey].(string); ok && strings.HasPrefix(id, canopyIDPrefix) {
+			canopies = append(canopies, s.newCanopy(id, entity))
+		}
+	}
+	return canopies
+}
+
+// resetCanopies forgets every canopy, so that the next stored entity starts a new one
+func (s *Service) resetCanopies() {
+	s.canopyMutex.Lock()
+	s.canopies = nil
+	s.canopiesLoaded = true
+	s.canopyMutex.Unlock()
+}
+
+// DropCanopy forgets the canopy with the given ID, such as one started by an entity that
+// could not be stored
+func (s *Service) DropCanopy(id string) {
+	s.canopyMutex.Lock()
+	defer s.canopyMutex.Unlock()
+
+	for i, center := range s.canopies {
+		if center.id == id {
+			s.canopies = append(s.canopies[:i], s.canopies[i+1:]...)
+			return
+		}
+	}
+}
+
+// assignCanopies records the canopies an entity belongs to and returns the most similar one.
+// A stored entity with no center within the tight threshold starts a new canopy.
+func (s *Service) assignCanopies(entity *store.EntityRecord, stored bool) string {
+	loose, tight := s.thresholds()
+	if entity.Metadata == nil {
+		entity.Metadata = make(map[string]interface{})
+	}
+
+	// Centers keep their own canopy
+	if id, ok := entity.Metadata[CanopyCenterMetadataKey].(string); ok && id != "" && stored {
+		matches := s.nearestCanopies(entity, loose)
+		entity.Metadata[CanopyMetadataKey] = canopyIDs(append([]canopyMatch{{id: id, similarity: 1}}, withoutCanopy(matches, id)...))
+		return id
+	}
+
+	// Check the tight threshold and start the canopy under one lock, so that concurrent
+	// entities cannot both start canopies where one should have joined the other's
+	var matches []canopyMatch
+	if stored {
+		s.canopyMutex.Lock()
+		matches = s.rankCanopies(entity, loose)
+		if len(matches) == 0 || matches[0].similarity < tight {
+			center := s.newCanopy(canopyIDPrefix+uuid.New().String(), entity)
+			s.canopies = append(s.canopies, center)
+
+			entity.Metadata[CanopyCenterMetadataKey] = center.id
+			matches = append([]canopyMatch{{id: center.id, similarity: 1}}, matches...)
+		}
+		s.canopyMutex.Unlock()
+	} else {
+		matches = s.nearestCanopies(entity, loose)
+	}
+
+	// Queries far from every canopy search the nearest one
+	if len(matches) == 0 {
+		if nearest := s.nearestCanopies(entity, math.Inf(-1)); len(nearest) > 0 {
+			matches = nearest[:1]
+		}
+	}
+
+	if len(matches) == 0 {
+		delete(entity.Metadata, CanopyMetadataKey)
+		return DefaultClusterID
+	}
+	entity.Metadata[CanopyMetadataKey] = canopyIDs(matches)
+	return matches[0].id
+}
+
+// nearestCanopies returns the canopies whose centers are at least minSimilarity similar
+// to the entity, most similar first
+func (s *Service) nearestCanopies(entity *store.EntityRecord, minSimilarity float64) []canopyMatch {
+	s.canopyMutex.RLock()
+	defer s.canopyMutex.RUnlock()
+	return s.rankCanopies(entity, minSimilarity)
+}
+
+// rankCanopies returns the canopies of nearestCanopies. Callers must hold the canopy lock.
+func (s *Service) rankCanopies(entity *store.EntityRecord, minSimilarity float64) []canopyMatch {
+	tokens := s.canopyTokens(entity.Fields)
+
+	var matches []canopyMatch
+	for _, center := range s.canopies {
+		similarity := s.canopySimilarity(tokens, entity.Vector, center.tokens, center.vector)
+		if similarity >= minSimilarity {
+			matches = append(matches, canopyMatch{id: center.id, similarity: similarity})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].similarity > matches[j].similarity
+	})
+	return matches
+}
+
+// recomputeCanopies rebuilds the canopies over every stored entity. Centers are picked in
+// store order; each canopy takes every remaining entity within the loose threshold, and
+// entities within the tight threshold can no longer become centers or join later canopies.
+func (s *Service) recomputeCanopies(ctx context.Context, client store.VectorStore, batchSize int) error {
+	// Load every entity
+	var entities []*store.EntityRecord
+	err := store.ScanEntities(ctx, client, store.ListOptions{Limit: batchSize, IncludeVector: true}, func(batch []*store.EntityRecord) error {
+		entities = append(entities, batch...)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list entities: %w", err)
+	}
+
+	loose, tight := s.thresholds()
+	tokens := make([]map[string]bool, len(entities))
+	for i, entity := range entities {
+		tokens[i] = s.canopyTokens(entity.Fields)
+	}
+
+	// Build canopies
+	memberships := make([][]canopyMatch, len(entities))
+	removed := make([]bool, len(entities))
+	var canopies []canopy
+	centerOf := make(map[int]string)
+	for i, entity := range entities {
+		if removed[i] {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		center := canopy{id: canopyIDPrefix + uuid.New().String(), tokens: tokens[i], vector: entity.Vector}
+		canopies = append(canopies, center)
+		centerOf[i] = center.id
+		memberships[i] = append(memberships[i], canopyMatch{id: center.id, similarity: 1})
+		removed[i] = true
+
+		for j := i + 1; j < len(entities); j++ {
+			if removed[j] {
+				continue
+			}
+			similarity := s.canopySimilarity(tokens[i], entity.Vector, tokens[j], entities[j].Vector)
+			if similarity >= loose {
+				memberships[j] = append(memberships[j], canopyMatch{id: center.id, similarity: similarity})
+			}
+			if similarity >= tight {
+				removed[j] = true
+			}
+		}
+	}
+
+	// Write the memberships back
+	for start := 0; start < len(entities); start += batchSize {
+		end := start + batchSize
+		if end > len(entities) {
+			end = len(entities)
+		}
+
+		for i := start; i < end; i++ {
+			entity := entities[i]
+			if entity.Metadata == nil {
+				entity.Metadata = make(map[string]interface{})
+			}
+
+			matches := memberships[i]
+			sort.SliceStable(matches, func(a, b int) bool {
+				return matches[a].similarity > matches[b].similarity
+			})
+			entity.Metadata[ClusterMetadataKey] = matches[0].id
+			entity.Metadata[CanopyMetadataKey] = canopyIDs(matches)
+			if id, ok := centerOf[i]; ok {
+				entity.Metadata[CanopyCenterMetadataKey] = id
+			} else {
+				delete(entity.Metadata, CanopyCenterMetadataKey)
+			}
+
+			// Refresh the keys of every blocking pass
+			for key := range entity.Metadata {
+				if strings.HasPrefix(key, BlockMetadataPrefix) {
+					delete(entity.Metadata, key)
+				}
+			}
+			for key, value := range s.GenerateBlockingKeys(ctx, entity.Fields) {
+				entity.Metadata[key] = value
+			}
+		}
+
+		if _, err := client.BatchUpdateEntities(ctx, entities[start:end]); err != nil {
+			return fmt.Errorf("failed to update entities: %w", err)
+		}
+	}
+
+	s.canopyMutex.Lock()
+	s.canopies = canopies
+	s.canopiesLoaded = true
+	s.canopyMutex.Unlock()
+
+	return nil
+}
+
+// canopyFilters returns a search filter for each of the entity's first MaxClustersToSearch canopies
+func canopyFilters(entity *store.EntityRecord) []map[string]string {
+	ids := metadataStrings(entity.Metadata[CanopyMetadataKey])
+	if len(ids) > MaxClustersToSearch {
+		ids = ids[:MaxClustersToSearch]
+	}
+	filters := make([]map[string]string, 0, len(ids))
+	for _, id := range ids {
+		filters = append(filters, map[string]string{"metadata." + CanopyMetadataKey: id})
+	}
+	return filters
+}
+
+// newCanopy creates a canopy centered on an entity
+func (s *Service) newCanopy(id string, center *store.EntityRecord) canopy {
+	return canopy{id: id, tokens: s.canopyTokens(center.Fields), vector: center.Vector}
+}
+
+// canopyTokens returns the tokens of the clustering fields, preferring normalized values
+func (s *Service) canopyTokens(fields map[string]string) map[string]bool {
+	names := s.config.Fields
+	if len(names) == 0 {
+		names = []string{"name"}
+	}
+
+	tokens := make(map[string]bool)
+	for _, name := range names {
+		value := fields[name+"_normalized"]
+		if value == "" {
+			value = fields[name]
+		}
+		for _, token := range strings.Fields(strings.ToLower(value)) {
+			tokens[name+":"+token] = true
+		}
+	}
+	return tokens
+}
+
+// canopySimilarity is the cheap similarity used to build canopies
+func (s *Service) canopySimilarity(tokensA map[string]bool, vectorA []float32, tokensB map[string]bool, vectorB []float32) float64 {
+	if s.config.Distance == DistanceCosine {
+		return store.CosineSimilarity(vectorA, vectorB)
+	}
+	return jaccardSimilarity(tokensA, tokensB)
+}
+
+// jaccardSimilarity returns the Jaccard similarity of two token sets
+func jaccardSimilarity(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	intersection := 0
+	for token := range a {
+		if b[token] {
+			intersection++
+		}
+	}
+	return float64(intersection) / float64(len(a)+len(b)-intersection)
+}
+
+// canopyIDs returns the IDs of the canopy matches in order
+func canopyIDs(matches []canopyMatch) []string {
+	ids := make([]string, len(matches))
+	for i, match := range matches {
+		ids[i] = match.id
+	}
+	return ids
+}
+
+// withoutCanopy removes a canopy from the matches
+func withoutCanopy(matches []canopyMatch, id string) []canopyMatch {
+	filtered := matches[:0]
+	for _, match := range matches {
+		if match.id != id {
+			filtered = append(filtered, match)
+		}
+	}
+	return filtered
+}
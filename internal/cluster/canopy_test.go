@@ -0,0 +1,199 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/TFMV/resolve/internal/config"
+	"github.com/TFMV/resolve/internal/normalize"
+	"github.com/TFMV/resolve/internal/store"
+)
+
+func newCanopyService() *Service {
+	return NewService(&Config{
+		Enabled:             true,
+		Method:              string(CanopyMethod),
+		Fields:              []string{"name"},
+		SimilarityThreshold: 0.3,
+		TightThreshold:      0.9,
+	}, normalize.NewNormalizer(&config.Config{}))
+}
+
+func TestAssignCanopiesOverlap(t *testing.T) {
+	ctx := context.Background()
+	s := newCanopyService()
+
+	acme := &store.EntityRecord{ID: "acme", Fields: map[string]string{"name": "acme widgets"}}
+	globex := &store.EntityRecord{ID: "globex", Fields: map[string]string{"name": "globex widgets"}}
+	duplicate := &store.EntityRecord{ID: "acme-2", Fields: map[string]string{"name": "acme widgets"}}
+	for _, entity := range []*store.EntityRecord{acme, globex, duplicate} {
+		if _, err := s.AssignCluster(ctx, entity); err != nil {
+			t.Fatalf("AssignCluster: %v", err)
+		}
+	}
+
+	if len(s.canopies) != 2 {
+		t.Fatalf("expected 2 canopies, got %d", len(s.canopies))
+	}
+	acmeCanopy := acme.Metadata[CanopyCenterMetadataKey]
+	globexCanopy := globex.Metadata[CanopyCenterMetadataKey]
+	if acmeCanopy == nil || globexCanopy == nil {
+		t.Fatalf("expected acme and globex to be centers, got %v and %v", acme.Metadata, globex.Metadata)
+	}
+	if _, ok := duplicate.Metadata[CanopyCenterMetadataKey]; ok {
+		t.Error("an entity within the tight threshold should not become a center")
+	}
+
+	ids := duplicate.Metadata[CanopyMetadataKey].([]string)
+	if len(ids) != 2 || ids[0] != acmeCanopy || ids[1] != globexCanopy {
+		t.Errorf("expected the duplicate in both canopies, nearest first, got %v", ids)
+	}
+	if duplicate.Metadata[ClusterMetadataKey] != acmeCanopy {
+		t.Errorf("expected the nearest canopy as cluster ID, got %v", duplicate.Metadata[ClusterMetadataKey])
+	}
+}
+
+func TestAssignCanopiesConcurrently(t *testing.T) {
+	ctx := context.Background()
+	s := newCanopyService()
+
+	// Identical entities assigned at once start a single canopy
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s.AssignCluster(ctx, &store.EntityRecord{ID: fmt.Sprintf("acme-%d", i), Fields: map[string]string{"name": "acme widgets"}})
+		}(i)
+	}
+	wg.Wait()
+	if len(s.canopies) != 1 {
+		t.Fatalf("expected 1 canopy, got %d", len(s.canopies))
+	}
+
+	// A dropped canopy is forgotten, so the next entity starts a new one
+	s.DropCanopy(s.canopies[0].id)
+	entity := &store.EntityRecord{ID: "acme", Fields: map[string]string{"name": "acme widgets"}}
+	if _, err := s.AssignCluster(ctx, entity); err != nil {
+		t.Fatalf("AssignCluster: %v", err)
+	}
+	if len(s.canopies) != 1 || entity.Metadata[CanopyCenterMetadataKey] != s.canopies[0].id {
+		t.Errorf("expected the entity to start the only canopy, got %v", entity.Metadata)
+	}
+}
+
+func TestAssignQueryDoesNotCreateCanopies(t *testing.T) {
+	ctx := context.Background()
+	s := newCanopyService()
+
+	for _, name := range []string{"acme widgets", "globex widgets"} {
+		if _, err := s.AssignCluster(ctx, &store.EntityRecord{Fields: map[string]string{"name": name}}); err != nil {
+			t.Fatalf("AssignCluster: %v", err)
+		}
+	}
+
+	query := &store.EntityRecord{Fields: map[string]string{"name": "widgets"}}
+	if _, err := s.AssignQuery(ctx, query); err != nil {
+		t.Fatalf("AssignQuery: %v", err)
+	}
+	if len(s.canopies) != 2 {
+		t.Errorf("queries should not create canopies, got %d", len(s.canopies))
+	}
+	if filters := s.GetClusterFiltersForEntity(ctx, query); len(filters) != 2 {
+		t.Errorf("expected a filter per overlapping canopy, got %v", filters)
+	}
+
+	// Queries outside every canopy search the nearest one
+	unrelated := &store.EntityRecord{Fields: map[string]string{"name": "initech"}}
+	if _, err := s.AssignQuery(ctx, unrelated); err != nil {
+		t.Fatalf("AssignQuery: %v", err)
+	}
+	if filters := s.GetClusterFiltersForEntity(ctx, unrelated); len(filters) != 1 {
+		t.Errorf("expected a single fallback filter, got %v", filters)
+	}
+}
+
+func TestRecomputeAndLoadCanopies(t *testing.T) {
+	ctx := context.Background()
+	memStore := store.NewMemoryStore()
+	for _, entity := range []*store.EntityRecord{
+		{ID: "a", Fields: map[string]string{"name": "acme widgets"}},
+		{ID: "b", Fields: map[string]string{"name": "acme widgets"}},
+		{ID: "c", Fields: map[string]string{"name": "globex widgets"}},
+		{ID: "d", Fields: map[string]string{"name": "initech"}},
+	} {
+		if _, err := memStore.AddEntity(ctx, entity); err != nil {
+			t.Fatalf("AddEntity: %v", err)
+		}
+	}
+
+	s := newCanopyService()
+	if err := s.RecomputeAllClusters(ctx, memStore, 2); err != nil {
+		t.Fatalf("RecomputeAllClusters: %v", err)
+	}
+
+	a, _ := memStore.GetEntity(ctx, "a")
+	b, _ := memStore.GetEntity(ctx, "b")
+	c, _ := memStore.GetEntity(ctx, "c")
+	d, _ := memStore.GetEntity(ctx, "d")
+	if a.Metadata[ClusterMetadataKey] != b.Metadata[ClusterMetadataKey] {
+		t.Errorf("expected a and b in the same canopy, got %v and %v", a.Metadata, b.Metadata)
+	}
+	if ids := c.Metadata[CanopyMetadataKey].([]string); len(ids) != 2 {
+		t.Errorf("expected c in its own canopy and a's, got %v", ids)
+	}
+	if d.Metadata[ClusterMetadataKey] == a.Metadata[ClusterMetadataKey] {
+		t.Error("expected d in a separate canopy")
+	}
+
+	// A fresh service picks up the stored centers
+	loaded := newCanopyService()
+	if err := loaded.LoadCanopies(ctx, memStore); err != nil {
+		t.Fatalf("LoadCanopies: %v", err)
+	}
+	if len(loaded.canopies) != 3 {
+		t.Errorf("expected 3 stored canopy centers, got %d", len(loaded.canopies))
+	}
+}
+
+// noMetadataFilterStore rejects metadata filters, like a store that keeps metadata opaque
+type noMetadataFilterStore struct {
+	*store.MemoryStore
+}
+
+func (s noMetadataFilterStore) QueryEntities(ctx context.Context, opts store.ListOptions) ([]*store.EntityRecord, error) {
+	for _, filter := range opts.Filters {
+		if strings.HasPrefix(filter.Path, "metadata.") {
+			return nil, fmt.Errorf("%w: %s", store.ErrUnsupportedFilter, filter.Path)
+		}
+	}
+	return s.MemoryStore.QueryEntities(ctx, opts)
+}
+
+func TestLoadCanopiesWithoutMetadataFilters(t *testing.T) {
+	ctx := context.Background()
+	memStore := store.NewMemoryStore()
+	for _, entity := range []*store.EntityRecord{
+		{ID: "a", Fields: map[string]string{"name": "acme widgets"}},
+		{ID: "b", Fields: map[string]string{"name": "globex"}},
+		{ID: "c", Fields: map[string]string{"name": "acme widgets"}},
+	} {
+		if _, err := memStore.AddEntity(ctx, entity); err != nil {
+			t.Fatalf("AddEntity: %v", err)
+		}
+	}
+	if err := newCanopyService().RecomputeAllClusters(ctx, memStore, 2); err != nil {
+		t.Fatalf("RecomputeAllClusters: %v", err)
+	}
+
+	loaded := newCanopyService()
+	if err := loaded.LoadCanopies(ctx, noMetadataFilterStore{memStore}); err != nil {
+		t.Fatalf("LoadCanopies: %v", err)
+	}
+	if len(loaded.canopies) != 2 {
+		t.Errorf("expected 2 canopy centers found by scanning, got %d", len(loaded.canopies))
+	}
+}
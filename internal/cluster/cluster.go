@@ -9,16 +9,22 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/TFMV/resolve/internal/config"
 	"github.com/TFMV/resolve/internal/normalize"
-	"github.com/TFMV/resolve/internal/weaviate"
+	"github.com/TFMV/resolve/internal/similarity"
+	"github.com/TFMV/resolve/internal/store"
 )
 
 // ClusterMethod defines the clustering algorithm to use
 type ClusterMethod string
 
 const (
-	// CanopyMethod uses key-based canopy clustering
+	// KeyMethod groups entities with equal hashed blocking keys
+	KeyMethod ClusterMethod = "key"
+	// CanopyMethod groups entities into overlapping canopies around center entities
 	CanopyMethod ClusterMethod = "canopy"
+	// LSHMethod groups entities sharing a MinHash/LSH bucket in any band
+	LSHMethod ClusterMethod = "lsh"
 	// DefaultClusterID is used when no cluster can be determined
 	DefaultClusterID = "default"
 	// ClusterMetadataKey is the key used to store cluster ID in entity metadata
@@ -29,10 +35,18 @@ const (
 
 // Config holds the clustering configuration
 type Config struct {
-	Enabled             bool     `mapstructure:"enabled"`
-	Method              string   `mapstructure:"method"`
-	Fields              []string `mapstructure:"fields"`
-	SimilarityThreshold float64  `mapstructure:"similarity_threshold"`
+	Enabled             bool                  `mapstructure:"enabled"`
+	Method              string                `mapstructure:"method"`
+	Fields              []string              `mapstructure:"fields"`
+	SimilarityThreshold float64               `mapstructure:"similarity_threshold"` // Loose canopy threshold
+	TightThreshold      float64               `mapstructure:"tight_threshold"`      // Tight canopy threshold
+	Distance            string                `mapstructure:"distance"`             // Canopy similarity: "jaccard" or "cosine"
+	Bands               int                   `mapstructure:"bands"`                // LSH bands
+	Rows                int                   `mapstructure:"rows"`                 // MinHash rows per LSH band
+	ShingleSize         int                   `mapstructure:"shingle_size"`         // LSH character shingle length
+	MaxBandSearches     int                   `mapstructure:"max_band_searches"`    // LSH bands searched per query
+	Rules               []config.BlockingRule `mapstructure:"rules"`
+	Phonetic            string                `mapstructure:"phonetic"` // Phonetic encoder for the name component of keys
 }
 
 // Service represents the clustering service
@@ -41,6 +55,13 @@ type Service struct {
 	normalizer *normalize.Normalizer
 	keyCache   map[string]string
 	cacheMutex sync.RWMutex
+
+	canopies       []canopy // Canopy centers, when using the canopy method
+	canopiesLoaded bool
+	canopyMutex    sync.RWMutex
+
+	lshHashes [][2]uint64 // MinHash hash coefficients, when using the LSH method
+	lshOnce   sync.Once
 }
 
 // NewService creates a new clustering service
@@ -95,8 +116,13 @@ func (s *Service) GenerateClusterKey(ctx context.Context, fields map[string]stri
 		var keyComponent string
 		switch field {
 		case "name":
-			// Extract first 3 characters for name if available
-			if len(normalizedField) >= 3 {
+			// Encode the first token phonetically if configured, so that "Smyth" and
+			// "Smith" share a key; otherwise extract first 3 characters if available
+			if s.config.Phonetic != "" {
+				if tokens := strings.Fields(normalizedField); len(tokens) > 0 {
+					keyComponent = similarity.PhoneticKey(s.config.Phonetic, tokens[0])
+				}
+			} else if len(normalizedField) >= 3 {
 				keyComponent = normalizedField[:3]
 			} else {
 				keyComponent = normalizedField
@@ -155,33 +181,45 @@ func (s *Service) GenerateClusterKey(ctx context.Context, fields map[string]stri
 	return clusterID
 }
 
-// AssignCluster assigns a cluster ID to an entity
-func (s *Service) AssignCluster(ctx context.Context, entity *weaviate.EntityRecord) (string, error) {
+// AssignCluster assigns a cluster ID to an entity being stored. With the canopy method
+// the entity joins every canopy within the loose threshold and becomes the center of a
+// new canopy if no center is within the tight threshold.
+func (s *Service) AssignCluster(ctx context.Context, entity *store.EntityRecord) (string, error) {
+	return s.assign(ctx, entity, true)
+}
+
+// AssignQuery assigns cluster metadata to a query entity so that
+// GetClusterFiltersForEntity can be used for it. Unlike AssignCluster it never
+// creates canopies.
+func (s *Service) AssignQuery(ctx context.Context, entity *store.EntityRecord) (string, error) {
+	return s.assign(ctx, entity, false)
+}
+
+// assign sets the cluster ID and blocking keys of an entity
+func (s *Service) assign(ctx context.Context, entity *store.EntityRecord, stored bool) (string, error) {
 	// Skip if clustering is disabled
 	if !s.config.Enabled {
 		return DefaultClusterID, nil
 	}
 
-	// Extract fields from entity
-	fields := map[string]string{
-		"name":               entity.Name,
-		"name_normalized":    entity.NameNormalized,
-		"address":            entity.Address,
-		"address_normalized": entity.AddressNormalized,
-		"city":               entity.City,
-		"city_normalized":    entity.CityNormalized,
-		"state":              entity.State,
-		"state_normalized":   entity.StateNormalized,
-		"zip":                entity.Zip,
-		"zip_normalized":     entity.ZipNormalized,
-		"phone":              entity.Phone,
-		"phone_normalized":   entity.PhoneNormalized,
-		"email":              entity.Email,
-		"email_normalized":   entity.EmailNormalized,
-	}
-
 	// Generate cluster key
-	clusterID := s.GenerateClusterKey(ctx, fields)
+	var clusterID string
+	switch {
+	case s.isCanopy():
+		clusterID = s.assignCanopies(entity, stored)
+	case s.isLSH():
+		if entity.Metadata == nil {
+			entity.Metadata = make(map[string]interface{})
+		}
+		clusterID = DefaultClusterID
+		delete(entity.Metadata, LSHMetadataKey)
+		if buckets := s.LSHBuckets(entity.Fields); len(buckets) > 0 {
+			clusterID = buckets[0]
+			entity.Metadata[LSHMetadataKey] = buckets
+		}
+	default:
+		clusterID = s.GenerateClusterKey(ctx, entity.Fields)
+	}
 
 	// Ensure metadata exists
 	if entity.Metadata == nil {
@@ -191,58 +229,53 @@ func (s *Service) AssignCluster(ctx context.Context, entity *weaviate.EntityReco
 	// Set cluster ID in metadata
 	entity.Metadata[ClusterMetadataKey] = clusterID
 
+	// Replace the keys of every blocking pass
+	for key := range entity.Metadata {
+		if strings.HasPrefix(key, BlockMetadataPrefix) {
+			delete(entity.Metadata, key)
+		}
+	}
+	for key, value := range s.GenerateBlockingKeys(ctx, entity.Fields) {
+		entity.Metadata[key] = value
+	}
+
 	return clusterID, nil
 }
 
 // RecomputeAllClusters recomputes clusters for all entities
-func (s *Service) RecomputeAllClusters(ctx context.Context, client *weaviate.Client, batchSize int) error {
+func (s *Service) RecomputeAllClusters(ctx context.Context, client store.VectorStore, batchSize int) error {
 	if !s.config.Enabled {
 		return nil
 	}
 
-	// Get all entities (paginated)
-	offset := 0
-	for {
-		entities, err := client.ListEntities(ctx, offset, batchSize)
-		if err != nil {
-			return fmt.Errorf("failed to list entities: %w", err)
-		}
-
-		// If no more entities, we're done
-		if len(entities) == 0 {
-			break
-		}
+	// Canopies are rebuilt over the whole corpus at once
+	if s.isCanopy() {
+		return s.recomputeCanopies(ctx, client, batchSize)
+	}
 
-		// Assign cluster to each entity
-		updatedEntities := make([]*weaviate.EntityRecord, 0, len(entities))
+	// Assign a cluster to every entity, a page at a time
+	err := store.ScanEntities(ctx, client, store.ListOptions{Limit: batchSize, IncludeVector: true}, func(entities []*store.EntityRecord) error {
 		for _, entity := range entities {
-			_, err := s.AssignCluster(ctx, entity)
-			if err != nil {
+			if _, err := s.AssignCluster(ctx, entity); err != nil {
 				return fmt.Errorf("failed to assign cluster to entity %s: %w", entity.ID, err)
 			}
-			updatedEntities = append(updatedEntities, entity)
 		}
 
 		// Update entities in batch
-		_, err = client.BatchUpdateEntities(ctx, updatedEntities)
-		if err != nil {
+		if _, err := client.BatchUpdateEntities(ctx, entities); err != nil {
 			return fmt.Errorf("failed to update entities: %w", err)
 		}
-
-		// Move to next page
-		offset += len(entities)
-
-		// If we got fewer entities than the batch size, we're done
-		if len(entities) < batchSize {
-			break
-		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to recompute clusters: %w", err)
 	}
 
 	return nil
 }
 
 // GetClusterFilterForEntity returns a map of filters to search for similar clusters
-func (s *Service) GetClusterFilterForEntity(ctx context.Context, entity *weaviate.EntityRecord) map[string]string {
+func (s *Service) GetClusterFilterForEntity(ctx context.Context, entity *store.EntityRecord) map[string]string {
 	// Skip if clustering is disabled
 	if !s.config.Enabled || entity.Metadata == nil {
 		return nil
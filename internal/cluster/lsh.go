@@ -0,0 +1,184 @@
+package cluster
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"strings"
+
+	"github.com/TFMV/resolve/internal/store"
+)
+
+// LSH metadata keys and defaults
+const (
+	// LSHMetadataKey holds the LSH bucket key of every band of an entity
+	LSHMetadataKey = "lsh_buckets"
+	// DefaultBands is the number of LSH bands used when none is configured
+	DefaultBands = 20
+	// DefaultRows is the number of MinHash rows per band used when none is configured
+	DefaultRows = 5
+	// DefaultShingleSize is the character shingle length used when none is configured
+	DefaultShingleSize = 3
+	// DefaultMaxBandSearches is the number of LSH bands searched per query when none is configured
+	DefaultMaxBandSearches = 10
+
+	lshSeed = 20240601 // Fixed so that signatures are stable across restarts
+)
+
+// isLSH reports whether the service uses MinHash/LSH blocking
+func (s *Service) isLSH() bool {
+	return ClusterMethod(s.config.Method) == LSHMethod
+}
+
+// lshParams returns the configured bands, rows and shingle size
+func (s *Service) lshParams() (int, int, int) {
+	bands, rows, shingleSize := s.config.Bands, s.config.Rows, s.config.ShingleSize
+	if bands <= 0 {
+		bands = DefaultBands
+	}
+	if rows <= 0 {
+		rows = DefaultRows
+	}
+	if shingleSize <= 0 {
+		shingleSize = DefaultShingleSize
+	}
+	return bands, rows, shingleSize
+}
+
+// maxBandSearches returns the number of LSH bands searched per query. Each band costs one
+// vector search.
+func (s *Service) maxBandSearches() int {
+	if s.config.MaxBandSearches <= 0 {
+		return DefaultMaxBandSearches
+	}
+	return s.config.MaxBandSearches
+}
+
+// LSHBuckets returns the bucket key of every LSH band for the entity, or nil if the
+// clustering fields are empty. Entities whose shingle sets have Jaccard similarity s
+// share at least one bucket with probability 1-(1-s^rows)^bands.
+func (s *Service) LSHBuckets(fields map[string]string) []string {
+	bands, rows, shingleSize := s.lshParams()
+
+	shingles := s.shingles(fields, shingleSize)
+	if len(shingles) == 0 {
+		return nil
+	}
+	signature := s.minHash(shingles, bands*rows)
+
+	buckets := make([]string, bands)
+	buf := make([]byte, 8)
+	for band := 0; band < bands; band++ {
+		hasher := fnv.New64a()
+		for _, value := range signature[band*rows : (band+1)*rows] {
+			binary.LittleEndian.PutUint64(buf, value)
+			hasher.Write(buf)
+		}
+		buckets[band] = fmt.Sprintf("%d-%s", band, hex.EncodeToString(hasher.Sum(nil)))
+	}
+	return buckets
+}
+
+// shingles returns the character shingles of the clustering fields, preferring normalized values
+func (s *Service) shingles(fields map[string]string, size int) map[uint64]bool {
+	names := s.config.Fields
+	if len(names) == 0 {
+		names = []string{"name"}
+	}
+
+	shingles := make(map[uint64]bool)
+	for _, name := range names {
+		value := fields[name+"_normalized"]
+		if value == "" {
+			value = fields[name]
+		}
+		value = strings.Join(strings.Fields(strings.ToLower(value)), " ")
+		if value == "" {
+			continue
+		}
+
+		// Values shorter than a shingle are a single shingle
+		runes := []rune(value)
+		if len(runes) <= size {
+			shingles[shingleHash(name, value)] = true
+			continue
+		}
+		for start := 0; start+size <= len(runes); start++ {
+			shingles[shingleHash(name, string(runes[start:start+size]))] = true
+		}
+	}
+	return shingles
+}
+
+// shingleHash hashes a shingle of a field
+func shingleHash(field, shingle string) uint64 {
+	hasher := fnv.New64a()
+	hasher.Write([]byte(field + ":" + shingle))
+	return hasher.Sum64()
+}
+
+// minHash returns the MinHash signature of a shingle set
+func (s *Service) minHash(shingles map[uint64]bool, size int) []uint64 {
+	s.lshOnce.Do(func() {
+		s.lshHashes = lshHashes(size)
+	})
+
+	signature := make([]uint64, size)
+	for i := range signature {
+		signature[i] = math.MaxUint64
+	}
+	for shingle := range shingles {
+		for i := range signature {
+			h := s.lshHashes[i]
+			if value := h[0]*shingle + h[1]; value < signature[i] {
+				signature[i] = value
+			}
+		}
+	}
+	return signature
+}
+
+// lshHashes returns the coefficients of the MinHash hash functions
+func lshHashes(count int) [][2]uint64 {
+	rng := rand.New(rand.NewSource(lshSeed))
+	hashes := make([][2]uint64, count)
+	for i := range hashes {
+		hashes[i] = [2]uint64{rng.Uint64() | 1, rng.Uint64()} // Odd multipliers are invertible modulo 2^64
+	}
+	return hashes
+}
+
+// lshFilters returns a search filter for each of the entity's first maxBands LSH buckets,
+// or for every bucket if maxBands is 0
+func lshFilters(entity *store.EntityRecord, maxBands int) []map[string]string {
+	buckets := metadataStrings(entity.Metadata[LSHMetadataKey])
+	if maxBands > 0 && len(buckets) > maxBands {
+		buckets = buckets[:maxBands]
+	}
+	filters := make([]map[string]string, 0, len(buckets))
+	for _, bucket := range buckets {
+		filters = append(filters, map[string]string{"metadata." + LSHMetadataKey: bucket})
+	}
+	return filters
+}
+
+// metadataStrings reads a string list from metadata, which may have been decoded as []interface{}
+func metadataStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
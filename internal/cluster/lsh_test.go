@@ -0,0 +1,109 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	"github.com/TFMV/resolve/internal/config"
+	"github.com/TFMV/resolve/internal/normalize"
+	"github.com/TFMV/resolve/internal/store"
+)
+
+func newLSHService(bands, rows int) *Service {
+	return NewService(&Config{
+		Enabled: true,
+		Method:  string(LSHMethod),
+		Fields:  []string{"name"},
+		Bands:   bands,
+		Rows:    rows,
+	}, normalize.NewNormalizer(&config.Config{}))
+}
+
+func sharesBucket(a, b []string) bool {
+	seen := make(map[string]bool, len(a))
+	for _, bucket := range a {
+		seen[bucket] = true
+	}
+	for _, bucket := range b {
+		if seen[bucket] {
+			return true
+		}
+	}
+	return false
+}
+
+func TestLSHBuckets(t *testing.T) {
+	s := newLSHService(20, 5)
+
+	original := s.LSHBuckets(map[string]string{"name": "international business machines"})
+	typo := s.LSHBuckets(map[string]string{"name": "internatoinal business machines"})
+	other := s.LSHBuckets(map[string]string{"name": "globex corporation"})
+
+	if len(original) != 20 {
+		t.Fatalf("expected a bucket per band, got %d", len(original))
+	}
+	if !sharesBucket(original, typo) {
+		t.Error("expected a misspelled name to share a bucket with the original")
+	}
+	if sharesBucket(original, other) {
+		t.Error("expected unrelated names to share no bucket")
+	}
+
+	// Signatures are stable across services
+	again := newLSHService(20, 5).LSHBuckets(map[string]string{"name": "international business machines"})
+	for i := range original {
+		if original[i] != again[i] {
+			t.Fatalf("expected stable buckets, got %s and %s", original[i], again[i])
+		}
+	}
+
+	if buckets := s.LSHBuckets(map[string]string{"zip": "10001"}); buckets != nil {
+		t.Errorf("expected no buckets without clustering fields, got %v", buckets)
+	}
+}
+
+func TestAssignClusterLSH(t *testing.T) {
+	ctx := context.Background()
+	s := newLSHService(4, 2)
+
+	entity := &store.EntityRecord{Fields: map[string]string{"name": "acme corp"}}
+	if _, err := s.AssignCluster(ctx, entity); err != nil {
+		t.Fatalf("AssignCluster: %v", err)
+	}
+	if buckets := entity.Metadata[LSHMetadataKey].([]string); entity.Metadata[ClusterMetadataKey] != buckets[0] {
+		t.Errorf("expected the first bucket as cluster ID, got %v", entity.Metadata)
+	}
+	if filters := s.GetClusterFiltersForEntity(ctx, entity); len(filters) != 4 {
+		t.Errorf("expected a filter per band, got %v", filters)
+	}
+	if keys := s.BlockingKeys(ctx, entity.Fields); len(keys) != 4 {
+		t.Errorf("expected a blocking pass per band, got %v", keys)
+	}
+}
+
+func TestLSHMaxBandSearches(t *testing.T) {
+	ctx := context.Background()
+	s := newLSHService(4, 2)
+	s.config.MaxBandSearches = 2
+
+	entity := &store.EntityRecord{Fields: map[string]string{"name": "acme corp"}}
+	if _, err := s.AssignCluster(ctx, entity); err != nil {
+		t.Fatalf("AssignCluster: %v", err)
+	}
+	if filters := s.GetClusterFiltersForEntity(ctx, entity); len(filters) != 2 {
+		t.Errorf("expected a filter for each of the first two bands, got %v", filters)
+	}
+
+	// Batch blocking still uses every band
+	blocker, err := NewKeyBlocker(s, 0, OversizedCap)
+	if err != nil {
+		t.Fatalf("NewKeyBlocker: %v", err)
+	}
+	blocks, err := blocker.Blocks(ctx, []map[string]string{entity.Fields})
+	if err != nil {
+		t.Fatalf("Blocks: %v", err)
+	}
+	if len(blocks) != 4 {
+		t.Errorf("expected a block per band, got %v", blocks)
+	}
+}
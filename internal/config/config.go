@@ -1,12 +1,83 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 
+	"github.com/TFMV/resolve/internal/similarity"
 	"github.com/spf13/viper"
 )
 
+// FieldConfig describes a single entity field in the schema
+type FieldConfig struct {
+	Name        string `mapstructure:"name"`        // Field name, also used as the stored property name
+	Type        string `mapstructure:"type"`        // Field type used to select a similarity function
+	Normalizer  string `mapstructure:"normalizer"`  // Normalizer applied before storage and matching (defaults to the type)
+	Description string `mapstructure:"description"` // Optional human-readable description
+}
+
+// BlockingField is one component of a blocking key: a field value reduced by a transform
+type BlockingField struct {
+	Field     string `mapstructure:"field"`     // Entity field; its normalized value is used when available
+	Transform string `mapstructure:"transform"` // One of BlockingTransforms (defaults to "exact")
+	Length    int    `mapstructure:"length"`    // Number of characters or digits kept by prefix, suffix and last_digits
+}
+
+// BlockingRule is one blocking pass. Entities whose keys for the rule are equal are compared.
+type BlockingRule struct {
+	Name   string          `mapstructure:"name"`   // Rule name, used in the stored metadata key
+	Fields []BlockingField `mapstructure:"fields"` // Key components; the rule is skipped for entities missing any of them
+}
+
+// CompositeConfig declares a comparator built from a weighted blend of others
+type CompositeConfig struct {
+	Name       string `mapstructure:"name"`       // Comparator name, usable as a field type target and in other composites
+	Expression string `mapstructure:"expression"` // For example "0.6*jarowinkler + 0.4*jaccard after lowercase"
+}
+
+// FieldPatternConfig chooses a comparator for untyped fields whose name contains one of the substrings
+type FieldPatternConfig struct {
+	Function string   `mapstructure:"function"` // Comparator name
+	Contains []string `mapstructure:"contains"` // Substrings of the field name, matched case-insensitively
+}
+
+// BlockingTransforms lists the supported blocking key transforms
+var BlockingTransforms = map[string]bool{
+	"exact":        true, // Whole value
+	"prefix":       true, // First Length characters
+	"suffix":       true, // Last Length characters
+	"digits":       true, // Digits only
+	"last_digits":  true, // Last Length digits
+	"first_token":  true, // First whitespace-separated token
+	"email_domain": true, // Part after the @
+	"email_local":  true, // Part before the @
+	"soundex":      true, // Soundex code of each token
+	"metaphone":    true, // Primary Double Metaphone code of each token
+	"nysiis":       true, // NYSIIS code of each token
+}
+
+// PhoneticEncoders lists the supported phonetic encoders
+var PhoneticEncoders = map[string]bool{
+	"soundex":   true,
+	"metaphone": true, // Double Metaphone
+	"nysiis":    true,
+}
+
+// reservedFieldNames are property names used by Resolve itself
+var reservedFieldNames = map[string]bool{
+	"id":         true,
+	"created_at": true,
+	"updated_at": true,
+	"metadata":   true,
+	"vector":     true,
+}
+
+// fieldNameRegex matches valid property names for the vector store
+var fieldNameRegex = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
+
 // Config holds all configuration for the Resolve application
 type Config struct {
 	// Server configuration
@@ -46,6 +117,11 @@ type Config struct {
 		SimilarityThreshold float32            `mapstructure:"similarity_threshold"`
 		FieldWeights        map[string]float32 `mapstructure:"field_weights"`
 		DefaultLimit        int                `mapstructure:"default_limit"`
+		Scorer              string             `mapstructure:"scorer"`               // Default scoring model: "vector", "fellegi_sunter" or "logistic"
+		ModelPath           string             `mapstructure:"model_path"`           // Trained model artifact loaded at startup
+		MinGroupSimilarity  float32            `mapstructure:"min_group_similarity"` // Match groups whose weakest internal pair scores below this are flagged
+		NamePhonetic        string             `mapstructure:"name_phonetic"`        // Phonetic encoder folded into name similarity: soundex, metaphone or nysiis (default: none)
+		NamePhoneticWeight  float64            `mapstructure:"name_phonetic_weight"` // Weight of the phonetic score in name similarity
 	} `mapstructure:"matching"`
 
 	// Normalization configuration
@@ -59,13 +135,94 @@ type Config struct {
 		EnableLowercase bool            `mapstructure:"enable_lowercase"`
 	} `mapstructure:"normalization"`
 
+	// Entity schema configuration
+	Schema struct {
+		Fields []FieldConfig `mapstructure:"fields"`
+	} `mapstructure:"schema"`
+
 	// Clustering configuration
 	Clustering struct {
-		Enabled             bool     `mapstructure:"enabled"`
-		Method              string   `mapstructure:"method"`
-		Fields              []string `mapstructure:"fields"`
-		SimilarityThreshold float64  `mapstructure:"similarity_threshold"`
+		Enabled             bool           `mapstructure:"enabled"`
+		Method              string         `mapstructure:"method"`
+		Fields              []string       `mapstructure:"fields"`
+		SimilarityThreshold float64        `mapstructure:"similarity_threshold"` // Loose canopy threshold
+		TightThreshold      float64        `mapstructure:"tight_threshold"`      // Tight canopy threshold
+		Distance            string         `mapstructure:"distance"`             // Canopy similarity: "jaccard" or "cosine"
+		Bands               int            `mapstructure:"bands"`                // LSH bands; more bands raise recall
+		Rows                int            `mapstructure:"rows"`                 // MinHash rows per LSH band; more rows cut candidate volume
+		ShingleSize         int            `mapstructure:"shingle_size"`         // LSH character shingle length
+		MaxBandSearches     int            `mapstructure:"max_band_searches"`    // LSH bands searched per query, one vector search each
+		Rules               []BlockingRule `mapstructure:"rules"`                // Independent blocking passes; candidates are the union across passes
+		Phonetic            string         `mapstructure:"phonetic"`             // Phonetic encoder for the name component of cluster keys (default: first three characters)
+
+		// Sorted-neighborhood blocking for batch resolution
+		SortedNeighborhood struct {
+			Window int            `mapstructure:"window"` // Number of following records each record is compared with
+			Keys   []BlockingRule `mapstructure:"keys"`   // Sort keys, one pass each; components are concatenated unhashed
+		} `mapstructure:"sorted_neighborhood"`
 	} `mapstructure:"clustering"`
+
+	// Resolved-entity configuration
+	Resolution struct {
+		Enabled   bool    `mapstructure:"enabled"`   // Maintain resolved IDs as entities are added or updated
+		Threshold float32 `mapstructure:"threshold"` // Score at which a match is accepted (defaults to review.auto_match with review enabled, else matching.similarity_threshold)
+	} `mapstructure:"resolution"`
+
+	// Survivorship configuration for golden records
+	Survivorship struct {
+		DefaultRule    string            `mapstructure:"default_rule"`    // Rule for fields without their own rule
+		Rules          map[string]string `mapstructure:"rules"`           // Rule per field: most_recent, source_priority, longest, most_complete, most_frequent or a registered aggregator
+		SourceField    string            `mapstructure:"source_field"`    // Metadata key naming the source system of a record
+		SourcePriority []string          `mapstructure:"source_priority"` // Sources, most trusted first
+	} `mapstructure:"survivorship"`
+
+	// Steward override configuration
+	Overrides struct {
+		Path string `mapstructure:"path"` // JSON file recording must-link, cannot-link and split overrides
+	} `mapstructure:"overrides"`
+
+	// Clerical review configuration
+	Review struct {
+		Enabled    bool    `mapstructure:"enabled"`     // Queue borderline pairs for review as entities are added or updated
+		AutoMatch  float32 `mapstructure:"auto_match"`  // Score at or above which a pair is matched without review
+		AutoReject float32 `mapstructure:"auto_reject"` // Score below which a pair is rejected without review
+		Path       string  `mapstructure:"path"`        // JSON file holding the review queue and decisions
+	} `mapstructure:"review"`
+
+	// Soft TF-IDF configuration
+	TFIDF struct {
+		Enabled   bool     `mapstructure:"enabled"`   // Score the fields with soft TF-IDF once statistics are built
+		Fields    []string `mapstructure:"fields"`    // Fields scored with soft TF-IDF, each with its own document frequencies
+		Threshold float64  `mapstructure:"threshold"` // Jaro-Winkler similarity at which two tokens count as the same
+		Path      string   `mapstructure:"path"`      // JSON file holding the document frequencies, refreshed by recompute-clusters
+	} `mapstructure:"tfidf"`
+
+	// Similarity function configuration
+	Similarity struct {
+		NameBlend     string               `mapstructure:"name_blend"`     // Comparators scoring preprocessed names
+		AddressBlend  string               `mapstructure:"address_blend"`  // Comparators scoring preprocessed addresses
+		Composites    []CompositeConfig    `mapstructure:"composites"`     // Composite comparators, declared in dependency order
+		FieldTypes    map[string]string    `mapstructure:"field_types"`    // Field type aliases: field type to comparator name
+		FieldPatterns []FieldPatternConfig `mapstructure:"field_patterns"` // Rules choosing a comparator for untyped fields by name, checked in order
+
+		// Numeric comparator used by the number, amount and revenue field types
+		Numeric struct {
+			Scale         float64 `mapstructure:"scale"`          // Absolute difference at which numbers score 0 (0: relative difference only)
+			RelativeScale float64 `mapstructure:"relative_scale"` // Relative difference, as a share of the larger number, at which numbers score 0
+		} `mapstructure:"numeric"`
+
+		// Date comparator used by the date and date_of_birth field types
+		Date struct {
+			ToleranceDays int  `mapstructure:"tolerance_days"` // Days apart at which dates score 0
+			YearTolerance int  `mapstructure:"year_tolerance"` // Years apart at which dates without a day score 0
+			DayFirst      bool `mapstructure:"day_first"`      // Read numeric dates with the year last as day first, like 04/03/1985
+		} `mapstructure:"date"`
+
+		// Year comparator used by the year and age field types
+		Year struct {
+			Tolerance int `mapstructure:"tolerance"` // Years apart at which years and ages score 0
+		} `mapstructure:"year"`
+	} `mapstructure:"similarity"`
 }
 
 // Load loads the configuration from file and environment variables
@@ -98,9 +255,275 @@ func Load(configPath string) (*Config, error) {
 		return nil, err
 	}
 
+	// Validate the entity schema
+	if err := config.ValidateSchema(); err != nil {
+		return nil, err
+	}
+
+	// Validate the matching settings
+	if err := config.ValidateMatching(); err != nil {
+		return nil, err
+	}
+
+	// Validate the clustering settings
+	if err := config.ValidateClustering(); err != nil {
+		return nil, err
+	}
+
+	// Validate the survivorship rules
+	if err := config.ValidateSurvivorship(); err != nil {
+		return nil, err
+	}
+
+	// Validate the review thresholds
+	if err := config.ValidateReview(); err != nil {
+		return nil, err
+	}
+
+	// Validate the similarity functions
+	if err := config.ValidateSimilarity(); err != nil {
+		return nil, err
+	}
+
+	// Validate the TF-IDF settings
+	if err := config.ValidateTFIDF(); err != nil {
+		return nil, err
+	}
+
 	return &config, nil
 }
 
+// DefaultSchemaFields returns the built-in business entity schema
+func DefaultSchemaFields() []FieldConfig {
+	return []FieldConfig{
+		{Name: "name", Type: "name", Normalizer: "name", Description: "Entity name"},
+		{Name: "address", Type: "address", Normalizer: "address", Description: "Entity address"},
+		{Name: "city", Type: "text", Normalizer: "text", Description: "Entity city"},
+		{Name: "state", Type: "text", Normalizer: "state", Description: "Entity state"},
+		{Name: "zip", Type: "zip", Normalizer: "zip", Description: "Entity ZIP code"},
+		{Name: "phone", Type: "phone", Normalizer: "phone", Description: "Entity phone"},
+		{Name: "email", Type: "email", Normalizer: "email", Description: "Entity email"},
+	}
+}
+
+// SchemaFields returns the configured entity fields, falling back to the default schema
+func (c *Config) SchemaFields() []FieldConfig {
+	if len(c.Schema.Fields) == 0 {
+		return DefaultSchemaFields()
+	}
+	return c.Schema.Fields
+}
+
+// ValidateSchema checks that every schema field has a unique, storable name
+func (c *Config) ValidateSchema() error {
+	seen := make(map[string]bool)
+	for i, field := range c.Schema.Fields {
+		if field.Name == "" {
+			return fmt.Errorf("schema field %d has no name", i)
+		}
+		if !fieldNameRegex.MatchString(field.Name) {
+			return fmt.Errorf("schema field %q must start with a letter or underscore and contain only letters, digits and underscores", field.Name)
+		}
+		if reservedFieldNames[field.Name] || strings.HasSuffix(field.Name, "_normalized") {
+			return fmt.Errorf("schema field name %q is reserved", field.Name)
+		}
+		if seen[field.Name] {
+			return fmt.Errorf("schema field %q is declared more than once", field.Name)
+		}
+		seen[field.Name] = true
+	}
+	return nil
+}
+
+// ValidateMatching checks the phonetic name similarity settings
+func (c *Config) ValidateMatching() error {
+	if c.Matching.NamePhonetic != "" && !PhoneticEncoders[c.Matching.NamePhonetic] {
+		return fmt.Errorf("unknown matching name_phonetic encoder %q", c.Matching.NamePhonetic)
+	}
+	if c.Matching.NamePhoneticWeight < 0 || c.Matching.NamePhoneticWeight >= 1 {
+		return fmt.Errorf("matching name_phonetic_weight %.2f must be at least 0 and below 1", c.Matching.NamePhoneticWeight)
+	}
+	return nil
+}
+
+// ValidateClustering checks the clustering method, canopy and LSH settings and blocking rules
+func (c *Config) ValidateClustering() error {
+	switch c.Clustering.Method {
+	case "", "key", "canopy", "lsh":
+	default:
+		return fmt.Errorf("unknown clustering method %q", c.Clustering.Method)
+	}
+	switch c.Clustering.Distance {
+	case "", "jaccard", "cosine":
+	default:
+		return fmt.Errorf("unknown canopy distance %q", c.Clustering.Distance)
+	}
+	if c.Clustering.TightThreshold > 0 && c.Clustering.TightThreshold < c.Clustering.SimilarityThreshold {
+		return fmt.Errorf("clustering tight_threshold %.2f is below the loose similarity_threshold %.2f",
+			c.Clustering.TightThreshold, c.Clustering.SimilarityThreshold)
+	}
+	if c.Clustering.SortedNeighborhood.Window < 0 {
+		return fmt.Errorf("clustering sorted_neighborhood window must not be negative")
+	}
+	if c.Clustering.Bands < 0 || c.Clustering.Rows < 0 || c.Clustering.ShingleSize < 0 || c.Clustering.MaxBandSearches < 0 {
+		return fmt.Errorf("clustering bands, rows, shingle_size and max_band_searches must not be negative")
+	}
+	if c.Clustering.Phonetic != "" && !PhoneticEncoders[c.Clustering.Phonetic] {
+		return fmt.Errorf("unknown clustering phonetic encoder %q", c.Clustering.Phonetic)
+	}
+
+	return c.ValidateBlockingRules()
+}
+
+// ValidateSurvivorship checks that the fields with survivorship rules are declared in the
+// schema and that source priority rules have a priority list. Rule names are checked when
+// golden records are built, since custom aggregators are registered at runtime.
+func (c *Config) ValidateSurvivorship() error {
+	declared := make(map[string]bool)
+	for _, field := range c.SchemaFields() {
+		declared[field.Name] = true
+	}
+
+	usesPriority := c.Survivorship.DefaultRule == "source_priority"
+	for field, rule := range c.Survivorship.Rules {
+		if !declared[field] {
+			return fmt.Errorf("survivorship rule for undeclared field %q", field)
+		}
+		if rule == "source_priority" {
+			usesPriority = true
+		}
+	}
+	if usesPriority && len(c.Survivorship.SourcePriority) == 0 {
+		return fmt.Errorf("the source_priority survivorship rule requires survivorship.source_priority")
+	}
+
+	return nil
+}
+
+// ValidateReview checks that the review thresholds leave a band for review and that
+// decisions can be recorded as overrides
+func (c *Config) ValidateReview() error {
+	if !c.Review.Enabled {
+		return nil
+	}
+	if c.Review.AutoReject < 0 || c.Review.AutoMatch > 1 || c.Review.AutoReject >= c.Review.AutoMatch {
+		return fmt.Errorf("review thresholds must satisfy 0 <= auto_reject < auto_match <= 1, got %.2f and %.2f", c.Review.AutoReject, c.Review.AutoMatch)
+	}
+	if c.Review.Path == "" {
+		return fmt.Errorf("review requires review.path")
+	}
+	if c.Overrides.Path == "" {
+		return fmt.Errorf("review requires overrides.path to record decisions")
+	}
+	return nil
+}
+
+// ValidateSimilarity checks that the blends, composites, field type aliases and field
+// patterns parse and name known comparators, and that the typed comparator tolerances are
+// not negative. Custom comparators must be registered with similarity.Register before the
+// configuration is loaded.
+func (c *Config) ValidateSimilarity() error {
+	if c.Similarity.Numeric.Scale < 0 || c.Similarity.Numeric.RelativeScale < 0 {
+		return fmt.Errorf("similarity numeric scales must not be negative")
+	}
+	if c.Similarity.Date.ToleranceDays < 0 || c.Similarity.Date.YearTolerance < 0 || c.Similarity.Year.Tolerance < 0 {
+		return fmt.Errorf("similarity date and year tolerances must not be negative")
+	}
+	if _, err := similarity.NewRegistryWithOptions(c.SimilarityOptions()); err != nil {
+		return fmt.Errorf("invalid similarity configuration: %w", err)
+	}
+	return nil
+}
+
+// ValidateTFIDF checks that the TF-IDF fields are declared in the schema and the token threshold is a similarity
+func (c *Config) ValidateTFIDF() error {
+	if !c.TFIDF.Enabled {
+		return nil
+	}
+	if c.TFIDF.Threshold <= 0 || c.TFIDF.Threshold > 1 {
+		return fmt.Errorf("tfidf threshold must be in (0, 1], got %.2f", c.TFIDF.Threshold)
+	}
+	declared := make(map[string]bool)
+	for _, field := range c.SchemaFields() {
+		declared[field.Name] = true
+	}
+	for _, field := range c.TFIDF.Fields {
+		if !declared[field] {
+			return fmt.Errorf("tfidf field %s is not declared in the schema", field)
+		}
+	}
+	return nil
+}
+
+// SimilarityOptions returns the similarity registry options described by the configuration
+func (c *Config) SimilarityOptions() similarity.RegistryOptions {
+	opts := similarity.RegistryOptions{
+		NamePhonetic:       c.Matching.NamePhonetic,
+		NamePhoneticWeight: c.Matching.NamePhoneticWeight,
+		NameBlend:          c.Similarity.NameBlend,
+		AddressBlend:       c.Similarity.AddressBlend,
+		FieldTypes:         c.Similarity.FieldTypes,
+
+		NumericScale:         c.Similarity.Numeric.Scale,
+		NumericRelativeScale: c.Similarity.Numeric.RelativeScale,
+		DateToleranceDays:    c.Similarity.Date.ToleranceDays,
+		DateYearTolerance:    c.Similarity.Date.YearTolerance,
+		DateDayFirst:         c.Similarity.Date.DayFirst,
+		YearTolerance:        c.Similarity.Year.Tolerance,
+	}
+	for _, composite := range c.Similarity.Composites {
+		opts.Composites = append(opts.Composites, similarity.CompositeSpec{Name: composite.Name, Expression: composite.Expression})
+	}
+	for _, pattern := range c.Similarity.FieldPatterns {
+		opts.FieldPatterns = append(opts.FieldPatterns, similarity.FieldPattern{Function: pattern.Function, Contains: pattern.Contains})
+	}
+	return opts
+}
+
+// ValidateBlockingRules checks that every blocking rule and sort key has a unique name and known transforms
+func (c *Config) ValidateBlockingRules() error {
+	if err := validateRules("blocking rule", c.Clustering.Rules); err != nil {
+		return err
+	}
+	return validateRules("sort key", c.Clustering.SortedNeighborhood.Keys)
+}
+
+// validateRules checks a list of blocking rules or sort keys
+func validateRules(kind string, rules []BlockingRule) error {
+	seen := make(map[string]bool)
+	for i, rule := range rules {
+		if rule.Name == "" {
+			return fmt.Errorf("%s %d has no name", kind, i)
+		}
+		if !fieldNameRegex.MatchString(rule.Name) {
+			return fmt.Errorf("%s %q must start with a letter or underscore and contain only letters, digits and underscores", kind, rule.Name)
+		}
+		if seen[rule.Name] {
+			return fmt.Errorf("%s %q is declared more than once", kind, rule.Name)
+		}
+		seen[rule.Name] = true
+
+		if len(rule.Fields) == 0 {
+			return fmt.Errorf("%s %q has no fields", kind, rule.Name)
+		}
+		for _, field := range rule.Fields {
+			if field.Field == "" {
+				return fmt.Errorf("%s %q has a component with no field", kind, rule.Name)
+			}
+			if field.Transform != "" && !BlockingTransforms[field.Transform] {
+				return fmt.Errorf("%s %q uses unknown transform %q", kind, rule.Name, field.Transform)
+			}
+			switch field.Transform {
+			case "prefix", "suffix", "last_digits":
+				if field.Length <= 0 {
+					return fmt.Errorf("%s %q needs a positive length for the %s transform", kind, rule.Name, field.Transform)
+				}
+			}
+		}
+	}
+	return nil
+}
+
 // setDefaults sets default values for the configuration
 func setDefaults(v *viper.Viper) {
 	// Server defaults
@@ -129,6 +552,9 @@ func setDefaults(v *viper.Viper) {
 	// Matching defaults
 	v.SetDefault("matching.similarity_threshold", 0.85)
 	v.SetDefault("matching.default_limit", 10)
+	v.SetDefault("matching.scorer", "vector")
+	v.SetDefault("matching.min_group_similarity", 0.0)
+	v.SetDefault("matching.name_phonetic_weight", 0.2)
 	v.SetDefault("matching.field_weights", map[string]float32{
 		"name":    0.4,
 		"address": 0.2,
@@ -158,11 +584,62 @@ func setDefaults(v *viper.Viper) {
 		"lowercase_domain": true,
 	})
 
+	// Schema defaults
+	defaultFields := make([]map[string]string, 0)
+	for _, field := range DefaultSchemaFields() {
+		defaultFields = append(defaultFields, map[string]string{
+			"name":        field.Name,
+			"type":        field.Type,
+			"normalizer":  field.Normalizer,
+			"description": field.Description,
+		})
+	}
+	v.SetDefault("schema.fields", defaultFields)
+
 	// Clustering defaults
 	v.SetDefault("clustering.enabled", false)
-	v.SetDefault("clustering.method", "canopy")
+	v.SetDefault("clustering.method", "key")
 	v.SetDefault("clustering.fields", []string{"name", "zip"})
 	v.SetDefault("clustering.similarity_threshold", 0.8)
+	v.SetDefault("clustering.tight_threshold", 0.9)
+	v.SetDefault("clustering.distance", "jaccard")
+	v.SetDefault("clustering.bands", 20)
+	v.SetDefault("clustering.rows", 5)
+	v.SetDefault("clustering.shingle_size", 3)
+	v.SetDefault("clustering.max_band_searches", 10)
+	v.SetDefault("clustering.sorted_neighborhood.window", 10)
+
+	// Resolution defaults
+	v.SetDefault("resolution.enabled", false)
+
+	// Survivorship defaults
+	v.SetDefault("survivorship.default_rule", "most_frequent")
+	v.SetDefault("survivorship.source_field", "source")
+
+	// Override defaults
+	v.SetDefault("overrides.path", "overrides.json")
+
+	// Review defaults
+	v.SetDefault("review.enabled", false)
+	v.SetDefault("review.auto_match", 0.95)
+	v.SetDefault("review.auto_reject", 0.75)
+	v.SetDefault("review.path", "review.json")
+
+	// TF-IDF defaults
+	v.SetDefault("tfidf.enabled", false)
+	v.SetDefault("tfidf.fields", []string{"name", "address"})
+	v.SetDefault("tfidf.threshold", similarity.DefaultSoftTFIDFThreshold)
+	v.SetDefault("tfidf.path", "tfidf.json")
+
+	// Similarity defaults
+	v.SetDefault("similarity.name_blend", similarity.DefaultNameBlend)
+	v.SetDefault("similarity.address_blend", similarity.DefaultAddressBlend)
+	v.SetDefault("similarity.numeric.scale", 0.0)
+	v.SetDefault("similarity.numeric.relative_scale", similarity.DefaultNumericRelativeScale)
+	v.SetDefault("similarity.date.tolerance_days", similarity.DefaultDateToleranceDays)
+	v.SetDefault("similarity.date.year_tolerance", similarity.DefaultDateYearTolerance)
+	v.SetDefault("similarity.date.day_first", false)
+	v.SetDefault("similarity.year.tolerance", similarity.DefaultYearTolerance)
 }
 
 // SaveDefault saves the default configuration to a file
@@ -0,0 +1,192 @@
+package correlation
+
+import "sort"
+
+// Edge is a signed link between two records. A positive weight favors putting them in the
+// same cluster and a negative weight favors keeping them apart.
+type Edge struct {
+	A, B   string
+	Weight float64
+}
+
+// Options controls correlation clustering
+type Options struct {
+	MaxIterations int // Maximum local search passes (default 10)
+}
+
+// epsilon is the smallest improvement that counts as a better move
+const epsilon = 1e-9
+
+// Cluster partitions records to maximize agreement with the edges: positive edges within
+// clusters and negative edges across them. Pairs without an edge weigh nothing.
+//
+// The initial partition comes from pivot clustering: records are visited by decreasing
+// total positive weight, and each unclustered record starts a cluster holding itself and
+// its unclustered positive neighbors. Local search then moves single records to the
+// cluster, or a new cluster of their own, that most improves agreement, until no move
+// helps. Edges involving records outside ids are ignored. Groups are sorted and ordered
+// by their first ID.
+func Cluster(ids []string, edges []Edge, opts Options) [][]string {
+	if opts.MaxIterations <= 0 {
+		opts.MaxIterations = 10
+	}
+
+	// Index the records and their weights
+	nodes := uniqueSorted(ids)
+	index := make(map[string]int, len(nodes))
+	for i, id := range nodes {
+		index[id] = i
+	}
+	weights := make([]map[int]float64, len(nodes))
+	for i := range weights {
+		weights[i] = make(map[int]float64)
+	}
+	strength := make([]float64, len(nodes))
+	for _, edge := range edges {
+		a, okA := index[edge.A]
+		b, okB := index[edge.B]
+		if !okA || !okB || a == b {
+			continue
+		}
+		weights[a][b] += edge.Weight
+		weights[b][a] += edge.Weight
+		if edge.Weight > 0 {
+			strength[a] += edge.Weight
+			strength[b] += edge.Weight
+		}
+	}
+
+	// Pivot clustering, strongest records first
+	order := make([]int, len(nodes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return strength[order[i]] > strength[order[j]]
+	})
+
+	assignment := make([]int, len(nodes))
+	for i := range assignment {
+		assignment[i] = -1
+	}
+	clusters := 0
+	for _, pivot := range order {
+		if assignment[pivot] >= 0 {
+			continue
+		}
+		assignment[pivot] = clusters
+		for _, neighbor := range sortedNeighbors(weights[pivot]) {
+			if assignment[neighbor] < 0 && weights[pivot][neighbor] > 0 {
+				assignment[neighbor] = clusters
+			}
+		}
+		clusters++
+	}
+
+	// Local search: move records while it improves agreement
+	for iteration := 0; iteration < opts.MaxIterations; iteration++ {
+		moved := false
+		for node := range nodes {
+			affinity := make(map[int]float64)
+			for _, neighbor := range sortedNeighbors(weights[node]) {
+				affinity[assignment[neighbor]] += weights[node][neighbor]
+			}
+
+			// Staying put, joining another cluster and standing alone (affinity 0) are the options
+			current := assignment[node]
+			best, bestCluster := affinity[current], current
+			for cluster := 0; cluster < clusters; cluster++ {
+				if weight, ok := affinity[cluster]; ok && weight > best+epsilon {
+					best, bestCluster = weight, cluster
+				}
+			}
+			if best < -epsilon && !alone(assignment, node) {
+				best, bestCluster = 0, clusters
+				clusters++
+			}
+
+			if bestCluster != current {
+				assignment[node] = bestCluster
+				moved = true
+			}
+		}
+		if !moved {
+			break
+		}
+	}
+
+	// Collect the groups
+	byCluster := make(map[int][]string)
+	for node, cluster := range assignment {
+		byCluster[cluster] = append(byCluster[cluster], nodes[node])
+	}
+	groups := make([][]string, 0, len(byCluster))
+	for _, members := range byCluster {
+		groups = append(groups, members) // Members are already sorted
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i][0] < groups[j][0]
+	})
+	return groups
+}
+
+// Disagreement returns the total weight of the edges a partition violates: positive edges
+// across clusters and negative edges within them
+func Disagreement(groups [][]string, edges []Edge) float64 {
+	cluster := make(map[string]int)
+	for i, group := range groups {
+		for _, id := range group {
+			cluster[id] = i
+		}
+	}
+
+	var total float64
+	for _, edge := range edges {
+		a, okA := cluster[edge.A]
+		b, okB := cluster[edge.B]
+		if !okA || !okB {
+			continue
+		}
+		if a == b && edge.Weight < 0 {
+			total -= edge.Weight
+		} else if a != b && edge.Weight > 0 {
+			total += edge.Weight
+		}
+	}
+	return total
+}
+
+// alone reports whether a record is the only member of its cluster
+func alone(assignment []int, node int) bool {
+	for other, cluster := range assignment {
+		if other != node && cluster == assignment[node] {
+			return false
+		}
+	}
+	return true
+}
+
+// sortedNeighbors returns the neighbors of a record in index order, so that clustering
+// does not depend on map iteration order
+func sortedNeighbors(weights map[int]float64) []int {
+	neighbors := make([]int, 0, len(weights))
+	for neighbor := range weights {
+		neighbors = append(neighbors, neighbor)
+	}
+	sort.Ints(neighbors)
+	return neighbors
+}
+
+// uniqueSorted returns the distinct IDs in order
+func uniqueSorted(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	unique := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
@@ -0,0 +1,35 @@
+package correlation
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClusterBreaksChains(t *testing.T) {
+	// Two tight triangles joined by one weak link; records across them disagree
+	var edges []Edge
+	for _, triangle := range [][]string{{"a", "b", "c"}, {"d", "e", "f"}} {
+		edges = append(edges,
+			Edge{A: triangle[0], B: triangle[1], Weight: 0.5},
+			Edge{A: triangle[0], B: triangle[2], Weight: 0.5},
+			Edge{A: triangle[1], B: triangle[2], Weight: 0.5})
+	}
+	edges = append(edges, Edge{A: "c", B: "d", Weight: 0.1})
+	for _, a := range []string{"a", "b", "c"} {
+		for _, b := range []string{"d", "e", "f"} {
+			if a != "c" || b != "d" {
+				edges = append(edges, Edge{A: a, B: b, Weight: -0.2})
+			}
+		}
+	}
+
+	// The pivot c pulls in d; local search moves d back to its triangle
+	groups := Cluster([]string{"a", "b", "c", "d", "e", "f", "g"}, edges, Options{})
+	want := [][]string{{"a", "b", "c"}, {"d", "e", "f"}, {"g"}}
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("expected %v, got %v", want, groups)
+	}
+	if got := Disagreement(groups, edges); got < 0.099 || got > 0.101 {
+		t.Errorf("expected only the weak link to be cut, got disagreement %f", got)
+	}
+}
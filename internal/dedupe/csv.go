@@ -0,0 +1,122 @@
+package dedupe
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/TFMV/resolve/internal/config"
+)
+
+// Table is a CSV file read for deduplication
+type Table struct {
+	Header   []string   // Input columns
+	Rows     [][]string // Input rows, aligned with Records
+	Records  []Record   // Records built from the schema columns
+	idColumn int        // Index of the ID column, or -1 if IDs are row numbers
+}
+
+// ReadCSV reads a CSV file with a header row. Columns named after schema fields
+// (case-insensitively) become record fields; other columns are carried through to the
+// output. The idColumn column identifies records; if it is absent, records are
+// numbered from 1 in file order.
+func ReadCSV(r io.Reader, idColumn string, fields []config.FieldConfig) (*Table, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("input has no header row")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input header: %w", err)
+	}
+
+	// Map columns to schema fields
+	table := &Table{Header: header, idColumn: -1}
+	columns := make(map[int]string)
+	for i, column := range header {
+		name := strings.TrimSpace(column)
+		if strings.EqualFold(name, idColumn) {
+			table.idColumn = i
+			continue
+		}
+		for _, field := range fields {
+			if strings.EqualFold(name, field.Name) {
+				columns[i] = field.Name
+			}
+		}
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no input columns match the entity schema")
+	}
+
+	seen := make(map[string]bool)
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read input: %w", err)
+		}
+
+		id := strconv.Itoa(len(table.Records) + 1)
+		if table.idColumn >= 0 {
+			id = strings.TrimSpace(row[table.idColumn])
+		}
+		if id == "" || seen[id] {
+			return nil, fmt.Errorf("missing or duplicate record ID %q on line %d", id, line)
+		}
+		seen[id] = true
+
+		record := Record{ID: id, Fields: make(map[string]string, len(columns))}
+		for i, field := range columns {
+			if value := strings.TrimSpace(row[i]); value != "" {
+				record.Fields[field] = value
+			}
+		}
+
+		table.Rows = append(table.Rows, row)
+		table.Records = append(table.Records, record)
+	}
+
+	return table, nil
+}
+
+// WriteCSV writes every input row followed by its cluster ID and confidence. A
+// record_id column is added first when the input had no ID column.
+func WriteCSV(w io.Writer, table *Table, result *Result) error {
+	writer := csv.NewWriter(w)
+
+	header := append([]string(nil), table.Header...)
+	if table.idColumn < 0 {
+		header = append([]string{"record_id"}, header...)
+	}
+	if err := writer.Write(append(header, "cluster_id", "confidence")); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+
+	for i, row := range table.Rows {
+		record := table.Records[i]
+		assignment := result.Assignments[record.ID]
+
+		out := append([]string(nil), row...)
+		if table.idColumn < 0 {
+			out = append([]string{record.ID}, out...)
+		}
+		out = append(out, assignment.ClusterID, strconv.FormatFloat(assignment.Confidence, 'f', 4, 64))
+		if err := writer.Write(out); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,317 @@
+package dedupe
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/TFMV/resolve/internal/cluster"
+	"github.com/TFMV/resolve/internal/config"
+	"github.com/TFMV/resolve/internal/embed"
+	"github.com/TFMV/resolve/internal/normalize"
+	"github.com/TFMV/resolve/internal/override"
+	"github.com/TFMV/resolve/internal/similarity"
+	"github.com/TFMV/resolve/internal/store"
+)
+
+// Deduplication defaults
+const (
+	DefaultThreshold       = 0.85
+	DefaultMaxBlockSize    = cluster.DefaultMaxBlockSize
+	DefaultEmbeddingWeight = 0.5
+	embeddingBatchSize     = 64
+)
+
+// Record is a single input record
+type Record struct {
+	ID     string
+	Fields map[string]string
+}
+
+// Blocking methods
+const (
+	BlockingKey                = "key"                 // Compare records sharing a blocking key
+	BlockingSortedNeighborhood = "sorted_neighborhood" // Compare records neighboring in sort order
+)
+
+// Options controls deduplication
+type Options struct {
+	Threshold       float64                // Minimum pair score to link two records
+	Blocking        string                 // BlockingKey (the default) or BlockingSortedNeighborhood
+	MaxBlockSize    int                    // Larger key blocks are capped or split to this size
+	Oversized       string                 // cluster.OversizedCap (the default) or cluster.OversizedSplit
+	Window          int                    // Number of following records compared by sorted-neighborhood blocking (default from config)
+	EmbeddingWeight float64                // Share of the pair score taken by embedding similarity when embeddings are used
+	Embeddings      embed.EmbeddingService // Optional embedding service; nil compares fields only
+	Constraints     *override.Constraints  // Optional steward overrides, which win over any score
+}
+
+// Assignment is the cluster a record was placed in
+type Assignment struct {
+	ClusterID  string  `json:"cluster_id"`
+	Confidence float64 `json:"confidence"` // Best link score within the cluster, or 1 minus the best score to any other record for singletons
+}
+
+// Result is the outcome of deduplicating a dataset
+type Result struct {
+	Assignments map[string]Assignment `json:"assignments"`
+	Records     int                   `json:"records"`
+	Blocks      int                   `json:"blocks"`
+	Comparisons int                   `json:"comparisons"`
+	Links       int                   `json:"links"`
+	Clusters    int                   `json:"clusters"`
+}
+
+// Deduper finds duplicate records within a dataset entirely in process
+type Deduper struct {
+	cfg           *config.Config
+	opts          Options
+	schemaFields  []config.FieldConfig
+	normalizer    *normalize.Normalizer
+	blocker       cluster.Blocker
+	similarityReg *similarity.Registry
+}
+
+// NewDeduper creates a deduper, blocking records with NewBlocker
+func NewDeduper(cfg *config.Config, opts Options) (*Deduper, error) {
+	// Apply defaults
+	if opts.Threshold <= 0 {
+		opts.Threshold = DefaultThreshold
+	}
+	if opts.MaxBlockSize <= 1 {
+		opts.MaxBlockSize = DefaultMaxBlockSize
+	}
+	if opts.EmbeddingWeight <= 0 || opts.EmbeddingWeight > 1 {
+		opts.EmbeddingWeight = DefaultEmbeddingWeight
+	}
+
+	blocker, err := NewBlocker(cfg, opts)
+	if err != nil {
+		return nil, err
+	}
+	normalizer := normalize.NewNormalizer(cfg)
+	similarityReg, err := similarity.NewRegistryWithOptions(cfg.SimilarityOptions())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create similarity registry: %w", err)
+	}
+
+	return &Deduper{
+		cfg:           cfg,
+		opts:          opts,
+		schemaFields:  cfg.SchemaFields(),
+		normalizer:    normalizer,
+		blocker:       blocker,
+		similarityReg: similarityReg,
+	}, nil
+}
+
+// NewBlocker creates the blocker selected by the options. With key blocking, records are
+// blocked with the configured clustering method and blocking rules, or on the clustering
+// fields (falling back to the name) if there are none, whether or not clustering is enabled
+// for the vector store. Sorted-neighborhood blocking uses the configured sort keys, or the
+// clustering fields if there are none.
+func NewBlocker(cfg *config.Config, opts Options) (cluster.Blocker, error) {
+	blockFields := cfg.Clustering.Fields
+	if len(blockFields) == 0 {
+		blockFields = []string{"name"}
+	}
+
+	switch opts.Blocking {
+	case "", BlockingKey:
+		blocker, err := cluster.NewKeyBlocker(cluster.NewService(&cluster.Config{
+			Enabled:             true,
+			Method:              cfg.Clustering.Method,
+			Fields:              blockFields,
+			SimilarityThreshold: cfg.Clustering.SimilarityThreshold,
+			TightThreshold:      cfg.Clustering.TightThreshold,
+			Distance:            cfg.Clustering.Distance,
+			Bands:               cfg.Clustering.Bands,
+			Rows:                cfg.Clustering.Rows,
+			ShingleSize:         cfg.Clustering.ShingleSize,
+			MaxBandSearches:     cfg.Clustering.MaxBandSearches,
+			Rules:               cfg.Clustering.Rules,
+			Phonetic:            cfg.Clustering.Phonetic,
+		}, normalize.NewNormalizer(cfg)), opts.MaxBlockSize, opts.Oversized)
+		if err != nil {
+			return nil, err
+		}
+		return blocker, nil
+	case BlockingSortedNeighborhood:
+		window := opts.Window
+		if window <= 0 {
+			window = cfg.Clustering.SortedNeighborhood.Window
+		}
+		return cluster.NewSortedNeighborhoodBlocker(cfg.Clustering.SortedNeighborhood.Keys, blockFields, window), nil
+	default:
+		return nil, fmt.Errorf("unknown blocking method: %s", opts.Blocking)
+	}
+}
+
+// Dedupe normalizes and blocks the records, scores every candidate pair, and clusters
+// the records by linking pairs scoring at or above the threshold. Must-link overrides
+// always link their records and pairs crossing a cannot-link override are not linked.
+func (d *Deduper) Dedupe(ctx context.Context, records []Record) (*Result, error) {
+	// Normalize fields
+	normalized := make([]map[string]string, len(records))
+	for i, record := range records {
+		normalized[i] = d.normalizer.NormalizeEntity(record.Fields)
+	}
+
+	// Optionally embed records
+	var vectors [][]float32
+	if d.opts.Embeddings != nil {
+		var err error
+		vectors, err = d.embed(ctx, normalized)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Score candidate pairs
+	result := &Result{Records: len(records)}
+	best := make([]float64, len(records)) // Best score to any compared record
+	var links []override.Pair
+	blocks, err := d.blocker.Pairs(ctx, normalized, func(i, j int) {
+		result.Comparisons++
+		score := d.score(normalized[i], normalized[j])
+		if vectors != nil {
+			score = (1-d.opts.EmbeddingWeight)*score + d.opts.EmbeddingWeight*store.CosineSimilarity(vectors[i], vectors[j])
+		}
+
+		best[i], best[j] = math.Max(best[i], score), math.Max(best[j], score)
+		if score >= d.opts.Threshold {
+			links = append(links, override.Pair{A: records[i].ID, B: records[j].ID, Score: score})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Blocks = blocks
+
+	// Cluster the linked records, honoring the overrides
+	ids := make([]string, len(records))
+	index := make(map[string]int, len(records))
+	for i, record := range records {
+		ids[i] = record.ID
+		index[record.ID] = i
+	}
+	groups := d.opts.Constraints.Cluster(ids, links)
+
+	cluster := make(map[string]int, len(records))
+	for g, group := range groups {
+		for _, id := range group {
+			cluster[id] = g
+		}
+	}
+
+	// Best score to a record in the same cluster; must-linked records are certain
+	linked := make([]float64, len(records))
+	for _, link := range links {
+		if cluster[link.A] == cluster[link.B] {
+			result.Links++
+			i, j := index[link.A], index[link.B]
+			linked[i], linked[j] = math.Max(linked[i], link.Score), math.Max(linked[j], link.Score)
+		}
+	}
+	for _, pair := range d.opts.Constraints.MustLinks() {
+		i, okA := index[pair[0]]
+		j, okB := index[pair[1]]
+		if okA && okB {
+			linked[i], linked[j] = 1, 1
+		}
+	}
+
+	// Assign clusters, named after their first member
+	result.Clusters = len(groups)
+	result.Assignments = make(map[string]Assignment, len(records))
+	for _, group := range groups {
+		for _, id := range group {
+			i := index[id]
+			confidence := linked[i]
+			if len(group) == 1 {
+				confidence = 1 - best[i]
+			}
+			result.Assignments[id] = Assignment{ClusterID: group[0], Confidence: confidence}
+		}
+	}
+
+	return result, nil
+}
+
+// score returns the weighted average similarity of the schema fields present in both records
+func (d *Deduper) score(a, b map[string]string) float64 {
+	var total, weights float64
+	for _, field := range d.schemaFields {
+		valueA, valueB := a[field.Name+"_normalized"], b[field.Name+"_normalized"]
+		if valueA == "" || valueB == "" {
+			valueA, valueB = a[field.Name], b[field.Name]
+		}
+		if valueA == "" || valueB == "" {
+			continue
+		}
+
+		weight := d.fieldWeight(field.Name)
+		if weight <= 0 {
+			continue
+		}
+
+		score := 1.0
+		if valueA != valueB {
+			score = d.similarityReg.GetByFieldType(field.Type).Compare(valueA, valueB)
+		}
+		total += weight * score
+		weights += weight
+	}
+
+	if weights == 0 {
+		return 0
+	}
+	return total / weights
+}
+
+// fieldWeight returns the configured weight of a field. Fields without a configured weight,
+// or every field when none are configured, get an equal share of the schema.
+func (d *Deduper) fieldWeight(field string) float64 {
+	if weight, ok := d.cfg.Matching.FieldWeights[field]; ok {
+		return float64(weight)
+	}
+	return 1 / float64(len(d.schemaFields))
+}
+
+// embed generates embeddings for the normalized records in batches
+func (d *Deduper) embed(ctx context.Context, normalized []map[string]string) ([][]float32, error) {
+	texts := make([]string, len(normalized))
+	for i, fields := range normalized {
+		var parts []string
+		for _, field := range d.schemaFields {
+			value := fields[field.Name+"_normalized"]
+			if value == "" {
+				value = fields[field.Name]
+			}
+			if value != "" {
+				parts = append(parts, value)
+			}
+		}
+		texts[i] = strings.Join(parts, " ")
+	}
+
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += embeddingBatchSize {
+		end := start + embeddingBatchSize
+		if end > len(texts) {
+			end = len(texts)
+		}
+
+		batch, err := d.opts.Embeddings.GetEmbeddingBatch(ctx, texts[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate embeddings: %w", err)
+		}
+		if len(batch) != end-start {
+			return nil, fmt.Errorf("embedding service returned %d embeddings for %d records", len(batch), end-start)
+		}
+		vectors = append(vectors, batch...)
+	}
+
+	return vectors, nil
+}
@@ -0,0 +1,189 @@
+package dedupe
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/TFMV/resolve/internal/config"
+	"github.com/TFMV/resolve/internal/embed"
+	"github.com/TFMV/resolve/internal/override"
+)
+
+const testInput = `id,name,address,zip,phone,notes
+1,Acme Corporation,123 Main Street,10001,555-123-4567,first
+2,Acme Corp,123 Main St,10001,(555) 123-4567,second
+3,Globex,9 Elm Road,94105,555-987-6543,third
+4,Acme Corporation,123 Main Street,10001,,fourth
+`
+
+func newTestConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.Normalization.EnableLowercase = true
+	cfg.Normalization.NameOptions = map[string]bool{"remove_legal_suffixes": true}
+	cfg.Clustering.Fields = []string{"name", "zip"}
+	return cfg
+}
+
+func TestDedupe(t *testing.T) {
+	cfg := newTestConfig()
+	table, err := ReadCSV(strings.NewReader(testInput), "id", cfg.SchemaFields())
+	if err != nil {
+		t.Fatalf("ReadCSV: %v", err)
+	}
+	if len(table.Records) != 4 || table.Records[3].Fields["phone"] != "" {
+		t.Fatalf("unexpected records: %+v", table.Records)
+	}
+
+	deduper, err := NewDeduper(cfg, Options{Threshold: 0.85})
+	if err != nil {
+		t.Fatalf("NewDeduper: %v", err)
+	}
+	result, err := deduper.Dedupe(context.Background(), table.Records)
+	if err != nil {
+		t.Fatalf("Dedupe: %v", err)
+	}
+
+	acme := result.Assignments["1"].ClusterID
+	if result.Assignments["2"].ClusterID != acme || result.Assignments["4"].ClusterID != acme {
+		t.Errorf("expected the Acme records in one cluster, got %+v", result.Assignments)
+	}
+	if result.Assignments["3"].ClusterID == acme {
+		t.Errorf("Globex should not join the Acme cluster")
+	}
+	if result.Clusters != 2 {
+		t.Errorf("expected 2 clusters, got %d", result.Clusters)
+	}
+	for id, assignment := range result.Assignments {
+		if assignment.Confidence <= 0 || assignment.Confidence > 1 {
+			t.Errorf("record %s has confidence %f", id, assignment.Confidence)
+		}
+	}
+
+	// Output keeps every input column and adds the assignment
+	var out bytes.Buffer
+	if err := WriteCSV(&out, table, result); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 5 || lines[0] != "id,name,address,zip,phone,notes,cluster_id,confidence" {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+	if !strings.HasPrefix(lines[3], "3,Globex,9 Elm Road,94105,555-987-6543,third,3,") {
+		t.Errorf("unexpected Globex row: %s", lines[3])
+	}
+}
+
+func TestDedupeWithEmbeddings(t *testing.T) {
+	cfg := newTestConfig()
+	table, err := ReadCSV(strings.NewReader(testInput), "missing", cfg.SchemaFields())
+	if err != nil {
+		t.Fatalf("ReadCSV: %v", err)
+	}
+	if table.Records[0].ID != "1" {
+		t.Errorf("expected row-numbered IDs, got %q", table.Records[0].ID)
+	}
+
+	deduper, err := NewDeduper(cfg, Options{Threshold: 0.5, Embeddings: embed.NewMockEmbeddingService(16)})
+	if err != nil {
+		t.Fatalf("NewDeduper: %v", err)
+	}
+	result, err := deduper.Dedupe(context.Background(), table.Records)
+	if err != nil {
+		t.Fatalf("Dedupe: %v", err)
+	}
+	if len(result.Assignments) != 4 {
+		t.Errorf("expected 4 assignments, got %d", len(result.Assignments))
+	}
+}
+
+func TestFieldWeightDefaults(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Matching.FieldWeights = map[string]float32{"name": 0.5}
+	deduper, err := NewDeduper(cfg, Options{})
+	if err != nil {
+		t.Fatalf("NewDeduper: %v", err)
+	}
+
+	// Fields without a configured weight still count
+	a := map[string]string{"name": "acme", "zip": "10001"}
+	b := map[string]string{"name": "acme", "zip": "94105"}
+	if got := deduper.score(a, b); got >= 1 {
+		t.Errorf("expected the differing zip to lower the score, got %f", got)
+	}
+	if got := deduper.fieldWeight("zip"); got != 1/float64(len(cfg.SchemaFields())) {
+		t.Errorf("expected an equal share for zip, got %f", got)
+	}
+}
+
+func TestDedupeSortedNeighborhood(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Clustering.SortedNeighborhood.Keys = []config.BlockingRule{
+		{Name: "name", Fields: []config.BlockingField{{Field: "name"}}},
+		{Name: "phone", Fields: []config.BlockingField{{Field: "phone", Transform: "digits"}}},
+	}
+	table, err := ReadCSV(strings.NewReader(testInput), "id", cfg.SchemaFields())
+	if err != nil {
+		t.Fatalf("ReadCSV: %v", err)
+	}
+
+	deduper, err := NewDeduper(cfg, Options{Threshold: 0.85, Blocking: BlockingSortedNeighborhood, Window: 1})
+	if err != nil {
+		t.Fatalf("NewDeduper: %v", err)
+	}
+	result, err := deduper.Dedupe(context.Background(), table.Records)
+	if err != nil {
+		t.Fatalf("Dedupe: %v", err)
+	}
+
+	// Four records sorted by name and three with phones give 3+2 neighbor pairs, one in both passes
+	if result.Blocks != 2 || result.Comparisons != 4 {
+		t.Errorf("expected 2 passes and 4 comparisons, got %d and %d", result.Blocks, result.Comparisons)
+	}
+	acme := result.Assignments["1"].ClusterID
+	if result.Assignments["2"].ClusterID != acme || result.Assignments["3"].ClusterID == acme {
+		t.Errorf("unexpected assignments: %+v", result.Assignments)
+	}
+
+	if _, err := NewDeduper(cfg, Options{Blocking: "unknown"}); err == nil {
+		t.Error("expected an error for an unknown blocking method")
+	}
+}
+
+func TestDedupeWithOverrides(t *testing.T) {
+	cfg := newTestConfig()
+	table, err := ReadCSV(strings.NewReader(testInput), "id", cfg.SchemaFields())
+	if err != nil {
+		t.Fatalf("ReadCSV: %v", err)
+	}
+
+	constraints := override.NewConstraints([]override.Override{
+		{Kind: override.CannotLink, Records: []string{"1", "4"}},
+		{Kind: override.MustLink, Records: []string{"3", "4"}},
+	})
+	deduper, err := NewDeduper(cfg, Options{Threshold: 0.85, Constraints: constraints})
+	if err != nil {
+		t.Fatalf("NewDeduper: %v", err)
+	}
+	result, err := deduper.Dedupe(context.Background(), table.Records)
+	if err != nil {
+		t.Fatalf("Dedupe: %v", err)
+	}
+
+	// Record 4 leaves Acme for Globex despite its scores
+	assignments := result.Assignments
+	if assignments["1"].ClusterID != assignments["2"].ClusterID || assignments["1"].ClusterID == assignments["4"].ClusterID {
+		t.Errorf("expected record 4 to be separated from Acme, got %+v", assignments)
+	}
+	if assignments["3"].ClusterID != assignments["4"].ClusterID || assignments["4"].Confidence != 1 {
+		t.Errorf("expected record 4 to be linked to Globex with confidence 1, got %+v", assignments)
+	}
+}
+
+func TestReadCSVRejectsDuplicateIDs(t *testing.T) {
+	_, err := ReadCSV(strings.NewReader("id,name\n1,Acme\n1,Globex\n"), "id", config.DefaultSchemaFields())
+	if err == nil {
+		t.Error("expected an error for duplicate IDs")
+	}
+}
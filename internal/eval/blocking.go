@@ -0,0 +1,183 @@
+package eval
+
+import (
+	"context"
+	"sort"
+
+	"github.com/TFMV/resolve/internal/cluster"
+)
+
+// blockSizeBounds are the upper bounds of the block size histogram buckets
+var blockSizeBounds = []int{1, 2, 5, 10, 50, 100, 500, 1000}
+
+// SizeBucket counts the blocks whose size falls within [Min, Max]; Max is 0 for the open-ended last bucket
+type SizeBucket struct {
+	Min     int `json:"min"`
+	Max     int `json:"max"`
+	Blocks  int `json:"blocks"`
+	Records int `json:"records"` // Block memberships; a record in several passes counts once per block
+}
+
+// BlockSummary describes a single block
+type BlockSummary struct {
+	Key     string   `json:"key"` // "<pass>=<key>"
+	Size    int      `json:"size"`
+	Samples []string `json:"samples"` // First few member record IDs
+}
+
+// BlockingReport summarizes how a blocker partitions a dataset and which true matches it keeps
+type BlockingReport struct {
+	Records          int            `json:"records"`
+	Blocks           int            `json:"blocks"`                    // Blocks, or passes for blockers without blocks
+	Histogram        []SizeBucket   `json:"histogram,omitempty"`       // Block size distribution
+	Largest          []BlockSummary `json:"largest,omitempty"`         // Largest blocks, biggest first, and the default block
+	DefaultBlock     int            `json:"default_block"`             // Records with no blocking key, which share the default block
+	CandidatePairs   int            `json:"candidate_pairs"`           // Distinct pairs compared
+	TotalPairs       int64          `json:"total_pairs"`               // Pairs without blocking
+	ReductionRatio   float64        `json:"reduction_ratio"`           // 1 - candidate pairs / total pairs
+	TruePairs        int            `json:"true_pairs"`                // Matching pairs in the ground truth
+	FoundTruePairs   int            `json:"found_true_pairs"`          // True pairs among the candidates
+	PairCompleteness float64        `json:"pair_completeness"`         // Share of true pairs among the candidates (recall)
+	PairQuality      float64        `json:"pair_quality"`              // Share of candidates that are true pairs (precision)
+	HasTruth         bool           `json:"has_truth"`                 // Whether completeness and quality were measured
+	MissedExamples   [][2]string    `json:"missed_examples,omitempty"` // A few true pairs the blocker never compares
+}
+
+// ReportOptions controls the blocking report
+type ReportOptions struct {
+	Largest int   // Number of largest blocks to list
+	Samples int   // Number of sample record IDs per listed block
+	Truth   Truth // Optional ground truth for pair completeness
+}
+
+// EvaluateBlocking runs a blocker over the records and reports block sizes, candidate pairs
+// and, with a ground truth, pair completeness. Block statistics are only available for
+// blockers implementing cluster.BlockLister.
+func EvaluateBlocking(ctx context.Context, blocker cluster.Blocker, ids []string, records []map[string]string, opts ReportOptions) (*BlockingReport, error) {
+	n := len(records)
+	report := &BlockingReport{Records: n, TotalPairs: int64(n) * int64(n-1) / 2}
+
+	// Block statistics
+	if lister, ok := blocker.(cluster.BlockLister); ok {
+		blocks, err := lister.Blocks(ctx, records)
+		if err != nil {
+			return nil, err
+		}
+		summarizeBlocks(report, blocks, ids, opts)
+	}
+
+	// Candidate pairs
+	found := make(map[[2]int]bool)
+	passes, err := blocker.Pairs(ctx, records, func(i, j int) {
+		report.CandidatePairs++
+		if opts.Truth != nil && sameCluster(opts.Truth, ids[i], ids[j]) {
+			report.FoundTruePairs++
+			found[[2]int{min(i, j), max(i, j)}] = true
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if report.Histogram == nil {
+		report.Blocks = passes
+	}
+	if report.TotalPairs > 0 {
+		report.ReductionRatio = 1 - float64(report.CandidatePairs)/float64(report.TotalPairs)
+	}
+
+	// Pair completeness against the ground truth
+	if opts.Truth != nil {
+		report.HasTruth = true
+		members := make(map[string][]int)
+		for i, id := range ids {
+			if clusterID, ok := opts.Truth[id]; ok {
+				members[clusterID] = append(members[clusterID], i)
+			}
+		}
+
+		clusterIDs := make([]string, 0, len(members))
+		for clusterID := range members {
+			clusterIDs = append(clusterIDs, clusterID)
+		}
+		sort.Strings(clusterIDs)
+
+		for _, clusterID := range clusterIDs {
+			group := members[clusterID]
+			for a := 0; a < len(group); a++ {
+				for b := a + 1; b < len(group); b++ {
+					report.TruePairs++
+					if !found[[2]int{group[a], group[b]}] && len(report.MissedExamples) < opts.Samples {
+						report.MissedExamples = append(report.MissedExamples, [2]string{ids[group[a]], ids[group[b]]})
+					}
+				}
+			}
+		}
+
+		if report.TruePairs > 0 {
+			report.PairCompleteness = float64(report.FoundTruePairs) / float64(report.TruePairs)
+		}
+		if report.CandidatePairs > 0 {
+			report.PairQuality = float64(report.FoundTruePairs) / float64(report.CandidatePairs)
+		}
+	}
+
+	return report, nil
+}
+
+// summarizeBlocks fills in the block count, size histogram and largest blocks
+func summarizeBlocks(report *BlockingReport, blocks map[string][]int, ids []string, opts ReportOptions) {
+	report.Blocks = len(blocks)
+
+	report.Histogram = make([]SizeBucket, len(blockSizeBounds)+1)
+	lower := 1
+	for i, bound := range blockSizeBounds {
+		report.Histogram[i] = SizeBucket{Min: lower, Max: bound}
+		lower = bound + 1
+	}
+	report.Histogram[len(blockSizeBounds)] = SizeBucket{Min: lower}
+
+	summaries := make([]BlockSummary, 0, len(blocks))
+	for key, members := range blocks {
+		bucket := sort.SearchInts(blockSizeBounds, len(members))
+		report.Histogram[bucket].Blocks++
+		report.Histogram[bucket].Records += len(members)
+
+		if key == cluster.ClusterMetadataKey+"="+cluster.DefaultClusterID {
+			report.DefaultBlock += len(members)
+		}
+		summaries = append(summaries, BlockSummary{Key: key, Size: len(members)})
+	}
+
+	// List the largest blocks
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Size != summaries[j].Size {
+			return summaries[i].Size > summaries[j].Size
+		}
+		return summaries[i].Key < summaries[j].Key
+	})
+	defaultKey := cluster.ClusterMetadataKey + "=" + cluster.DefaultClusterID
+	if len(summaries) > opts.Largest {
+		// The default block is always listed
+		listed := summaries[:opts.Largest]
+		for _, summary := range summaries[opts.Largest:] {
+			if summary.Key == defaultKey {
+				listed = append(listed, summary)
+			}
+		}
+		summaries = listed
+	}
+	for i := range summaries {
+		members := blocks[summaries[i].Key]
+		for _, member := range members[:min(opts.Samples, len(members))] {
+			summaries[i].Samples = append(summaries[i].Samples, ids[member])
+		}
+	}
+	report.Largest = summaries
+}
+
+// sameCluster reports whether two records share a true cluster
+func sameCluster(truth Truth, a, b string) bool {
+	clusterA, okA := truth[a]
+	clusterB, okB := truth[b]
+	return okA && okB && clusterA == clusterB
+}
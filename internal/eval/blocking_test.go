@@ -0,0 +1,54 @@
+package eval
+
+import (
+	"context"
+	"testing"
+
+	"github.com/TFMV/resolve/internal/cluster"
+	"github.com/TFMV/resolve/internal/config"
+	"github.com/TFMV/resolve/internal/normalize"
+)
+
+func TestEvaluateBlocking(t *testing.T) {
+	service := cluster.NewService(&cluster.Config{Enabled: true, Fields: []string{"name"}}, normalize.NewNormalizer(&config.Config{}))
+	blocker, err := cluster.NewKeyBlocker(service, 10, cluster.OversizedCap)
+	if err != nil {
+		t.Fatalf("NewKeyBlocker: %v", err)
+	}
+
+	ids := []string{"a", "b", "c", "d", "e"}
+	records := []map[string]string{
+		{"name": "acme corp"},
+		{"name": "acme inc"},
+		{"name": "akme corp"},
+		{"name": "globex"},
+		{"name": ""},
+	}
+	truth := Truth{"a": "1", "b": "1", "c": "1", "d": "2", "e": "2"}
+
+	report, err := EvaluateBlocking(context.Background(), blocker, ids, records, ReportOptions{Largest: 1, Samples: 5, Truth: truth})
+	if err != nil {
+		t.Fatalf("EvaluateBlocking: %v", err)
+	}
+
+	if report.Blocks != 4 || report.DefaultBlock != 1 {
+		t.Errorf("expected 4 blocks and 1 record without a key, got %d and %d", report.Blocks, report.DefaultBlock)
+	}
+	if report.Histogram[0].Blocks != 3 || report.Histogram[1].Blocks != 1 {
+		t.Errorf("unexpected histogram: %+v", report.Histogram)
+	}
+	if len(report.Largest) != 2 || report.Largest[0].Size != 2 || report.Largest[1].Key != cluster.ClusterMetadataKey+"="+cluster.DefaultClusterID {
+		t.Errorf("expected the largest block and the default block, got %+v", report.Largest)
+	}
+
+	// Only a-b is compared out of 10 pairs; the true pairs are ab, ac, bc and de
+	if report.CandidatePairs != 1 || report.TotalPairs != 10 || !near(report.ReductionRatio, 0.9) {
+		t.Errorf("unexpected pair counts: %+v", report)
+	}
+	if report.TruePairs != 4 || !near(report.PairCompleteness, 0.25) || !near(report.PairQuality, 1) {
+		t.Errorf("unexpected completeness: %+v", report)
+	}
+	if len(report.MissedExamples) != 3 {
+		t.Errorf("expected 3 missed pairs, got %v", report.MissedExamples)
+	}
+}
@@ -0,0 +1,230 @@
+package eval
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/TFMV/resolve/internal/unionfind"
+)
+
+// Truth maps record IDs to their true cluster IDs
+type Truth map[string]string
+
+// ScoredPair is a candidate record pair with its match score
+type ScoredPair struct {
+	A     string  `json:"a"`
+	B     string  `json:"b"`
+	Score float64 `json:"score"`
+}
+
+// PRF holds precision, recall and F1
+type PRF struct {
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+}
+
+// Metrics reports resolution quality at a single threshold
+type Metrics struct {
+	Threshold         float64 `json:"threshold"`
+	Pairwise          PRF     `json:"pairwise"`           // Over all record pairs placed in the same cluster
+	BCubed            PRF     `json:"bcubed"`             // Per-record overlap between predicted and true clusters
+	Cluster           PRF     `json:"cluster"`            // Over clusters reproduced exactly
+	PredictedClusters int     `json:"predicted_clusters"` // Number of predicted clusters
+	TrueClusters      int     `json:"true_clusters"`      // Number of true clusters
+}
+
+// Report is the result of evaluating resolution over a range of thresholds
+type Report struct {
+	Records     int       `json:"records"`     // Records present in both the data and the truth file
+	Pairs       int       `json:"pairs"`       // Scored candidate pairs between those records
+	Curve       []Metrics `json:"curve"`       // Metrics for every threshold, ascending
+	Recommended Metrics   `json:"recommended"` // Threshold with the best pairwise F1
+}
+
+// ReadTruth parses a CSV of record_id,cluster_id rows. A header row and any further
+// columns, such as the generated_id column written by resolve gen, are skipped.
+func ReadTruth(r io.Reader) (Truth, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	truth := make(Truth)
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read truth file: %w", err)
+		}
+		if len(record) < 2 {
+			return nil, fmt.Errorf("expected record_id,cluster_id on line %d", line)
+		}
+
+		id, clusterID := strings.TrimSpace(record[0]), strings.TrimSpace(record[1])
+		if line == 1 && (strings.EqualFold(id, "record_id") || strings.EqualFold(id, "id")) {
+			continue // Header row
+		}
+		if id == "" || clusterID == "" {
+			return nil, fmt.Errorf("empty record or cluster ID on line %d", line)
+		}
+		truth[id] = clusterID
+	}
+
+	return truth, nil
+}
+
+// Thresholds returns evenly spaced thresholds from min to 1.0 inclusive
+func Thresholds(min, step float64) []float64 {
+	if step <= 0 {
+		step = 0.01
+	}
+
+	var thresholds []float64
+	steps := int(math.Round((1.0 - min) / step))
+	for i := 0; i <= steps; i++ {
+		thresholds = append(thresholds, math.Round((min+float64(i)*step)*1e6)/1e6)
+	}
+	return thresholds
+}
+
+// Evaluate scores the clustering produced at every threshold against the truth.
+// Only records present in both ids and the truth are considered; pairs scoring at
+// or above a threshold are linked and clusters are their transitive closure.
+func Evaluate(ids []string, pairs []ScoredPair, truth Truth, thresholds []float64) Report {
+	// Restrict to records with known truth
+	known := make(map[string]bool)
+	records := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := truth[id]; ok && !known[id] {
+			known[id] = true
+			records = append(records, id)
+		}
+	}
+
+	candidates := make([]ScoredPair, 0, len(pairs))
+	for _, pair := range pairs {
+		if known[pair.A] && known[pair.B] && pair.A != pair.B {
+			candidates = append(candidates, pair)
+		}
+	}
+
+	report := Report{Records: len(records), Pairs: len(candidates)}
+	thresholds = append([]float64(nil), thresholds...)
+	sort.Float64s(thresholds)
+
+	for _, threshold := range thresholds {
+		// Link every pair at or above the threshold
+		uf := unionfind.New()
+		for _, id := range records {
+			uf.Add(id)
+		}
+		for _, pair := range candidates {
+			if pair.Score >= threshold {
+				uf.Union(pair.A, pair.B)
+			}
+		}
+
+		metrics := ClusterMetrics(uf.Groups(), truth)
+		metrics.Threshold = threshold
+		report.Curve = append(report.Curve, metrics)
+
+		if metrics.Pairwise.F1 > report.Recommended.Pairwise.F1 || len(report.Curve) == 1 {
+			report.Recommended = metrics
+		}
+	}
+
+	return report
+}
+
+// ClusterMetrics compares predicted clusters with the truth. Every predicted
+// record must appear in the truth.
+func ClusterMetrics(predicted [][]string, truth Truth) Metrics {
+	// Size of every true cluster among the predicted records
+	trueSizes := make(map[string]int)
+	records := 0
+	for _, cluster := range predicted {
+		for _, id := range cluster {
+			trueSizes[truth[id]]++
+			records++
+		}
+	}
+
+	var truePositives, predictedPairs, truePairs float64
+	var bcubedPrecision, bcubedRecall float64
+	exactClusters := 0
+
+	for _, cluster := range predicted {
+		// Count how the predicted cluster splits across true clusters
+		overlap := make(map[string]int)
+		for _, id := range cluster {
+			overlap[truth[id]]++
+		}
+
+		size := float64(len(cluster))
+		predictedPairs += size * (size - 1) / 2
+		for trueID, n := range overlap {
+			shared := float64(n)
+			truePositives += shared * (shared - 1) / 2
+
+			// Every record of this overlap has the same B-cubed contribution
+			bcubedPrecision += shared * shared / size
+			bcubedRecall += shared * shared / float64(trueSizes[trueID])
+		}
+
+		if len(overlap) == 1 {
+			for trueID := range overlap {
+				if trueSizes[trueID] == len(cluster) {
+					exactClusters++
+				}
+			}
+		}
+	}
+
+	for _, n := range trueSizes {
+		size := float64(n)
+		truePairs += size * (size - 1) / 2
+	}
+
+	metrics := Metrics{
+		PredictedClusters: len(predicted),
+		TrueClusters:      len(trueSizes),
+		Pairwise:          newPRF(truePositives, predictedPairs, truePairs),
+		Cluster:           newPRF(float64(exactClusters), float64(len(predicted)), float64(len(trueSizes))),
+	}
+	if records > 0 {
+		precision := bcubedPrecision / float64(records)
+		recall := bcubedRecall / float64(records)
+		metrics.BCubed = PRF{Precision: precision, Recall: recall, F1: f1(precision, recall)}
+	}
+
+	return metrics
+}
+
+// newPRF computes precision and recall from counts. With nothing predicted or
+// nothing to find, the corresponding measure is 1.
+func newPRF(correct, predicted, actual float64) PRF {
+	prf := PRF{Precision: 1, Recall: 1}
+	if predicted > 0 {
+		prf.Precision = correct / predicted
+	}
+	if actual > 0 {
+		prf.Recall = correct / actual
+	}
+	prf.F1 = f1(prf.Precision, prf.Recall)
+	return prf
+}
+
+// f1 returns the harmonic mean of precision and recall
+func f1(precision, recall float64) float64 {
+	if precision+recall == 0 {
+		return 0
+	}
+	return 2 * precision * recall / (precision + recall)
+}
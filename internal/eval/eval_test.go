@@ -0,0 +1,79 @@
+package eval
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestReadTruth(t *testing.T) {
+	truth, err := ReadTruth(strings.NewReader("record_id,cluster_id\na,1\nb,1\nc,2\n"))
+	if err != nil {
+		t.Fatalf("ReadTruth: %v", err)
+	}
+	if len(truth) != 3 || truth["a"] != "1" || truth["c"] != "2" {
+		t.Errorf("unexpected truth: %v", truth)
+	}
+
+	// Columns after the cluster ID are ignored
+	truth, err = ReadTruth(strings.NewReader("record_id,cluster_id,generated_id\na,1,gen-1\nb,1,gen-1-d1\n"))
+	if err != nil || truth["b"] != "1" {
+		t.Errorf("expected the generated_id column to be ignored, got %v (%v)", truth, err)
+	}
+
+	if _, err := ReadTruth(strings.NewReader("a,1\nb,\n")); err == nil {
+		t.Error("expected an error for an empty cluster ID")
+	}
+}
+
+func TestClusterMetrics(t *testing.T) {
+	truth := Truth{"a": "1", "b": "1", "c": "1", "d": "2", "e": "3"}
+
+	// One true cluster split in two, and two true singletons merged
+	metrics := ClusterMetrics([][]string{{"a", "b"}, {"c"}, {"d", "e"}}, truth)
+
+	// Predicted pairs ab, de; true pairs ab, ac, bc
+	if !near(metrics.Pairwise.Precision, 0.5) || !near(metrics.Pairwise.Recall, 1.0/3) {
+		t.Errorf("unexpected pairwise metrics: %+v", metrics.Pairwise)
+	}
+
+	// Precision: a,b,c = 1; d,e = 1/2. Recall: a,b = 2/3; c = 1/3; d,e = 1
+	if !near(metrics.BCubed.Precision, 4.0/5) || !near(metrics.BCubed.Recall, (2.0/3*2+1.0/3+2)/5) {
+		t.Errorf("unexpected B-cubed metrics: %+v", metrics.BCubed)
+	}
+
+	// No predicted cluster reproduces a true cluster exactly
+	if metrics.Cluster.Precision != 0 || metrics.PredictedClusters != 3 || metrics.TrueClusters != 3 {
+		t.Errorf("unexpected cluster metrics: %+v", metrics)
+	}
+
+	perfect := ClusterMetrics([][]string{{"a", "b", "c"}, {"d"}, {"e"}}, truth)
+	if perfect.Pairwise.F1 != 1 || perfect.BCubed.F1 != 1 || perfect.Cluster.F1 != 1 {
+		t.Errorf("expected perfect scores, got %+v", perfect)
+	}
+}
+
+func TestEvaluateRecommendsBestThreshold(t *testing.T) {
+	truth := Truth{"a": "1", "b": "1", "c": "2", "d": "2"}
+	pairs := []ScoredPair{
+		{A: "a", B: "b", Score: 0.9},
+		{A: "c", B: "d", Score: 0.8},
+		{A: "b", B: "c", Score: 0.6},
+		{A: "a", B: "x", Score: 0.99}, // Unlabeled records are ignored
+	}
+
+	report := Evaluate([]string{"a", "b", "c", "d", "x"}, pairs, truth, Thresholds(0.5, 0.1))
+	if report.Records != 4 || report.Pairs != 3 {
+		t.Fatalf("expected 4 records and 3 pairs, got %d and %d", report.Records, report.Pairs)
+	}
+	if len(report.Curve) != 6 {
+		t.Fatalf("expected 6 thresholds, got %d", len(report.Curve))
+	}
+	if report.Recommended.Pairwise.F1 != 1 || report.Recommended.Threshold < 0.7 || report.Recommended.Threshold > 0.8 {
+		t.Errorf("unexpected recommendation: %+v", report.Recommended)
+	}
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
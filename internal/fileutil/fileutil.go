@@ -0,0 +1,24 @@
+package fileutil
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// WriteFile writes data to path through a temporary file in the same directory that is
+// renamed over it, so readers never see a partial write
+func WriteFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,32 @@
+package fileutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+
+	for _, content := range []string{"first", "second"} {
+		if err := WriteFile(path, []byte(content)); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || string(data) != content {
+			t.Errorf("expected %q, got %q (%v)", content, data, err)
+		}
+	}
+
+	// No temporary files are left behind
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("expected only the written file, got %d entries", len(entries))
+	}
+
+	if err := WriteFile(filepath.Join(dir, "missing", "state.json"), nil); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
@@ -0,0 +1,26 @@
+//go:build unix
+
+package fileutil
+
+import (
+	"os"
+	"syscall"
+)
+
+// Lock takes an exclusive advisory lock on path, waiting for other processes to release
+// it, and returns the function that releases it. The lock is held on a ".lock" file next
+// to path, so the locked file itself can be replaced while it is held.
+func Lock(path string) (func(), error) {
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
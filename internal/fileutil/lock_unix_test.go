@@ -0,0 +1,38 @@
+//go:build unix
+
+package fileutil
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	unlock, err := Lock(path)
+	if err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+
+	// A second holder waits until the first releases the lock
+	acquired := make(chan struct{})
+	go func() {
+		unlockSecond, err := Lock(path)
+		if err != nil {
+			t.Errorf("Lock: %v", err)
+			close(acquired)
+			return
+		}
+		close(acquired)
+		unlockSecond()
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("expected the second lock to wait")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	<-acquired
+}
@@ -0,0 +1,289 @@
+package generate
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Corruption names recorded in duplicate metadata
+const (
+	CorruptTypo        = "typo"
+	CorruptAbbreviate  = "abbreviate"
+	CorruptLegalSuffix = "legal_suffix"
+	CorruptPhoneFormat = "phone_format"
+	CorruptMissing     = "missing"
+	CorruptSwapTokens  = "swap_tokens"
+	CorruptAddressUnit = "address_unit"
+)
+
+// abbreviations maps words to their common abbreviations
+var abbreviations = map[string]string{
+	"street": "St", "avenue": "Ave", "road": "Rd", "boulevard": "Blvd", "drive": "Dr",
+	"lane": "Ln", "court": "Ct", "place": "Pl", "parkway": "Pkwy",
+	"corporation": "Corp", "incorporated": "Inc", "limited": "Ltd", "company": "Co",
+	"international": "Intl", "technologies": "Tech", "manufacturing": "Mfg", "industries": "Inds",
+	"solutions": "Solns", "systems": "Sys", "partners": "Ptnrs", "holdings": "Hldgs",
+	"pharmaceuticals": "Pharma", "engineering": "Eng", "properties": "Props",
+}
+
+// units are address unit designators
+var units = []string{"Suite %d", "Ste %d", "Unit %d", "Apt %d", "#%d", "Floor %d"}
+
+// unitWords are tokens that start an address unit
+var unitWords = map[string]bool{"suite": true, "ste": true, "unit": true, "apt": true, "floor": true}
+
+// corrupt returns a copy of the seed with randomly applied corruptions. Typos are added
+// until the duplicate differs from its seed.
+func (g *Generator) corrupt(seed Record) Record {
+	record := Record{
+		Fields:   make(map[string]string, len(seed.Fields)),
+		Metadata: map[string]interface{}{"source": "generator"},
+	}
+	for name, value := range seed.Fields {
+		record.Fields[name] = value
+	}
+
+	c := g.opts.Corruption
+	var applied []string
+	apply := func(name string, probability float64, fn func(fields map[string]string) bool) {
+		if g.rng.Float64() < probability && fn(record.Fields) {
+			applied = append(applied, name)
+		}
+	}
+
+	apply(CorruptAbbreviate, c.Abbreviate, g.abbreviate)
+	apply(CorruptLegalSuffix, c.LegalSuffix, g.changeLegalSuffix)
+	apply(CorruptSwapTokens, c.SwapTokens, g.swapTokens)
+	apply(CorruptAddressUnit, c.AddressUnit, g.changeAddressUnit)
+	apply(CorruptPhoneFormat, c.PhoneFormat, g.reformatPhone)
+	apply(CorruptMissing, c.Missing, g.dropField)
+	apply(CorruptTypo, c.Typo, g.typo)
+	for len(applied) == 0 || sameFields(record.Fields, seed.Fields) {
+		if !g.typo(record.Fields) {
+			break
+		}
+		applied = append(applied, CorruptTypo)
+	}
+
+	record.Metadata["corruptions"] = applied
+	return record
+}
+
+// fieldsOfKind returns the names of the schema fields holding values of the given kind
+func (g *Generator) fieldsOfKind(fields map[string]string, kind string) []string {
+	var names []string
+	for _, field := range g.opts.Fields {
+		if fieldKind(field) == kind && fields[field.Name] != "" {
+			names = append(names, field.Name)
+		}
+	}
+	return names
+}
+
+// typo inserts, deletes, substitutes or transposes a character of a random field
+func (g *Generator) typo(fields map[string]string) bool {
+	var candidates []string
+	for _, field := range g.opts.Fields {
+		if len([]rune(fields[field.Name])) >= 4 {
+			candidates = append(candidates, field.Name)
+		}
+	}
+	if len(candidates) == 0 {
+		return false
+	}
+
+	// Prefer the name, which matters most for matching
+	name := candidates[g.rng.Intn(len(candidates))]
+	if names := g.fieldsOfKind(fields, "name"); len(names) > 0 && g.rng.Float64() < 0.5 {
+		name = names[0]
+	}
+
+	runes := []rune(fields[name])
+	i := 1 + g.rng.Intn(len(runes)-2) // Keep the first and last characters
+	letter := rune('a' + g.rng.Intn(26))
+	if unicode.IsUpper(runes[i]) {
+		letter = unicode.ToUpper(letter)
+	}
+
+	switch g.rng.Intn(4) {
+	case 0: // Insert
+		runes = append(runes[:i], append([]rune{letter}, runes[i:]...)...)
+	case 1: // Delete
+		runes = append(runes[:i], runes[i+1:]...)
+	case 2: // Substitute
+		if runes[i] == letter {
+			letter = 'x'
+		}
+		runes[i] = letter
+	default: // Transpose
+		runes[i], runes[i+1] = runes[i+1], runes[i]
+	}
+
+	fields[name] = string(runes)
+	return true
+}
+
+// abbreviate abbreviates the first abbreviable word of the name or address
+func (g *Generator) abbreviate(fields map[string]string) bool {
+	names := append(g.fieldsOfKind(fields, "name"), g.fieldsOfKind(fields, "address")...)
+	g.rng.Shuffle(len(names), func(i, j int) { names[i], names[j] = names[j], names[i] })
+
+	for _, name := range names {
+		tokens := strings.Fields(fields[name])
+		for i, token := range tokens {
+			if abbreviation, ok := abbreviations[strings.ToLower(token)]; ok {
+				if g.rng.Float64() < 0.3 {
+					abbreviation += "."
+				}
+				tokens[i] = abbreviation
+				fields[name] = strings.Join(tokens, " ")
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// changeLegalSuffix removes, replaces or adds the legal suffix of the name
+func (g *Generator) changeLegalSuffix(fields map[string]string) bool {
+	names := g.fieldsOfKind(fields, "name")
+	if len(names) == 0 {
+		return false
+	}
+
+	tokens := strings.Fields(fields[names[0]])
+	last := len(tokens) - 1
+	switch {
+	case !isLegalSuffix(tokens[last]):
+		tokens = append(tokens, pick(g.rng, legalSuffixes))
+	case g.rng.Float64() < 0.5 && last > 0:
+		tokens = tokens[:last]
+	default:
+		suffix := pick(g.rng, legalSuffixes)
+		for strings.EqualFold(suffix, tokens[last]) {
+			suffix = pick(g.rng, legalSuffixes)
+		}
+		tokens[last] = suffix
+	}
+
+	fields[names[0]] = strings.Join(tokens, " ")
+	return true
+}
+
+// swapTokens swaps two adjacent tokens of the name
+func (g *Generator) swapTokens(fields map[string]string) bool {
+	names := g.fieldsOfKind(fields, "name")
+	if len(names) == 0 {
+		return false
+	}
+
+	tokens := strings.Fields(fields[names[0]])
+	if len(tokens) < 2 {
+		return false
+	}
+	i := g.rng.Intn(len(tokens) - 1)
+	tokens[i], tokens[i+1] = tokens[i+1], tokens[i]
+
+	fields[names[0]] = strings.Join(tokens, " ")
+	return true
+}
+
+// changeAddressUnit adds, removes or changes the unit of the address
+func (g *Generator) changeAddressUnit(fields map[string]string) bool {
+	names := g.fieldsOfKind(fields, "address")
+	if len(names) == 0 {
+		return false
+	}
+
+	// Strip an existing unit
+	tokens := strings.Fields(fields[names[0]])
+	hadUnit := false
+	for i, token := range tokens {
+		if unitWords[strings.ToLower(strings.TrimSuffix(token, "."))] || strings.HasPrefix(token, "#") {
+			tokens, hadUnit = tokens[:i], true
+			break
+		}
+	}
+	address := strings.TrimSuffix(strings.Join(tokens, " "), ",")
+
+	if !hadUnit || g.rng.Float64() < 0.5 {
+		unit := fmt.Sprintf(pick(g.rng, units), 1+g.rng.Intn(999))
+		address = fmt.Sprintf("%s, %s", address, unit)
+	}
+
+	fields[names[0]] = address
+	return true
+}
+
+// reformatPhone rewrites the digits of the phone number in a different format
+func (g *Generator) reformatPhone(fields map[string]string) bool {
+	names := g.fieldsOfKind(fields, "phone")
+	if len(names) == 0 {
+		return false
+	}
+
+	var digits []rune
+	for _, r := range fields[names[0]] {
+		if unicode.IsDigit(r) {
+			digits = append(digits, r)
+		}
+	}
+	if len(digits) > 10 {
+		digits = digits[len(digits)-10:]
+	}
+	if len(digits) != 10 {
+		return false
+	}
+	d := string(digits)
+
+	formats := []string{
+		"%s-%s-%s", "%s.%s.%s", "%s %s %s", "%s%s%s", "+1 (%s) %s-%s", "1-%s-%s-%s",
+	}
+	phone := fmt.Sprintf(pick(g.rng, formats), d[:3], d[3:6], d[6:])
+	if phone == fields[names[0]] {
+		phone = fmt.Sprintf("%s-%s-%s", d[:3], d[3:6], d[6:])
+	}
+
+	fields[names[0]] = phone
+	return true
+}
+
+// dropField removes the value of a random field other than the name
+func (g *Generator) dropField(fields map[string]string) bool {
+	var candidates []string
+	for _, field := range g.opts.Fields {
+		if fieldKind(field) != "name" && fields[field.Name] != "" {
+			candidates = append(candidates, field.Name)
+		}
+	}
+	if len(candidates) == 0 {
+		return false
+	}
+
+	delete(fields, candidates[g.rng.Intn(len(candidates))])
+	return true
+}
+
+// sameFields reports whether two records have identical fields
+func sameFields(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, value := range a {
+		if b[name] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// isLegalSuffix reports whether a token is a legal entity suffix
+func isLegalSuffix(token string) bool {
+	for _, suffix := range legalSuffixes {
+		if strings.EqualFold(strings.TrimSuffix(token, "."), strings.TrimSuffix(suffix, ".")) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,290 @@
+package generate
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/TFMV/resolve/internal/config"
+	"github.com/google/uuid"
+)
+
+// Generation defaults
+const (
+	DefaultSeeds         = 1000
+	DefaultDuplicateRate = 0.5
+	DefaultMaxDuplicates = 3
+)
+
+// GeneratedIDMetadataKey holds the readable ID of a generated record, such as gen-001-d1
+const GeneratedIDMetadataKey = "generated_id"
+
+// idNamespace derives record IDs from readable IDs, so the same options generate the same IDs
+var idNamespace = uuid.NewSHA1(uuid.NameSpaceURL, []byte("https://github.com/TFMV/resolve/generate"))
+
+// Record is a generated entity in the ingest format accepted by Resolve
+type Record struct {
+	ID       string                 `json:"id"`
+	Fields   map[string]string      `json:"fields"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// Corruption holds the probability of applying each kind of corruption to a duplicate
+type Corruption struct {
+	Typo        float64 // Insert, delete, substitute or transpose a character
+	Abbreviate  float64 // Abbreviate words such as Street or Corporation
+	LegalSuffix float64 // Add, remove or change the legal suffix of the name
+	PhoneFormat float64 // Reformat the phone number
+	Missing     float64 // Drop a field other than the name
+	SwapTokens  float64 // Swap two adjacent tokens of the name
+	AddressUnit float64 // Add, remove or change the address unit
+}
+
+// DefaultCorruption returns moderate corruption probabilities
+func DefaultCorruption() Corruption {
+	return Corruption{
+		Typo:        0.3,
+		Abbreviate:  0.4,
+		LegalSuffix: 0.3,
+		PhoneFormat: 0.5,
+		Missing:     0.2,
+		SwapTokens:  0.1,
+		AddressUnit: 0.2,
+	}
+}
+
+// Options controls dataset generation
+type Options struct {
+	Seeds         int                  // Number of distinct seed entities
+	DuplicateRate float64              // Fraction of seeds that receive duplicates
+	MaxDuplicates int                  // Maximum duplicates per duplicated seed
+	Corruption    Corruption           // Corruption probabilities for duplicates
+	Fields        []config.FieldConfig // Schema fields to populate
+	Seed          int64                // Random seed
+	IDPrefix      string               // Prefix of the readable IDs of generated records
+}
+
+// Generator produces seed entities and corrupted duplicates with known ground truth
+type Generator struct {
+	opts Options
+	rng  *rand.Rand
+}
+
+// NewGenerator creates a generator, applying defaults to unset options
+func NewGenerator(opts Options) *Generator {
+	if opts.Seeds <= 0 {
+		opts.Seeds = DefaultSeeds
+	}
+	if opts.DuplicateRate < 0 {
+		opts.DuplicateRate = 0
+	}
+	if opts.MaxDuplicates <= 0 {
+		opts.MaxDuplicates = DefaultMaxDuplicates
+	}
+	if len(opts.Fields) == 0 {
+		opts.Fields = config.DefaultSchemaFields()
+	}
+	if opts.IDPrefix == "" {
+		opts.IDPrefix = "gen"
+	}
+
+	return &Generator{
+		opts: opts,
+		rng:  rand.New(rand.NewSource(opts.Seed)),
+	}
+}
+
+// Generate produces every record in order, each seed followed by its duplicates, and
+// calls emit with the record and the ID of its true cluster, the ID of its seed. Record
+// IDs are UUIDs derived from readable IDs such as gen-001 and gen-001-d1, which are kept
+// under GeneratedIDMetadataKey. Records are streamed so large datasets need not fit in
+// memory. Generation stops at the first emit error.
+func (g *Generator) Generate(emit func(record Record, clusterID string) error) error {
+	width := len(fmt.Sprint(g.opts.Seeds))
+	for i := 1; i <= g.opts.Seeds; i++ {
+		seedID := fmt.Sprintf("%s-%0*d", g.opts.IDPrefix, width, i)
+		seed := g.seedRecord(seedID)
+		if err := emit(seed, seed.ID); err != nil {
+			return err
+		}
+
+		if g.rng.Float64() >= g.opts.DuplicateRate {
+			continue
+		}
+
+		duplicates := 1 + g.rng.Intn(g.opts.MaxDuplicates)
+		for d := 1; d <= duplicates; d++ {
+			duplicate := g.corrupt(seed)
+			setID(&duplicate, fmt.Sprintf("%s-d%d", seedID, d))
+			if err := emit(duplicate, seed.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// GenerateAll returns all records and the ground truth mapping record IDs to cluster IDs
+func (g *Generator) GenerateAll() ([]Record, map[string]string) {
+	var records []Record
+	truth := make(map[string]string)
+	g.Generate(func(record Record, clusterID string) error {
+		records = append(records, record)
+		truth[record.ID] = clusterID
+		return nil
+	})
+	return records, truth
+}
+
+// seedRecord generates a clean entity for the schema fields
+func (g *Generator) seedRecord(generatedID string) Record {
+	name := g.companyName()
+	location := locations[g.rng.Intn(len(locations))]
+
+	record := Record{
+		Fields:   make(map[string]string, len(g.opts.Fields)),
+		Metadata: map[string]interface{}{"source": "generator"},
+	}
+	setID(&record, generatedID)
+	for _, field := range g.opts.Fields {
+		record.Fields[field.Name] = g.fieldValue(field, name, location)
+	}
+	return record
+}
+
+// setID gives a record the UUID derived from its readable ID and keeps the readable ID in metadata
+func setID(record *Record, generatedID string) {
+	record.ID = uuid.NewSHA1(idNamespace, []byte(generatedID)).String()
+	record.Metadata[GeneratedIDMetadataKey] = generatedID
+}
+
+// fieldValue generates the value of a field from its name, normalizer or type
+func (g *Generator) fieldValue(field config.FieldConfig, name string, location location) string {
+	switch fieldKind(field) {
+	case "name":
+		return name
+	case "address":
+		return fmt.Sprintf("%d %s %s", 1+g.rng.Intn(9999), pick(g.rng, streetNames), pick(g.rng, streetTypes))
+	case "city":
+		return location.city
+	case "state":
+		return location.state
+	case "country":
+		return "United States"
+	case "zip":
+		return fmt.Sprintf("%s%02d", location.zipPrefix, g.rng.Intn(100))
+	case "phone":
+		return fmt.Sprintf("(%d) %03d-%04d", 200+g.rng.Intn(800), g.rng.Intn(1000), g.rng.Intn(10000))
+	case "email":
+		return fmt.Sprintf("%s@%s.com", pick(g.rng, mailboxes), slug(name))
+	case "url":
+		return fmt.Sprintf("https://www.%s.com", slug(name))
+	case "identifier", "id", "exact":
+		return fmt.Sprintf("%02d-%07d", 10+g.rng.Intn(90), g.rng.Intn(10000000))
+	default:
+		return fmt.Sprintf("%s %s", pick(g.rng, nameWords), pick(g.rng, industries))
+	}
+}
+
+// fieldKind returns the kind of value a field holds, from its name, normalizer or type
+func fieldKind(field config.FieldConfig) string {
+	switch name := strings.ToLower(field.Name); name {
+	case "city", "state", "country":
+		return name
+	}
+
+	kind := field.Normalizer
+	if kind == "" || kind == "none" || kind == "text" {
+		kind = strings.ToLower(field.Type)
+	}
+	return kind
+}
+
+// companyName generates a company name such as "Northwind Harbor Logistics Inc"
+func (g *Generator) companyName() string {
+	words := []string{pick(g.rng, nameWords)}
+	if g.rng.Float64() < 0.6 {
+		words = append(words, pick(g.rng, nameWords))
+	}
+	words = append(words, pick(g.rng, industries))
+	if g.rng.Float64() < 0.8 {
+		words = append(words, pick(g.rng, legalSuffixes))
+	}
+	return strings.Join(words, " ")
+}
+
+// slug lowercases a name and keeps only letters and digits, without the legal suffix
+func slug(name string) string {
+	tokens := strings.Fields(name)
+	if len(tokens) > 1 && isLegalSuffix(tokens[len(tokens)-1]) {
+		tokens = tokens[:len(tokens)-1]
+	}
+
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.Join(tokens, "")) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// pick returns a random element of values
+func pick(rng *rand.Rand, values []string) string {
+	return values[rng.Intn(len(values))]
+}
+
+// location is a consistent city, state and ZIP prefix
+type location struct {
+	city      string
+	state     string
+	zipPrefix string
+}
+
+var locations = []location{
+	{"New York", "NY", "100"}, {"Brooklyn", "NY", "112"}, {"Buffalo", "NY", "142"},
+	{"Boston", "MA", "021"}, {"Cambridge", "MA", "021"}, {"Hartford", "CT", "061"},
+	{"Philadelphia", "PA", "191"}, {"Pittsburgh", "PA", "152"}, {"Newark", "NJ", "071"},
+	{"Baltimore", "MD", "212"}, {"Washington", "DC", "200"}, {"Richmond", "VA", "232"},
+	{"Charlotte", "NC", "282"}, {"Raleigh", "NC", "276"}, {"Atlanta", "GA", "303"},
+	{"Miami", "FL", "331"}, {"Orlando", "FL", "328"}, {"Tampa", "FL", "336"},
+	{"Nashville", "TN", "372"}, {"Chicago", "IL", "606"}, {"Detroit", "MI", "482"},
+	{"Columbus", "OH", "432"}, {"Cleveland", "OH", "441"}, {"Indianapolis", "IN", "462"},
+	{"Milwaukee", "WI", "532"}, {"Minneapolis", "MN", "554"}, {"St. Louis", "MO", "631"},
+	{"Kansas City", "MO", "641"}, {"Dallas", "TX", "752"}, {"Houston", "TX", "770"},
+	{"Austin", "TX", "787"}, {"San Antonio", "TX", "782"}, {"Denver", "CO", "802"},
+	{"Phoenix", "AZ", "850"}, {"Salt Lake City", "UT", "841"}, {"Las Vegas", "NV", "891"},
+	{"Los Angeles", "CA", "900"}, {"San Diego", "CA", "921"}, {"San Francisco", "CA", "941"},
+	{"San Jose", "CA", "951"}, {"Portland", "OR", "972"}, {"Seattle", "WA", "981"},
+}
+
+var nameWords = []string{
+	"Acme", "Apex", "Atlas", "Aurora", "Beacon", "Blue", "Bright", "Cardinal", "Cascade", "Cedar",
+	"Central", "Crescent", "Crown", "Delta", "Eagle", "Echo", "Emerald", "Evergreen", "Falcon", "First",
+	"Frontier", "Galaxy", "Global", "Golden", "Granite", "Harbor", "Heritage", "Horizon", "Iron", "Keystone",
+	"Liberty", "Lighthouse", "Maple", "Meridian", "Metro", "Monarch", "Northern", "Northwind", "Oak", "Omega",
+	"Orion", "Pacific", "Peak", "Pinnacle", "Pioneer", "Prairie", "Premier", "Quantum", "Redwood", "River",
+	"Sequoia", "Silver", "Sterling", "Summit", "Sun", "Titan", "Trinity", "United", "Valley", "Vertex",
+	"Vista", "Western", "Willow", "Zenith",
+}
+
+var industries = []string{
+	"Analytics", "Bakery", "Builders", "Capital", "Chemicals", "Consulting", "Dental", "Design", "Electric",
+	"Energy", "Engineering", "Foods", "Freight", "Health", "Holdings", "Hospitality", "Industries",
+	"Insurance", "International", "Labs", "Logistics", "Manufacturing", "Media", "Medical", "Motors",
+	"Networks", "Partners", "Pharmaceuticals", "Plumbing", "Properties", "Realty", "Robotics", "Solutions",
+	"Software", "Supply", "Systems", "Technologies", "Textiles", "Trading", "Ventures",
+}
+
+var legalSuffixes = []string{"Inc", "Inc.", "Incorporated", "Corp", "Corporation", "LLC", "Ltd", "Limited", "LLP", "Co"}
+
+var streetNames = []string{
+	"Main", "Oak", "Pine", "Maple", "Cedar", "Elm", "Washington", "Lake", "Hill", "Park",
+	"Church", "Market", "Spring", "Broad", "Center", "Highland", "Mill", "River", "Sunset", "Union",
+	"Water", "Franklin", "Jefferson", "Lincoln", "Madison", "Walnut", "Chestnut", "Adams", "Forest", "Jackson",
+}
+
+var streetTypes = []string{"Street", "Avenue", "Road", "Boulevard", "Drive", "Lane", "Court", "Place", "Parkway", "Way"}
+
+var mailboxes = []string{"info", "contact", "sales", "hello", "office", "support"}
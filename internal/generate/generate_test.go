@@ -0,0 +1,89 @@
+package generate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/TFMV/resolve/internal/config"
+	"github.com/google/uuid"
+)
+
+func TestGenerateGroundTruth(t *testing.T) {
+	records, truth := NewGenerator(Options{Seeds: 200, DuplicateRate: 0.5, MaxDuplicates: 3, Corruption: DefaultCorruption(), Seed: 1}).GenerateAll()
+
+	if len(records) <= 200 || len(truth) != len(records) {
+		t.Fatalf("expected more than 200 records with truth, got %d records and %d truth rows", len(records), len(truth))
+	}
+
+	seeds := make(map[string]Record)
+	for _, record := range records {
+		clusterID := truth[record.ID]
+		if clusterID == record.ID {
+			seeds[record.ID] = record
+			continue
+		}
+
+		// Duplicates follow their seed and differ from it
+		seed, ok := seeds[clusterID]
+		if !ok {
+			t.Fatalf("duplicate %s precedes its seed %s", record.ID, clusterID)
+		}
+		if reflect.DeepEqual(seed.Fields, record.Fields) {
+			t.Errorf("duplicate %s is identical to its seed", record.ID)
+		}
+		if corruptions, _ := record.Metadata["corruptions"].([]string); len(corruptions) == 0 {
+			t.Errorf("duplicate %s records no corruptions", record.ID)
+		}
+	}
+	if len(seeds) != 200 {
+		t.Errorf("expected 200 seeds, got %d", len(seeds))
+	}
+
+	// IDs are UUIDs derived from the readable IDs kept in metadata
+	first := records[0]
+	if _, err := uuid.Parse(first.ID); err != nil || first.Metadata[GeneratedIDMetadataKey] != "gen-001" {
+		t.Errorf("expected a UUID for gen-001, got %s (%v)", first.ID, first.Metadata)
+	}
+	if first.ID != uuid.NewSHA1(idNamespace, []byte("gen-001")).String() {
+		t.Errorf("expected a stable ID for gen-001, got %s", first.ID)
+	}
+
+	// Every default schema field is populated on seeds
+	for _, field := range config.DefaultSchemaFields() {
+		if first.Fields[field.Name] == "" {
+			t.Errorf("seed has no %s", field.Name)
+		}
+	}
+}
+
+func TestGenerateDeterministic(t *testing.T) {
+	opts := Options{Seeds: 50, DuplicateRate: 0.5, Corruption: DefaultCorruption(), Seed: 42}
+	a, _ := NewGenerator(opts).GenerateAll()
+	b, _ := NewGenerator(opts).GenerateAll()
+	if !reflect.DeepEqual(a, b) {
+		t.Error("expected identical output for the same seed")
+	}
+}
+
+func TestCorruptions(t *testing.T) {
+	g := NewGenerator(Options{Seed: 3})
+
+	fields := map[string]string{"name": "Summit Logistics Corporation", "address": "12 Main Street", "phone": "(555) 123-4567"}
+	if !g.abbreviate(fields) || !strings.Contains(fields["name"]+fields["address"], "Corp") && !strings.Contains(fields["address"], "St") {
+		t.Errorf("expected an abbreviation, got %v", fields)
+	}
+
+	if !g.reformatPhone(fields) || fields["phone"] == "(555) 123-4567" || !strings.Contains(strings.NewReplacer("-", "", ".", "", " ", "", "(", "", ")", "").Replace(fields["phone"]), "5551234567") {
+		t.Errorf("expected a reformatted phone with the same digits, got %q", fields["phone"])
+	}
+
+	if !g.changeAddressUnit(fields) || !strings.Contains(fields["address"], ",") {
+		t.Errorf("expected an address unit, got %q", fields["address"])
+	}
+
+	before := fields["name"]
+	if !g.changeLegalSuffix(fields) || fields["name"] == before {
+		t.Errorf("expected a legal suffix change, got %q", fields["name"])
+	}
+}
@@ -0,0 +1,200 @@
+package match
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/TFMV/resolve/internal/correlation"
+	"github.com/TFMV/resolve/internal/store"
+)
+
+// getCorrelationMatchGroup gathers the transitive matches of the entity as candidates,
+// scores every pair of them and keeps the correlation cluster holding the entity. Pairs
+// above the threshold pull records together and pairs below push them apart, so a chain
+// of matches A~B~C~D does not merge A with a clearly different D. The pair scores are
+// returned so weak group flags can reuse them.
+func (s *Service) getCorrelationMatchGroup(ctx context.Context, group *MatchGroup, entity *store.EntityRecord, opts MatchGroupOptions) (map[[2]string]float32, error) {
+	// Gather the candidates
+	candidates := &MatchGroup{ID: entity.ID, PrimaryID: entity.ID}
+	if err := s.getTransitiveMatchGroup(ctx, candidates, entity, opts); err != nil {
+		return nil, err
+	}
+	records := []*store.EntityRecord{entity}
+	results := make(map[string]MatchResult, len(candidates.Entities))
+	for _, candidate := range candidates.Entities {
+		if candidate.ID == entity.ID {
+			continue
+		}
+		record, err := s.store.GetEntity(ctx, candidate.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve entity %s: %w", candidate.ID, err)
+		}
+		records = append(records, record)
+		results[candidate.ID] = candidate
+	}
+
+	// Score every pair, weighting it by its distance from the threshold
+	scores, err := s.scoreGroupPairs(records, opts, nil)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(records))
+	for i, record := range records {
+		ids[i] = record.ID
+	}
+	edges := make([]correlation.Edge, 0, len(scores))
+	for pair, score := range scores {
+		edges = append(edges, correlation.Edge{A: pair[0], B: pair[1], Weight: float64(score - opts.ThresholdOverride)})
+	}
+
+	// Keep the cluster holding the entity, scoring members against it
+	for _, cluster := range correlation.Cluster(ids, edges, correlation.Options{}) {
+		if !contains(cluster, entity.ID) {
+			continue
+		}
+		for _, id := range cluster {
+			if id == entity.ID {
+				continue
+			}
+			result := results[id]
+			result.Score = scores[pairKey(entity.ID, id)]
+			result.Explanation = generateExplanation(result.Score, result.MatchedOn)
+			group.Entities = append(group.Entities, result)
+		}
+		break
+	}
+
+	return scores, nil
+}
+
+// flagWeakGroup records the lowest score between any two entities of a match group and
+// flags the group when it falls below the minimum similarity. Must-linked pairs count as
+// perfect matches. Pairs in known keep their score; only the others are scored.
+func (s *Service) flagWeakGroup(ctx context.Context, group *MatchGroup, opts MatchGroupOptions, known map[[2]string]float32) error {
+	group.MinScore, group.WeakestPair, group.Flagged = 1, nil, false
+	if len(group.Entities) < 2 {
+		return nil
+	}
+
+	// Retrieve the entities of the pairs that are not scored yet
+	need := make(map[string]bool)
+	for i, a := range group.Entities {
+		for _, b := range group.Entities[i+1:] {
+			if _, ok := known[pairKey(a.ID, b.ID)]; !ok && a.ID != b.ID {
+				need[a.ID], need[b.ID] = true, true
+			}
+		}
+	}
+	records := make([]*store.EntityRecord, 0, len(need))
+	for _, entity := range group.Entities {
+		if !need[entity.ID] {
+			continue
+		}
+		record, err := s.store.GetEntity(ctx, entity.ID)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve entity %s: %w", entity.ID, err)
+		}
+		records = append(records, record)
+	}
+	scores, err := s.scoreGroupPairs(records, opts, known)
+	if err != nil {
+		return err
+	}
+	for i, a := range group.Entities {
+		for _, b := range group.Entities[i+1:] {
+			if score, ok := known[pairKey(a.ID, b.ID)]; ok {
+				scores[pairKey(a.ID, b.ID)] = score
+			}
+		}
+	}
+	constraints, err := s.constraints()
+	if err != nil {
+		return err
+	}
+
+	pairs := make([][2]string, 0, len(scores))
+	for pair := range scores {
+		pairs = append(pairs, pair)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+	linked := make(map[string][]string)
+	for _, pair := range pairs {
+		if _, ok := linked[pair[0]]; !ok {
+			linked[pair[0]] = constraints.Linked(pair[0])
+		}
+		score := scores[pair]
+		if contains(linked[pair[0]], pair[1]) {
+			score = 1
+		}
+		if score < group.MinScore {
+			group.MinScore, group.WeakestPair = score, []string{pair[0], pair[1]}
+		}
+	}
+	group.Flagged = group.MinScore < opts.MinSimilarity
+
+	return nil
+}
+
+// scoreGroupPairs scores every pair of records with the scoring model, keyed by ordered ID
+// pair. Pairs in known are skipped.
+func (s *Service) scoreGroupPairs(records []*store.EntityRecord, opts MatchGroupOptions, known map[[2]string]float32) (map[[2]string]float32, error) {
+	matchOpts := Options{FieldWeights: opts.FieldWeights, Scorer: s.cfg.Matching.Scorer}
+	if matchOpts.Scorer == ScorerLogistic && s.logisticModel == nil {
+		return nil, fmt.Errorf("the logistic scorer requires a trained model; set matching.model_path")
+	}
+
+	scores := make(map[[2]string]float32, len(records)*(len(records)-1)/2)
+	for i, a := range records {
+		for _, b := range records[i+1:] {
+			if _, ok := known[pairKey(a.ID, b.ID)]; ok || a.ID == b.ID {
+				continue
+			}
+			// Score from the lower ID so the result does not depend on record order
+			first, second := a, b
+			if second.ID < first.ID {
+				first, second = second, first
+			}
+			scores[pairKey(a.ID, b.ID)] = s.scorePair(first, second, matchOpts)
+		}
+	}
+	return scores, nil
+}
+
+// scorePair scores two stored records directly, the way findMatches scores a candidate
+// against a query: vector similarity refined by field weights, the Fellegi-Sunter
+// posterior or the supervised model
+func (s *Service) scorePair(a, b *store.EntityRecord, opts Options) float32 {
+	queryFields := s.normalizer.NormalizeEntity(s.entityDataFromRecord(a).Fields)
+	result := s.convertToMatchResult(b, float32(store.CosineSimilarity(a.Vector, b.Vector)))
+
+	switch opts.Scorer {
+	case ScorerFellegiSunter:
+		s.applyFellegiSunter(&result, queryFields, opts)
+	case ScorerLogistic:
+		features := s.pairFeatures(s.logisticModel.Fields, queryFields, b.Fields, a.Vector, b.Vector)
+		result.Score = float32(s.logisticModel.Predict(features))
+	default:
+		s.computeFieldScores(&result, queryFields, opts)
+	}
+	return result.Score
+}
+
+// pairKey orders a pair of IDs
+func pairKey(a, b string) [2]string {
+	if b < a {
+		return [2]string{b, a}
+	}
+	return [2]string{a, b}
+}
+
+// contains reports whether a sorted list of IDs holds id
+func contains(sorted []string, id string) bool {
+	i := sort.SearchStrings(sorted, id)
+	return i < len(sorted) && sorted[i] == id
+}
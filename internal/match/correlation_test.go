@@ -0,0 +1,73 @@
+package match
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCorrelationMatchGroupStopsChaining(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestService(t, false)
+
+	// Acme Holdings links the Acme records to the Apex records, which match neither Acme name
+	names := map[string]string{
+		"acme-1":  "Acme Corporation",
+		"acme-2":  "Acme Corp",
+		"acme-3":  "Acme Holdings",
+		"apex-1":  "Apex Holdings",
+		"apex-2":  "Apex Holding Group",
+		"initech": "Initech",
+	}
+	var entities []EntityData
+	for id, name := range names {
+		entities = append(entities, EntityData{ID: id, Fields: map[string]string{"name": name, "address": "123 Main Street", "zip": "10001"}})
+	}
+	if _, err := svc.AddEntities(ctx, entities); err != nil {
+		t.Fatalf("AddEntities: %v", err)
+	}
+
+	opts := MatchGroupOptions{Strategy: "transitive", ThresholdOverride: 0.93, MinSimilarity: 0.9, FieldWeights: map[string]float32{"name": 1}}
+	transitive, err := svc.GetMatchGroup(ctx, "acme-1", opts)
+	if err != nil {
+		t.Fatalf("GetMatchGroup: %v", err)
+	}
+	if !groupHas(transitive, "apex-2") || !transitive.Flagged || transitive.MinScore >= 0.9 {
+		t.Fatalf("expected the transitive group to chain into apex-2 and be flagged, got %+v", transitive)
+	}
+
+	opts.Strategy = "correlation"
+	group, err := svc.GetMatchGroup(ctx, "acme-1", opts)
+	if err != nil {
+		t.Fatalf("GetMatchGroup: %v", err)
+	}
+	if group.Size != 3 || !groupHas(group, "acme-2") || !groupHas(group, "acme-3") {
+		t.Errorf("expected the three Acme records, got %+v", group.Entities)
+	}
+	if group.Flagged || group.MinScore < 0.93 {
+		t.Errorf("expected an unflagged group, got min score %.3f on %v", group.MinScore, group.WeakestPair)
+	}
+
+	// Without a minimum similarity only the correlation strategy reports the weakest pair
+	opts.MinSimilarity = 0
+	if group, err := svc.GetMatchGroup(ctx, "acme-1", opts); err != nil || group.MinScore < 0.93 {
+		t.Errorf("expected the correlation scores to be reused, got %+v (%v)", group, err)
+	}
+	opts.Strategy = "transitive"
+	unscored, err := svc.GetMatchGroup(ctx, "acme-1", opts)
+	if err != nil {
+		t.Fatalf("GetMatchGroup: %v", err)
+	}
+	if unscored.Flagged || unscored.MinScore != 0 || unscored.WeakestPair != nil {
+		t.Errorf("expected no weak group scoring without a minimum similarity, got %+v", unscored)
+	}
+}
+
+// groupHas reports whether a match group holds an entity
+func groupHas(group *MatchGroup, id string) bool {
+	for _, entity := range group.Entities {
+		if entity.ID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,212 @@
+package match
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/TFMV/resolve/internal/config"
+	"github.com/TFMV/resolve/internal/similarity"
+)
+
+// Scorer names accepted in Options.Scorer
+const (
+	ScorerVector        = "vector"         // Vector similarity, optionally blended with field weights
+	ScorerFellegiSunter = "fellegi_sunter" // Probabilistic Fellegi-Sunter match weights
+	ScorerLogistic      = "logistic"       // Supervised logistic regression model
+)
+
+// ComparisonLevel is the degree of agreement between two field values
+type ComparisonLevel string
+
+// Comparison levels used by the Fellegi-Sunter model
+const (
+	LevelExact     ComparisonLevel = "exact"
+	LevelFuzzyHigh ComparisonLevel = "fuzzy_high"
+	LevelFuzzyLow  ComparisonLevel = "fuzzy_low"
+	LevelDisagree  ComparisonLevel = "disagree"
+	LevelMissing   ComparisonLevel = "missing"
+)
+
+// ComparisonLevels lists the levels that carry m/u probabilities, from strongest to weakest agreement.
+// Missing values are neutral and contribute no weight.
+var ComparisonLevels = []ComparisonLevel{LevelExact, LevelFuzzyHigh, LevelFuzzyLow, LevelDisagree}
+
+// Default similarity cut-offs between comparison levels
+const (
+	DefaultFuzzyHighThreshold = 0.9
+	DefaultFuzzyLowThreshold  = 0.7
+)
+
+// probabilityFloor keeps log weights finite when a probability is zero
+const probabilityFloor = 1e-6
+
+// FieldProbabilities holds the m and u probabilities of each comparison level for a field.
+// M is P(level | match), U is P(level | non-match).
+type FieldProbabilities struct {
+	M map[ComparisonLevel]float64 `json:"m"`
+	U map[ComparisonLevel]float64 `json:"u"`
+}
+
+// FellegiSunterModel holds the parameters of a Fellegi-Sunter record linkage model
+type FellegiSunterModel struct {
+	Prior              float64                       `json:"prior"`                // Prior probability that a candidate pair is a match
+	FuzzyHighThreshold float64                       `json:"fuzzy_high_threshold"` // Minimum similarity for the fuzzy-high level
+	FuzzyLowThreshold  float64                       `json:"fuzzy_low_threshold"`  // Minimum similarity for the fuzzy-low level
+	Fields             map[string]FieldProbabilities `json:"fields"`
+}
+
+// FellegiSunterResult is the outcome of scoring a record pair
+type FellegiSunterResult struct {
+	Weight      float64                        // Total log2 likelihood ratio
+	Probability float64                        // Posterior match probability
+	Levels      map[string]ComparisonLevel     // Comparison level per field
+	Weights     map[string]float64             // Log2 likelihood ratio per field
+	Similarity  map[string]float64             // Raw similarity per field
+	Functions   map[string]similarity.Function // Similarity function used per compared field
+}
+
+// DefaultFellegiSunterModel returns a model with prior m/u probabilities for the schema fields.
+// Agreement on high-cardinality fields such as phone, email and identifiers is rare among
+// non-matches, so it carries more weight than agreement on fields like state or city.
+func DefaultFellegiSunterModel(fields []config.FieldConfig) *FellegiSunterModel {
+	model := &FellegiSunterModel{
+		Prior:              0.01,
+		FuzzyHighThreshold: DefaultFuzzyHighThreshold,
+		FuzzyLowThreshold:  DefaultFuzzyLowThreshold,
+		Fields:             make(map[string]FieldProbabilities, len(fields)),
+	}
+
+	for _, field := range fields {
+		// Probability that an exact agreement happens by chance between non-matches
+		uExact := 0.01
+		switch strings.ToLower(field.Type) {
+		case "phone", "email", "identifier", "id", "exact":
+			uExact = 0.001
+		case "zip":
+			uExact = 0.02
+		}
+		if field.Normalizer == "state" || field.Name == "state" || field.Name == "city" {
+			uExact = 0.1
+		}
+
+		model.Fields[field.Name] = FieldProbabilities{
+			M: map[ComparisonLevel]float64{
+				LevelExact:     0.85,
+				LevelFuzzyHigh: 0.08,
+				LevelFuzzyLow:  0.04,
+				LevelDisagree:  0.03,
+			},
+			U: map[ComparisonLevel]float64{
+				LevelExact:     uExact,
+				LevelFuzzyHigh: 0.02,
+				LevelFuzzyLow:  0.08,
+				LevelDisagree:  0.9 - uExact,
+			},
+		}
+	}
+
+	return model
+}
+
+// Validate checks that the model parameters are usable
+func (m *FellegiSunterModel) Validate() error {
+	if m.Prior <= 0 || m.Prior >= 1 {
+		return fmt.Errorf("prior must be between 0 and 1, got %f", m.Prior)
+	}
+	if m.FuzzyLowThreshold > m.FuzzyHighThreshold {
+		return fmt.Errorf("fuzzy-low threshold %f exceeds fuzzy-high threshold %f", m.FuzzyLowThreshold, m.FuzzyHighThreshold)
+	}
+	if len(m.Fields) == 0 {
+		return fmt.Errorf("model has no fields")
+	}
+	for name, probs := range m.Fields {
+		for _, level := range ComparisonLevels {
+			if probs.M[level] < 0 || probs.M[level] > 1 || probs.U[level] < 0 || probs.U[level] > 1 {
+				return fmt.Errorf("field %s has an invalid probability for level %s", name, level)
+			}
+		}
+	}
+	return nil
+}
+
+// Level maps a similarity score to a comparison level
+func (m *FellegiSunterModel) Level(score float64) ComparisonLevel {
+	switch {
+	case score >= 1.0:
+		return LevelExact
+	case score >= m.FuzzyHighThreshold:
+		return LevelFuzzyHigh
+	case score >= m.FuzzyLowThreshold:
+		return LevelFuzzyLow
+	default:
+		return LevelDisagree
+	}
+}
+
+// LevelWeight returns the log2 likelihood ratio log2(m/u) of a field comparison level
+func (m *FellegiSunterModel) LevelWeight(field string, level ComparisonLevel) float64 {
+	probs, ok := m.Fields[field]
+	if !ok || level == LevelMissing {
+		return 0
+	}
+
+	mProb := math.Max(probs.M[level], probabilityFloor)
+	uProb := math.Max(probs.U[level], probabilityFloor)
+	return math.Log2(mProb / uProb)
+}
+
+// Posterior converts a total match weight into a posterior match probability
+func (m *FellegiSunterModel) Posterior(weight float64) float64 {
+	priorOdds := m.Prior / (1 - m.Prior)
+	posteriorOdds := priorOdds * math.Exp2(weight)
+	if math.IsInf(posteriorOdds, 1) {
+		return 1
+	}
+	return posteriorOdds / (1 + posteriorOdds)
+}
+
+// Compare scores a record pair. Each model field is compared with the similarity
+// function returned by fnForField, preferring normalized values when both records have them.
+func (m *FellegiSunterModel) Compare(a, b map[string]string, fnForField func(field string) similarity.Function) FellegiSunterResult {
+	result := FellegiSunterResult{
+		Levels:     make(map[string]ComparisonLevel, len(m.Fields)),
+		Weights:    make(map[string]float64, len(m.Fields)),
+		Similarity: make(map[string]float64, len(m.Fields)),
+		Functions:  make(map[string]similarity.Function, len(m.Fields)),
+	}
+
+	for field := range m.Fields {
+		valueA, valueB := comparableValues(a, b, field)
+
+		level := LevelMissing
+		if valueA != "" && valueB != "" {
+			fn := fnForField(field)
+			score := fn.Compare(valueA, valueB)
+			if valueA == valueB {
+				score = 1.0
+			}
+			level = m.Level(score)
+			result.Similarity[field] = score
+			result.Functions[field] = fn
+		}
+
+		weight := m.LevelWeight(field, level)
+		result.Levels[field] = level
+		result.Weights[field] = weight
+		result.Weight += weight
+	}
+
+	result.Probability = m.Posterior(result.Weight)
+	return result
+}
+
+// comparableValues returns the values of a field in both records, using the
+// normalized forms when both are available
+func comparableValues(a, b map[string]string, field string) (string, string) {
+	normalizedA, normalizedB := a[field+"_normalized"], b[field+"_normalized"]
+	if normalizedA != "" && normalizedB != "" {
+		return normalizedA, normalizedB
+	}
+	return a[field], b[field]
+}
@@ -0,0 +1,65 @@
+package match
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/TFMV/resolve/internal/config"
+)
+
+func TestFellegiSunterModelWeights(t *testing.T) {
+	model := DefaultFellegiSunterModel(config.DefaultSchemaFields())
+	if err := model.Validate(); err != nil {
+		t.Fatalf("default model invalid: %v", err)
+	}
+
+	// Agreeing on a phone number is stronger evidence than agreeing on a state
+	if model.LevelWeight("phone", LevelExact) <= model.LevelWeight("state", LevelExact) {
+		t.Errorf("expected phone agreement to outweigh state agreement")
+	}
+	if model.LevelWeight("name", LevelDisagree) >= 0 {
+		t.Errorf("expected disagreement to carry a negative weight")
+	}
+	if model.LevelWeight("name", LevelMissing) != 0 {
+		t.Errorf("expected missing values to be neutral")
+	}
+
+	// With no evidence the posterior equals the prior
+	if p := model.Posterior(0); math.Abs(p-model.Prior) > 1e-9 {
+		t.Errorf("expected posterior %f, got %f", model.Prior, p)
+	}
+}
+
+func TestServiceFellegiSunterScorer(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestService(t, false)
+
+	if _, err := svc.AddEntities(ctx, testEntities); err != nil {
+		t.Fatalf("AddEntities: %v", err)
+	}
+
+	query := EntityData{Fields: map[string]string{"name": "Acme Corp.", "address": "123 Main St", "zip": "10001"}}
+	matches, err := svc.FindMatchesForEntity(ctx, query, Options{Scorer: ScorerFellegiSunter, Threshold: 0.5})
+	if err != nil {
+		t.Fatalf("FindMatchesForEntity: %v", err)
+	}
+
+	ids := make(map[string]bool)
+	for _, m := range matches {
+		ids[m.ID] = true
+		if m.MatchWeight <= 0 {
+			t.Errorf("expected positive match weight for %s, got %f", m.ID, m.MatchWeight)
+		}
+		if m.FieldScores["zip"].Level != string(LevelExact) {
+			t.Errorf("expected exact zip agreement for %s, got %q", m.ID, m.FieldScores["zip"].Level)
+		}
+	}
+	if !ids["acme-1"] || !ids["acme-2"] || ids["globex"] {
+		t.Errorf("unexpected Fellegi-Sunter matches: %v", ids)
+	}
+
+	if _, err := svc.FindMatchesForEntity(ctx, query, Options{Scorer: "bogus"}); err == nil {
+		t.Errorf("expected error for unknown scorer")
+	}
+}
@@ -0,0 +1,54 @@
+package match
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/TFMV/resolve/internal/survivorship"
+)
+
+// goldenRecord builds the golden record of a group with the configured survivorship rules
+func (s *Service) goldenRecord(records []survivorship.Record) (*survivorship.GoldenRecord, error) {
+	return s.survivorship.Build(records, s.schemaFieldNames())
+}
+
+// survivorshipRecord converts a group member into a survivorship record. The update time
+// is read from the "updated_at" metadata, falling back to the stored update time, and the
+// source from the configured source metadata key.
+func (s *Service) survivorshipRecord(id string, fields map[string]string, metadata map[string]interface{}, updatedAt int64) survivorship.Record {
+	record := survivorship.Record{ID: id, Fields: fields, UpdatedAt: updatedAt}
+	if timestamp := metadataTimestamp(metadata["updated_at"]); timestamp > 0 {
+		record.UpdatedAt = timestamp
+	}
+	if source, ok := metadata[s.cfg.Survivorship.SourceField]; ok && source != nil {
+		record.Source = fmt.Sprint(source)
+	}
+	return record
+}
+
+// schemaFieldNames returns the names of the schema fields
+func (s *Service) schemaFieldNames() []string {
+	names := make([]string, len(s.schemaFields))
+	for i, field := range s.schemaFields {
+		names[i] = field.Name
+	}
+	return names
+}
+
+// metadataTimestamp converts a Unix timestamp stored in metadata, which is a float64 after a
+// JSON round trip and an integer when set in process, returning 0 for other values
+func metadataTimestamp(value interface{}) int64 {
+	switch v := value.(type) {
+	case int64:
+		return v
+	case int:
+		return int64(v)
+	case float64:
+		return int64(v)
+	case json.Number:
+		timestamp, _ := v.Int64()
+		return timestamp
+	default:
+		return 0
+	}
+}
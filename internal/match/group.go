@@ -5,16 +5,21 @@ import (
 	"fmt"
 	"sort"
 
-	"github.com/TFMV/resolve/internal/weaviate"
+	"github.com/TFMV/resolve/internal/store"
+	"github.com/TFMV/resolve/internal/survivorship"
 )
 
 // MatchGroup represents a group of matching entities
 type MatchGroup struct {
-	ID           string        `json:"id"`         // Group identifier (derived from primary entity ID)
-	Entities     []MatchResult `json:"entities"`   // All entities in the group
-	Score        float32       `json:"score"`      // Average match score within the group
-	Size         int           `json:"size"`       // Number of entities in the group
-	PrimaryID    string        `json:"primary_id"` // ID of the primary/canonical entity
+	ID           string                     `json:"id"`                      // Group identifier (derived from primary entity ID)
+	Entities     []MatchResult              `json:"entities"`                // All entities in the group
+	Score        float32                    `json:"score"`                   // Average match score within the group
+	Size         int                        `json:"size"`                    // Number of entities in the group
+	PrimaryID    string                     `json:"primary_id"`              // ID of the primary/canonical entity
+	Golden       *survivorship.GoldenRecord `json:"golden_record,omitempty"` // Surviving value of every field with its provenance
+	MinScore     float32                    `json:"min_score,omitempty"`     // Lowest score between any two entities in the group, if scored
+	WeakestPair  []string                   `json:"weakest_pair,omitempty"`  // IDs of the two entities scoring MinScore
+	Flagged      bool                       `json:"flagged"`                 // Whether MinScore falls below the minimum similarity
 	SampleFields map[string]struct {
 		Value      string  `json:"value"`      // Sample value for the field
 		Agreement  float32 `json:"agreement"`  // Percentage of agreement (how many entities have this value)
@@ -27,9 +32,10 @@ type MatchGroupOptions struct {
 	ThresholdOverride float32            // Optional threshold override for group membership
 	MaxGroupSize      int                // Maximum group size to return (0 for unlimited)
 	IncludeScores     bool               // Whether to include detailed scores
-	Strategy          string             // Strategy for group retrieval: "transitive", "direct", "hybrid"
-	HopsLimit         int                // Maximum number of transitive hops (for transitive strategy)
+	Strategy          string             // Strategy for group retrieval: "transitive", "direct", "hybrid", "correlation"
+	HopsLimit         int                // Maximum number of transitive hops (for transitive and correlation strategies)
 	FieldWeights      map[string]float32 // Field weights for scoring
+	MinSimilarity     float32            // Groups scoring below this between any two entities are flagged (defaults to matching.min_group_similarity)
 }
 
 // GetMatchGroup retrieves all entities that match/belong to the same group as the specified entity
@@ -47,9 +53,12 @@ func (s *Service) GetMatchGroup(ctx context.Context, entityID string, opts Match
 	if opts.HopsLimit <= 0 {
 		opts.HopsLimit = 3 // Default to 3 hops
 	}
+	if opts.MinSimilarity <= 0 {
+		opts.MinSimilarity = s.cfg.Matching.MinGroupSimilarity
+	}
 
 	// Get the entity to match against
-	entity, err := s.weaviateClient.GetEntity(ctx, entityID)
+	entity, err := s.store.GetEntity(ctx, entityID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve entity %s: %w", entityID, err)
 	}
@@ -68,10 +77,11 @@ func (s *Service) GetMatchGroup(ctx context.Context, entityID string, opts Match
 	}
 
 	// Add the primary entity to the group
-	primaryResult := convertToMatchResult(entity, 1.0) // Primary entity has perfect score
+	primaryResult := s.convertToMatchResult(entity, 1.0) // Primary entity has perfect score
 	group.Entities = append(group.Entities, primaryResult)
 
 	// Calculate match group based on the strategy
+	var pairScores map[[2]string]float32
 	switch opts.Strategy {
 	case "direct":
 		err = s.getDirectMatchGroup(ctx, group, entity, opts)
@@ -79,6 +89,8 @@ func (s *Service) GetMatchGroup(ctx context.Context, entityID string, opts Match
 		err = s.getTransitiveMatchGroup(ctx, group, entity, opts)
 	case "hybrid":
 		err = s.getHybridMatchGroup(ctx, group, entity, opts)
+	case "correlation":
+		pairScores, err = s.getCorrelationMatchGroup(ctx, group, entity, opts)
 	default:
 		return nil, fmt.Errorf("unknown match group strategy: %s", opts.Strategy)
 	}
@@ -87,14 +99,29 @@ func (s *Service) GetMatchGroup(ctx context.Context, entityID string, opts Match
 		return nil, fmt.Errorf("failed to retrieve match group: %w", err)
 	}
 
-	// Calculate aggregate statistics
-	s.calculateGroupStatistics(group)
+	// Apply steward overrides, which win over any score
+	if err := s.applyOverrides(ctx, group, entityID); err != nil {
+		return nil, fmt.Errorf("failed to apply overrides: %w", err)
+	}
+
+	// Flag groups whose weakest internal pair scores too low when a minimum similarity is
+	// set, or when the correlation strategy has already scored the pairs
+	if opts.MinSimilarity > 0 || pairScores != nil {
+		if err := s.flagWeakGroup(ctx, group, opts, pairScores); err != nil {
+			return nil, fmt.Errorf("failed to score group pairs: %w", err)
+		}
+	}
+
+	// Calculate aggregate statistics and the golden record
+	if err := s.calculateGroupStatistics(group); err != nil {
+		return nil, fmt.Errorf("failed to build golden record: %w", err)
+	}
 
 	return group, nil
 }
 
 // getDirectMatchGroup finds entities that directly match the primary entity
-func (s *Service) getDirectMatchGroup(ctx context.Context, group *MatchGroup, entity *weaviate.EntityRecord, opts MatchGroupOptions) error {
+func (s *Service) getDirectMatchGroup(ctx context.Context, group *MatchGroup, entity *store.EntityRecord, opts MatchGroupOptions) error {
 	// Find direct matches for the entity
 	matchOpts := Options{
 		Limit:              opts.MaxGroupSize,
@@ -106,42 +133,7 @@ func (s *Service) getDirectMatchGroup(ctx context.Context, group *MatchGroup, en
 	}
 
 	// Create EntityData from the EntityRecord for FindMatchesForEntity
-	entityData := EntityData{
-		ID: entity.ID,
-		Fields: map[string]string{
-			"name":    entity.Name,
-			"address": entity.Address,
-			"city":    entity.City,
-			"state":   entity.State,
-			"zip":     entity.Zip,
-			"phone":   entity.Phone,
-			"email":   entity.Email,
-		},
-		Metadata: entity.Metadata,
-	}
-
-	// Add normalized fields if available
-	if entity.NameNormalized != "" {
-		entityData.Fields["name_normalized"] = entity.NameNormalized
-	}
-	if entity.AddressNormalized != "" {
-		entityData.Fields["address_normalized"] = entity.AddressNormalized
-	}
-	if entity.CityNormalized != "" {
-		entityData.Fields["city_normalized"] = entity.CityNormalized
-	}
-	if entity.StateNormalized != "" {
-		entityData.Fields["state_normalized"] = entity.StateNormalized
-	}
-	if entity.ZipNormalized != "" {
-		entityData.Fields["zip_normalized"] = entity.ZipNormalized
-	}
-	if entity.PhoneNormalized != "" {
-		entityData.Fields["phone_normalized"] = entity.PhoneNormalized
-	}
-	if entity.EmailNormalized != "" {
-		entityData.Fields["email_normalized"] = entity.EmailNormalized
-	}
+	entityData := s.entityDataFromRecord(entity)
 
 	matches, err := s.FindMatchesForEntity(ctx, entityData, matchOpts)
 	if err != nil {
@@ -159,12 +151,12 @@ func (s *Service) getDirectMatchGroup(ctx context.Context, group *MatchGroup, en
 }
 
 // getTransitiveMatchGroup finds entities through transitive relationships
-func (s *Service) getTransitiveMatchGroup(ctx context.Context, group *MatchGroup, entity *weaviate.EntityRecord, opts MatchGroupOptions) error {
+func (s *Service) getTransitiveMatchGroup(ctx context.Context, group *MatchGroup, entity *store.EntityRecord, opts MatchGroupOptions) error {
 	visited := make(map[string]bool)
 	visited[entity.ID] = true
 
 	// Breadth-first search to find transitive matches
-	queue := []*weaviate.EntityRecord{entity}
+	queue := []*store.EntityRecord{entity}
 	hopCount := make(map[string]int) // Track hop distance from primary entity
 	hopCount[entity.ID] = 0
 
@@ -179,42 +171,7 @@ func (s *Service) getTransitiveMatchGroup(ctx context.Context, group *MatchGroup
 		}
 
 		// Find direct matches for the current entity
-		entityData := EntityData{
-			ID: current.ID,
-			Fields: map[string]string{
-				"name":    current.Name,
-				"address": current.Address,
-				"city":    current.City,
-				"state":   current.State,
-				"zip":     current.Zip,
-				"phone":   current.Phone,
-				"email":   current.Email,
-			},
-			Metadata: current.Metadata,
-		}
-
-		// Add normalized fields if available
-		if current.NameNormalized != "" {
-			entityData.Fields["name_normalized"] = current.NameNormalized
-		}
-		if current.AddressNormalized != "" {
-			entityData.Fields["address_normalized"] = current.AddressNormalized
-		}
-		if current.CityNormalized != "" {
-			entityData.Fields["city_normalized"] = current.CityNormalized
-		}
-		if current.StateNormalized != "" {
-			entityData.Fields["state_normalized"] = current.StateNormalized
-		}
-		if current.ZipNormalized != "" {
-			entityData.Fields["zip_normalized"] = current.ZipNormalized
-		}
-		if current.PhoneNormalized != "" {
-			entityData.Fields["phone_normalized"] = current.PhoneNormalized
-		}
-		if current.EmailNormalized != "" {
-			entityData.Fields["email_normalized"] = current.EmailNormalized
-		}
+		entityData := s.entityDataFromRecord(current)
 
 		matchOpts := Options{
 			Limit:              opts.MaxGroupSize,
@@ -245,7 +202,7 @@ func (s *Service) getTransitiveMatchGroup(ctx context.Context, group *MatchGroup
 				}
 
 				// Get the full entity to add to the BFS queue
-				matchEntity, err := s.weaviateClient.GetEntity(ctx, match.ID)
+				matchEntity, err := s.store.GetEntity(ctx, match.ID)
 				if err != nil {
 					// Log the error but continue processing
 					fmt.Printf("Warning: couldn't retrieve entity %s: %v\n", match.ID, err)
@@ -263,7 +220,7 @@ func (s *Service) getTransitiveMatchGroup(ctx context.Context, group *MatchGroup
 }
 
 // getHybridMatchGroup combines direct and limited transitive matching
-func (s *Service) getHybridMatchGroup(ctx context.Context, group *MatchGroup, entity *weaviate.EntityRecord, opts MatchGroupOptions) error {
+func (s *Service) getHybridMatchGroup(ctx context.Context, group *MatchGroup, entity *store.EntityRecord, opts MatchGroupOptions) error {
 	// First get direct matches with a higher threshold for high confidence matches
 	directOpts := opts
 	directOpts.HopsLimit = 1
@@ -298,7 +255,7 @@ func (s *Service) getHybridMatchGroup(ctx context.Context, group *MatchGroup, en
 			}
 
 			// Get the entity to use for further matching
-			matchEntity, err := s.weaviateClient.GetEntity(ctx, directMatch.ID)
+			matchEntity, err := s.store.GetEntity(ctx, directMatch.ID)
 			if err != nil {
 				// Log the error but continue processing
 				fmt.Printf("Warning: couldn't retrieve entity %s: %v\n", directMatch.ID, err)
@@ -336,10 +293,29 @@ func (s *Service) getHybridMatchGroup(ctx context.Context, group *MatchGroup, en
 	return nil
 }
 
-// calculateGroupStatistics computes aggregate statistics for a match group
-func (s *Service) calculateGroupStatistics(group *MatchGroup) {
+// entityDataFromRecord converts a stored record back into EntityData so it can be used as a match query
+func (s *Service) entityDataFromRecord(entity *store.EntityRecord) EntityData {
+	entityData := EntityData{
+		ID:       entity.ID,
+		Fields:   make(map[string]string, 2*len(s.schemaFields)),
+		Metadata: entity.Metadata,
+	}
+
+	// Add schema fields, including normalized values if available
+	for _, field := range s.schemaFields {
+		entityData.Fields[field.Name] = entity.Fields[field.Name]
+		if normalized := entity.Fields[field.Name+"_normalized"]; normalized != "" {
+			entityData.Fields[field.Name+"_normalized"] = normalized
+		}
+	}
+
+	return entityData
+}
+
+// calculateGroupStatistics computes aggregate statistics and the golden record of a match group
+func (s *Service) calculateGroupStatistics(group *MatchGroup) error {
 	if len(group.Entities) == 0 {
-		return
+		return nil
 	}
 
 	// Calculate average score
@@ -355,53 +331,31 @@ func (s *Service) calculateGroupStatistics(group *MatchGroup) {
 		return group.Entities[i].Score > group.Entities[j].Score
 	})
 
-	// Calculate field value agreement
-	fieldCounts := make(map[string]map[string]int) // field -> value -> count
-
-	// Count occurrences of each value for each field
-	for _, entity := range group.Entities {
-		for field, value := range entity.Fields {
-			// Skip normalized fields
-			if field == "" || value == "" {
-				continue
-			}
-
-			// Initialize map if needed
-			if _, ok := fieldCounts[field]; !ok {
-				fieldCounts[field] = make(map[string]int)
-			}
-
-			// Increment count for this value
-			fieldCounts[field][value]++
-		}
+	records := make([]survivorship.Record, len(group.Entities))
+	for i, entity := range group.Entities {
+		records[i] = s.survivorshipRecord(entity.ID, entity.Fields, entity.Metadata, entity.UpdatedAt)
 	}
 
-	// Find the most common value for each field and calculate agreement percentage
-	for field, valueCounts := range fieldCounts {
-		maxCount := 0
-		mostCommonValue := ""
-
-		for value, count := range valueCounts {
-			if count > maxCount {
-				maxCount = count
-				mostCommonValue = value
-			}
-		}
-
-		if mostCommonValue != "" {
-			// Calculate percentage of agreement and confidence
-			agreement := float32(maxCount) / float32(len(group.Entities))
-
-			// Add to sample fields
-			group.SampleFields[field] = struct {
-				Value      string  `json:"value"`
-				Agreement  float32 `json:"agreement"`
-				Confidence float32 `json:"confidence"`
-			}{
-				Value:      mostCommonValue,
-				Agreement:  agreement,
-				Confidence: agreement * group.Score, // Weight by group score
-			}
+	// Find the most common value of every schema field, comparing normalized values,
+	// and calculate the percentage of agreement
+	samples, err := survivorship.NewEngine(survivorship.Options{DefaultRule: survivorship.MostFrequent}).Build(records, s.schemaFieldNames())
+	if err != nil {
+		return err
+	}
+	for field, sample := range samples.Fields {
+		agreement := float32(len(sample.Sources)) / float32(len(group.Entities))
+		group.SampleFields[field] = struct {
+			Value      string  `json:"value"`
+			Agreement  float32 `json:"agreement"`
+			Confidence float32 `json:"confidence"`
+		}{
+			Value:      sample.Value,
+			Agreement:  agreement,
+			Confidence: agreement * group.Score, // Weight by group score
 		}
 	}
+
+	// Build the golden record with the configured survivorship rules
+	group.Golden, err = s.goldenRecord(records)
+	return err
 }
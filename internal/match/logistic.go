@@ -0,0 +1,318 @@
+package match
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/TFMV/resolve/internal/store"
+)
+
+// Logistic regression training defaults
+const (
+	DefaultLogisticFolds      = 5
+	DefaultLogisticIterations = 1000
+	DefaultLogisticRate       = 0.5
+	DefaultLogisticL2         = 0.001
+	logisticDecisionThreshold = 0.5
+)
+
+// VectorCosineFeature is the name of the embedding similarity feature
+const VectorCosineFeature = "vector_cosine"
+
+// LabeledPair is a reviewed record pair
+type LabeledPair struct {
+	IDA   string
+	IDB   string
+	Match bool
+}
+
+// LogisticOptions controls supervised model training
+type LogisticOptions struct {
+	Folds        int     // Number of cross-validation folds
+	Iterations   int     // Gradient descent iterations
+	LearningRate float64 // Gradient descent step size
+	L2           float64 // L2 regularization strength (default DefaultLogisticL2)
+	NoL2         bool    // Train without L2 regularization, ignoring L2
+	Seed         int64   // Seed for fold assignment
+}
+
+// LogisticModel is a logistic regression over pair features. For every schema field
+// the features are its similarity score and a missing-value indicator, followed by the
+// cosine similarity of the entity vectors.
+type LogisticModel struct {
+	Fields   []string  `json:"fields"`   // Schema fields, in feature order
+	Features []string  `json:"features"` // Feature names, aligned with Weights
+	Weights  []float64 `json:"weights"`
+	Bias     float64   `json:"bias"`
+}
+
+// CrossValidation reports the cross-validated quality of a supervised model
+type CrossValidation struct {
+	Folds     int     `json:"folds"`
+	Threshold float64 `json:"threshold"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+}
+
+// Validate checks that the model is well formed
+func (m *LogisticModel) Validate() error {
+	if len(m.Fields) == 0 {
+		return fmt.Errorf("model has no fields")
+	}
+	if len(m.Features) != len(m.Weights) || len(m.Features) != 2*len(m.Fields)+1 {
+		return fmt.Errorf("model has %d features and %d weights for %d fields", len(m.Features), len(m.Weights), len(m.Fields))
+	}
+	return nil
+}
+
+// Predict returns the match probability for a feature vector
+func (m *LogisticModel) Predict(features []float64) float64 {
+	z := m.Bias
+	for i, w := range m.Weights {
+		z += w * features[i]
+	}
+	return sigmoid(z)
+}
+
+// featureNames returns the feature names for the given fields
+func featureNames(fields []string) []string {
+	names := make([]string, 0, 2*len(fields)+1)
+	for _, field := range fields {
+		names = append(names, field+"_similarity", field+"_missing")
+	}
+	return append(names, VectorCosineFeature)
+}
+
+// PairFeatures computes the feature vector of two records for the model's fields
+func (s *Service) PairFeatures(fields []string, a, b *store.EntityRecord) []float64 {
+	return s.pairFeatures(fields, a.Fields, b.Fields, a.Vector, b.Vector)
+}
+
+// pairFeatures computes per-field similarities, missing indicators and vector cosine
+func (s *Service) pairFeatures(fields []string, a, b map[string]string, vectorA, vectorB []float32) []float64 {
+	features := make([]float64, 0, 2*len(fields)+1)
+	for _, field := range fields {
+		valueA, valueB := comparableValues(a, b, field)
+		if valueA == "" || valueB == "" {
+			features = append(features, 0, 1)
+			continue
+		}
+		features = append(features, s.similarityFunctionForField(field, Options{}).Compare(valueA, valueB), 0)
+	}
+	return append(features, store.CosineSimilarity(vectorA, vectorB))
+}
+
+// TrainLogistic fits a logistic regression model to labeled pairs of stored entities
+// and reports its k-fold cross-validated precision and recall
+func (s *Service) TrainLogistic(ctx context.Context, pairs []LabeledPair, opts LogisticOptions) (*ModelArtifact, error) {
+	// Apply defaults
+	if opts.Folds <= 1 {
+		opts.Folds = DefaultLogisticFolds
+	}
+	if opts.Iterations <= 0 {
+		opts.Iterations = DefaultLogisticIterations
+	}
+	if opts.LearningRate <= 0 {
+		opts.LearningRate = DefaultLogisticRate
+	}
+	if opts.NoL2 {
+		opts.L2 = 0
+	} else if opts.L2 <= 0 {
+		opts.L2 = DefaultLogisticL2
+	}
+
+	fields := make([]string, len(s.schemaFields))
+	for i, field := range s.schemaFields {
+		fields[i] = field.Name
+	}
+
+	// Build feature vectors, fetching each entity once
+	entities := make(map[string]*store.EntityRecord)
+	getEntity := func(id string) (*store.EntityRecord, error) {
+		if entity, ok := entities[id]; ok {
+			return entity, nil
+		}
+		entity, err := s.store.GetEntity(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve entity %s: %w", id, err)
+		}
+		entities[id] = entity
+		return entity, nil
+	}
+
+	features := make([][]float64, len(pairs))
+	labels := make([]float64, len(pairs))
+	positives := 0
+	for i, pair := range pairs {
+		a, err := getEntity(pair.IDA)
+		if err != nil {
+			return nil, err
+		}
+		b, err := getEntity(pair.IDB)
+		if err != nil {
+			return nil, err
+		}
+		features[i] = s.PairFeatures(fields, a, b)
+		if pair.Match {
+			labels[i] = 1
+			positives++
+		}
+	}
+	if positives == 0 || positives == len(pairs) {
+		return nil, fmt.Errorf("labels must include both matches and non-matches")
+	}
+	if len(pairs) < opts.Folds {
+		return nil, fmt.Errorf("at least %d labeled pairs are required for %d-fold cross-validation", opts.Folds, opts.Folds)
+	}
+
+	// Cross-validate
+	rng := rand.New(rand.NewSource(opts.Seed))
+	folds := rng.Perm(len(pairs))
+	var tp, fp, fn float64
+	for fold := 0; fold < opts.Folds; fold++ {
+		var trainX, testX [][]float64
+		var trainY, testY []float64
+		for i, idx := range folds {
+			if i%opts.Folds == fold {
+				testX, testY = append(testX, features[idx]), append(testY, labels[idx])
+			} else {
+				trainX, trainY = append(trainX, features[idx]), append(trainY, labels[idx])
+			}
+		}
+
+		model := fitLogistic(fields, trainX, trainY, opts)
+		for i, x := range testX {
+			predicted := model.Predict(x) >= logisticDecisionThreshold
+			actual := testY[i] == 1
+			switch {
+			case predicted && actual:
+				tp++
+			case predicted && !actual:
+				fp++
+			case !predicted && actual:
+				fn++
+			}
+		}
+	}
+
+	cv := &CrossValidation{Folds: opts.Folds, Threshold: logisticDecisionThreshold}
+	if tp+fp > 0 {
+		cv.Precision = tp / (tp + fp)
+	}
+	if tp+fn > 0 {
+		cv.Recall = tp / (tp + fn)
+	}
+	if cv.Precision+cv.Recall > 0 {
+		cv.F1 = 2 * cv.Precision * cv.Recall / (cv.Precision + cv.Recall)
+	}
+
+	// Fit the final model on all labeled pairs
+	model := fitLogistic(fields, features, labels, opts)
+
+	return &ModelArtifact{
+		Version:   ModelVersion,
+		Type:      ModelTypeLogistic,
+		CreatedAt: time.Now().UTC(),
+		Stats: TrainingStats{
+			Entities:        len(entities),
+			Pairs:           len(pairs),
+			Iterations:      opts.Iterations,
+			L2:              opts.L2,
+			CrossValidation: cv,
+		},
+		Logistic: model,
+	}, nil
+}
+
+// fitLogistic fits logistic regression weights with batch gradient descent
+func fitLogistic(fields []string, x [][]float64, y []float64, opts LogisticOptions) *LogisticModel {
+	names := featureNames(fields)
+	model := &LogisticModel{
+		Fields:   append([]string(nil), fields...),
+		Features: names,
+		Weights:  make([]float64, len(names)),
+	}
+	if len(x) == 0 {
+		return model
+	}
+
+	n := float64(len(x))
+	gradient := make([]float64, len(names))
+	for iter := 0; iter < opts.Iterations; iter++ {
+		for i := range gradient {
+			gradient[i] = 0
+		}
+		var biasGradient float64
+
+		for i, features := range x {
+			err := model.Predict(features) - y[i]
+			for j, value := range features {
+				gradient[j] += err * value
+			}
+			biasGradient += err
+		}
+
+		for j := range model.Weights {
+			model.Weights[j] -= opts.LearningRate * (gradient[j]/n + opts.L2*model.Weights[j])
+		}
+		model.Bias -= opts.LearningRate * biasGradient / n
+	}
+
+	return model
+}
+
+// ReadLabeledPairs parses a CSV of id_a,id_b,label rows. A header row is skipped.
+// Labels may be 1/0, true/false, yes/no or match/non-match.
+func ReadLabeledPairs(r io.Reader) ([]LabeledPair, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+
+	var pairs []LabeledPair
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read labels: %w", err)
+		}
+
+		match, ok := parseLabel(record[2])
+		if !ok {
+			if line == 1 {
+				continue // Header row
+			}
+			return nil, fmt.Errorf("invalid label %q on line %d", record[2], line)
+		}
+
+		pairs = append(pairs, LabeledPair{IDA: strings.TrimSpace(record[0]), IDB: strings.TrimSpace(record[1]), Match: match})
+	}
+
+	return pairs, nil
+}
+
+// parseLabel parses a match label
+func parseLabel(label string) (bool, bool) {
+	switch strings.ToLower(strings.TrimSpace(label)) {
+	case "1", "true", "yes", "y", "match":
+		return true, true
+	case "0", "false", "no", "n", "non-match", "nonmatch", "non_match":
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+// sigmoid is the logistic function
+func sigmoid(z float64) float64 {
+	return 1 / (1 + math.Exp(-z))
+}
@@ -0,0 +1,76 @@
+package match
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestReadLabeledPairs(t *testing.T) {
+	input := "id_a,id_b,label\na,b,1\nc,d,non-match\n"
+	pairs, err := ReadLabeledPairs(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadLabeledPairs: %v", err)
+	}
+	if len(pairs) != 2 || !pairs[0].Match || pairs[1].Match || pairs[1].IDB != "d" {
+		t.Errorf("unexpected pairs: %+v", pairs)
+	}
+
+	if _, err := ReadLabeledPairs(strings.NewReader("a,b,1\nc,d,maybe\n")); err == nil {
+		t.Errorf("expected error for invalid label")
+	}
+}
+
+func TestTrainLogisticScorer(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestService(t, false)
+
+	entities := append([]EntityData{
+		{ID: "initech-1", Fields: map[string]string{"name": "Initech", "address": "4120 Freidrich Lane", "zip": "73301"}},
+		{ID: "initech-2", Fields: map[string]string{"name": "Initech LLC", "address": "4120 Freidrich Ln", "zip": "73301"}},
+	}, testEntities...)
+	if _, err := svc.AddEntities(ctx, entities); err != nil {
+		t.Fatalf("AddEntities: %v", err)
+	}
+
+	pairs := []LabeledPair{
+		{IDA: "acme-1", IDB: "acme-2", Match: true},
+		{IDA: "initech-1", IDB: "initech-2", Match: true},
+		{IDA: "acme-1", IDB: "globex", Match: false},
+		{IDA: "acme-2", IDB: "initech-1", Match: false},
+		{IDA: "globex", IDB: "initech-2", Match: false},
+		{IDA: "acme-1", IDB: "initech-2", Match: false},
+	}
+	artifact, err := svc.TrainLogistic(ctx, pairs, LogisticOptions{Folds: 2, Seed: 1})
+	if err != nil {
+		t.Fatalf("TrainLogistic: %v", err)
+	}
+	if artifact.Stats.CrossValidation == nil || artifact.Stats.CrossValidation.Folds != 2 {
+		t.Fatalf("missing cross-validation report: %+v", artifact.Stats)
+	}
+	if artifact.Stats.L2 != DefaultLogisticL2 {
+		t.Errorf("expected the default L2 strength %v, got %v", DefaultLogisticL2, artifact.Stats.L2)
+	}
+	unregularized, err := svc.TrainLogistic(ctx, pairs, LogisticOptions{Folds: 2, Seed: 1, NoL2: true})
+	if err != nil {
+		t.Fatalf("TrainLogistic without L2: %v", err)
+	}
+	if unregularized.Stats.L2 != 0 {
+		t.Errorf("expected NoL2 to disable regularization, got %v", unregularized.Stats.L2)
+	}
+	if err := svc.UseModel(artifact); err != nil {
+		t.Fatalf("UseModel: %v", err)
+	}
+
+	matches, err := svc.FindMatchesForEntity(ctx, testEntities[0], Options{Scorer: ScorerLogistic, Threshold: 0.01})
+	if err != nil {
+		t.Fatalf("FindMatchesForEntity: %v", err)
+	}
+	scores := make(map[string]float32)
+	for _, m := range matches {
+		scores[m.ID] = m.Score
+	}
+	if scores["acme-2"] <= scores["globex"] {
+		t.Errorf("expected acme-2 to outscore globex, got %v", scores)
+	}
+}
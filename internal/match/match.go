@@ -2,19 +2,27 @@ package match
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/TFMV/resolve/internal/cluster"
 	"github.com/TFMV/resolve/internal/config"
 	"github.com/TFMV/resolve/internal/embed"
 	"github.com/TFMV/resolve/internal/normalize"
+	"github.com/TFMV/resolve/internal/override"
+	"github.com/TFMV/resolve/internal/review"
 	"github.com/TFMV/resolve/internal/similarity"
-	"github.com/TFMV/resolve/internal/weaviate"
+	"github.com/TFMV/resolve/internal/store"
+	"github.com/TFMV/resolve/internal/survivorship"
 )
 
+// ErrInvalidEntity is returned when entity data cannot be ingested as given
+var ErrInvalidEntity = errors.New("invalid entity")
+
 // EntityData represents a single entity with all its attributes
 type EntityData struct {
 	ID       string                 `json:"id,omitempty"`
@@ -29,6 +37,8 @@ type FieldScore struct {
 	MatchedValue string  `json:"matched_value,omitempty"`
 	SimilarityFn string  `json:"similarity_function,omitempty"`
 	Normalized   bool    `json:"normalized,omitempty"`
+	Level        string  `json:"level,omitempty"`  // Fellegi-Sunter comparison level
+	Weight       float64 `json:"weight,omitempty"` // Fellegi-Sunter log2 match weight
 }
 
 // MatchResult represents a match result with scores
@@ -42,18 +52,23 @@ type MatchResult struct {
 	CreatedAt   int64                  `json:"created_at,omitempty"`
 	UpdatedAt   int64                  `json:"updated_at,omitempty"`
 	FieldScores map[string]FieldScore  `json:"field_scores,omitempty"`
+	MatchWeight float64                `json:"match_weight,omitempty"` // Fellegi-Sunter total log2 match weight
 }
 
+// NoThreshold is a match threshold that keeps every scored candidate
+const NoThreshold float32 = -1
+
 // Options represents matching options
 type Options struct {
 	Limit                 int
-	Threshold             float32
+	Threshold             float32 // Minimum score; 0 uses matching.similarity_threshold and NoThreshold keeps every candidate
 	IncludeDetails        bool
 	UseClustering         bool               // Whether to use clustering
 	IncludeFieldScores    bool               // Whether to include field-level similarity scores
 	FieldWeights          map[string]float32 // Optional field weights for weighted scoring
 	FieldTypeMappings     map[string]string  // Optional field type mappings for similarity functions
 	ForceExactMatchFields []string           // Fields that should use exact matching
+	Scorer                string             // Scoring model: "vector" (default), "fellegi_sunter" or "logistic"
 }
 
 // Service represents the matching service
@@ -61,13 +76,24 @@ type Service struct {
 	cfg              *config.Config
 	normalizer       *normalize.Normalizer
 	embeddingService embed.EmbeddingService
-	weaviateClient   *weaviate.Client
+	store            store.VectorStore
 	clusterService   *cluster.Service
 	similarityReg    *similarity.Registry
+	schemaFields     []config.FieldConfig
+	fsModel          *FellegiSunterModel
+	logisticModel    *LogisticModel
+	survivorship     *survivorship.Engine
+	overrides        *override.Store // Steward overrides; nil when not configured
+	review           *review.Store   // Clerical review queue; nil when review is disabled
+	resolveMutex     sync.Mutex      // Serializes resolved ID assignment
+
+	tfidf      map[string]similarity.Function // Soft TF-IDF comparators by field, once statistics are built
+	tfidfMutex sync.RWMutex
 }
 
-// NewService creates a new matching service
-func NewService(cfg *config.Config, weaviateClient *weaviate.Client, embeddingService embed.EmbeddingService) *Service {
+// NewService creates a new matching service. It fails if the similarity settings do not
+// compile, which can only happen with a configuration that was not validated by Load.
+func NewService(cfg *config.Config, vectorStore store.VectorStore, embeddingService embed.EmbeddingService) (*Service, error) {
 	// Create normalizer
 	normalizer := normalize.NewNormalizer(cfg)
 
@@ -77,24 +103,65 @@ func NewService(cfg *config.Config, weaviateClient *weaviate.Client, embeddingSe
 		Method:              cfg.Clustering.Method,
 		Fields:              cfg.Clustering.Fields,
 		SimilarityThreshold: cfg.Clustering.SimilarityThreshold,
+		TightThreshold:      cfg.Clustering.TightThreshold,
+		Distance:            cfg.Clustering.Distance,
+		Bands:               cfg.Clustering.Bands,
+		Rows:                cfg.Clustering.Rows,
+		ShingleSize:         cfg.Clustering.ShingleSize,
+		MaxBandSearches:     cfg.Clustering.MaxBandSearches,
+		Rules:               cfg.Clustering.Rules,
+		Phonetic:            cfg.Clustering.Phonetic,
 	}
 	clusterService := cluster.NewService(clusterConfig, normalizer)
 
 	// Create similarity registry
-	similarityReg := similarity.NewRegistry()
+	similarityReg, err := similarity.NewRegistryWithOptions(cfg.SimilarityOptions())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create similarity registry: %w", err)
+	}
+
+	// Create survivorship engine for golden records
+	survivorshipEngine := survivorship.NewEngine(survivorship.Options{
+		DefaultRule:    cfg.Survivorship.DefaultRule,
+		Rules:          cfg.Survivorship.Rules,
+		SourcePriority: cfg.Survivorship.SourcePriority,
+	})
+
+	// Open the steward override log if configured
+	var overrides *override.Store
+	if cfg.Overrides.Path != "" {
+		overrides = override.NewStore(cfg.Overrides.Path)
+	}
+
+	// Open the clerical review queue if review is enabled
+	var reviewQueue *review.Store
+	if cfg.Review.Enabled && cfg.Review.Path != "" {
+		reviewQueue = review.NewStore(cfg.Review.Path)
+	}
 
 	return &Service{
 		cfg:              cfg,
 		normalizer:       normalizer,
 		embeddingService: embeddingService,
-		weaviateClient:   weaviateClient,
+		store:            vectorStore,
 		clusterService:   clusterService,
 		similarityReg:    similarityReg,
-	}
+		schemaFields:     cfg.SchemaFields(),
+		fsModel:          DefaultFellegiSunterModel(cfg.SchemaFields()),
+		survivorship:     survivorshipEngine,
+		overrides:        overrides,
+		review:           reviewQueue,
+	}, nil
 }
 
-// AddEntity adds a single entity to the database
-func (s *Service) AddEntity(ctx context.Context, data EntityData) error {
+// AddEntity normalizes, embeds and clusters a single entity, adds it to the
+// database and returns its ID
+func (s *Service) AddEntity(ctx context.Context, data EntityData) (string, error) {
+	// Reject fields the schema does not declare instead of silently dropping them
+	if err := s.validateFields(data.Fields); err != nil {
+		return "", err
+	}
+
 	// Normalize fields
 	normalizedFields := s.normalizer.NormalizeEntity(data.Fields)
 
@@ -104,35 +171,61 @@ func (s *Service) AddEntity(ctx context.Context, data EntityData) error {
 	// Generate embeddings
 	vector, err := s.embeddingService.GetEmbedding(ctx, textToEmbed)
 	if err != nil {
-		return fmt.Errorf("failed to generate embeddings: %w", err)
+		return "", fmt.Errorf("failed to generate embeddings: %w", err)
 	}
 
-	// Convert to Weaviate entity
-	entity := convertToWeaviateEntity(data.ID, normalizedFields, vector, data.Metadata)
+	// Convert to a store record
+	entity := s.convertToEntityRecord(data.ID, normalizedFields, vector, data.Metadata)
 
 	// Assign cluster ID if clustering is enabled
+	dropCanopy := func() {}
 	if s.cfg.Clustering.Enabled {
-		_, err = s.clusterService.AssignCluster(ctx, entity)
+		if err := s.clusterService.LoadCanopies(ctx, s.store); err != nil {
+			return "", err
+		}
+		dropCanopy, err = s.assignCluster(ctx, entity)
 		if err != nil {
-			return fmt.Errorf("failed to assign cluster to entity: %w", err)
+			return "", fmt.Errorf("failed to assign cluster to entity: %w", err)
 		}
 	}
 
-	// Add to Weaviate
-	_, err = s.weaviateClient.AddEntity(ctx, entity)
+	// Add to the vector store
+	id, err := s.store.AddEntity(ctx, entity)
 	if err != nil {
-		return fmt.Errorf("failed to add entity to Weaviate: %w", err)
+		dropCanopy()
+		return "", fmt.Errorf("failed to add entity to vector store: %w", err)
 	}
 
-	return nil
+	// Resolve the entity and queue its borderline matches for review, as enabled
+	if err := s.resolveAndReview(ctx, id); err != nil {
+		return "", err
+	}
+
+	return id, nil
 }
 
-// AddEntities adds multiple entities to the database in batch
-func (s *Service) AddEntities(ctx context.Context, dataList []EntityData) error {
-	entities := make([]*weaviate.EntityRecord, len(dataList))
+// AddEntities adds multiple entities to the database in batch and returns their IDs
+func (s *Service) AddEntities(ctx context.Context, dataList []EntityData) ([]string, error) {
+	entities := make([]*store.EntityRecord, len(dataList))
+
+	// Drop the canopies started by the batch unless it is stored
+	var dropCanopies []func()
+	stored := false
+	defer func() {
+		if !stored {
+			for _, drop := range dropCanopies {
+				drop()
+			}
+		}
+	}()
 
 	// Process all entities first (normalize & generate embeddings)
 	for i, data := range dataList {
+		// Reject fields the schema does not declare instead of silently dropping them
+		if err := s.validateFields(data.Fields); err != nil {
+			return nil, fmt.Errorf("entity %d: %w", i, err)
+		}
+
 		// Normalize fields
 		normalizedFields := s.normalizer.NormalizeEntity(data.Fields)
 
@@ -142,38 +235,184 @@ func (s *Service) AddEntities(ctx context.Context, dataList []EntityData) error
 		// Generate embedding
 		vector, err := s.embeddingService.GetEmbedding(ctx, textToEmbed)
 		if err != nil {
-			return fmt.Errorf("failed to generate embeddings for entity %d: %w", i, err)
+			return nil, fmt.Errorf("failed to generate embeddings for entity %d: %w", i, err)
 		}
 
-		// Convert to Weaviate entity
-		entities[i] = convertToWeaviateEntity(data.ID, normalizedFields, vector, data.Metadata)
+		// Convert to a store record
+		entities[i] = s.convertToEntityRecord(data.ID, normalizedFields, vector, data.Metadata)
 
 		// Assign cluster ID if clustering is enabled
 		if s.cfg.Clustering.Enabled {
-			_, err = s.clusterService.AssignCluster(ctx, entities[i])
+			if err := s.clusterService.LoadCanopies(ctx, s.store); err != nil {
+				return nil, err
+			}
+			drop, err := s.assignCluster(ctx, entities[i])
 			if err != nil {
-				return fmt.Errorf("failed to assign cluster to entity %d: %w", i, err)
+				return nil, fmt.Errorf("failed to assign cluster to entity %d: %w", i, err)
 			}
+			dropCanopies = append(dropCanopies, drop)
 		}
 	}
 
-	// Add to Weaviate in batch
-	_, err := s.weaviateClient.BatchAddEntities(ctx, entities)
+	// Add to the vector store in batch
+	ids, err := s.store.BatchAddEntities(ctx, entities)
 	if err != nil {
-		return fmt.Errorf("failed to add entities to Weaviate: %w", err)
+		return nil, fmt.Errorf("failed to add entities to vector store: %w", err)
 	}
+	stored = true
 
-	return nil
+	// Resolve the entities in order and queue their borderline matches for review, as
+	// enabled; matches within the batch join the resolved entity of the earlier record
+	for _, id := range ids {
+		if err := s.resolveAndReview(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+
+	return ids, nil
+}
+
+// UpdateEntity normalizes, embeds and clusters the new fields of a stored entity and
+// replaces them. The given metadata is merged into the stored metadata. If resolution is
+// enabled the entity is resolved again, and if review is enabled its borderline matches
+// are queued.
+func (s *Service) UpdateEntity(ctx context.Context, data EntityData) error {
+	if data.ID == "" {
+		return fmt.Errorf("%w: entity ID is required", ErrInvalidEntity)
+	}
+
+	// Reject fields the schema does not declare instead of silently dropping them
+	if err := s.validateFields(data.Fields); err != nil {
+		return err
+	}
+
+	// Get the stored entity
+	existing, err := s.store.GetEntity(ctx, data.ID)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve entity %s: %w", data.ID, err)
+	}
+
+	// Merge metadata
+	metadata := make(map[string]interface{}, len(existing.Metadata)+len(data.Metadata))
+	for key, value := range existing.Metadata {
+		metadata[key] = value
+	}
+	for key, value := range data.Metadata {
+		metadata[key] = value
+	}
+	metadata["updated_at"] = time.Now().Unix()
+
+	// Normalize fields
+	normalizedFields := s.normalizer.NormalizeEntity(data.Fields)
+
+	// Generate embeddings
+	vector, err := s.embeddingService.GetEmbedding(ctx, combineFields(normalizedFields))
+	if err != nil {
+		return fmt.Errorf("failed to generate embeddings: %w", err)
+	}
+
+	// Convert to a store record
+	entity := s.convertToEntityRecord(data.ID, normalizedFields, vector, metadata)
+	entity.CreatedAt = existing.CreatedAt
+
+	// Reassign the cluster ID if clustering is enabled
+	dropCanopy := func() {}
+	if s.cfg.Clustering.Enabled {
+		if err := s.clusterService.LoadCanopies(ctx, s.store); err != nil {
+			return err
+		}
+		if dropCanopy, err = s.assignCluster(ctx, entity); err != nil {
+			return fmt.Errorf("failed to assign cluster to entity: %w", err)
+		}
+	}
+
+	// Update the vector store
+	if err := s.store.UpdateEntity(ctx, entity); err != nil {
+		dropCanopy()
+		return fmt.Errorf("failed to update entity in vector store: %w", err)
+	}
+
+	// Resolve the entity again and queue its borderline matches for review, as enabled
+	return s.resolveAndReview(ctx, data.ID)
+}
+
+// assignCluster assigns the cluster of an entity about to be stored and returns a function
+// that drops the canopy the entity started, if any, for when it cannot be stored
+func (s *Service) assignCluster(ctx context.Context, entity *store.EntityRecord) (func(), error) {
+	center, _ := entity.Metadata[cluster.CanopyCenterMetadataKey].(string)
+	if _, err := s.clusterService.AssignCluster(ctx, entity); err != nil {
+		return nil, err
+	}
+	if id, _ := entity.Metadata[cluster.CanopyCenterMetadataKey].(string); id != "" && id != center {
+		return func() { s.clusterService.DropCanopy(id) }, nil
+	}
+	return func() {}, nil
+}
+
+// resolveAndReview resolves a stored entity if resolution is enabled and queues its
+// borderline matches if review is enabled. Both share one match pass, searched with the
+// stored vector so the entity is not embedded again.
+func (s *Service) resolveAndReview(ctx context.Context, id string) error {
+	resolve, queue := s.cfg.Resolution.Enabled, s.review != nil
+	if !resolve && !queue {
+		return nil
+	}
+
+	// Hold the resolve lock while matching so the matches see the latest resolved IDs
+	if resolve {
+		s.resolveMutex.Lock()
+		defer s.resolveMutex.Unlock()
+	}
+
+	entity, err := s.store.GetEntity(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve entity %s: %w", id, err)
+	}
+
+	// Match down to the lower of the resolve and auto-reject thresholds
+	opts := Options{Threshold: s.resolveThreshold()}
+	if queue {
+		opts.Threshold = min(opts.Threshold, s.reviewThreshold())
+		opts.IncludeFieldScores = true
+	}
+	matches, err := s.matchRecord(ctx, entity, opts)
+	if err != nil {
+		return fmt.Errorf("failed to match entity %s: %w", id, err)
+	}
+
+	if resolve {
+		if _, err := s.resolveMatches(ctx, entity, matches); err != nil {
+			return fmt.Errorf("failed to resolve entity %s: %w", id, err)
+		}
+	}
+	return s.queueReview(id, matches)
 }
 
 // FindMatches finds the best matching entities for the input text
 func (s *Service) FindMatches(ctx context.Context, text string, opts Options) ([]MatchResult, error) {
+	// Parse input fields if text contains field=value pairs
+	return s.findMatches(ctx, text, parseQueryFields(text), opts)
+}
+
+// findMatches embeds the text, retrieves candidates for it and scores them against the query fields
+func (s *Service) findMatches(ctx context.Context, text string, queryFields map[string]string, opts Options) ([]MatchResult, error) {
+	// Generate embedding for the query
+	vector, err := s.embeddingService.GetEmbedding(ctx, text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate embedding for query: %w", err)
+	}
+
+	return s.matchVector(ctx, text, vector, queryFields, opts)
+}
+
+// matchVector retrieves candidates for an embedded query and scores them against the query fields
+func (s *Service) matchVector(ctx context.Context, text string, vector []float32, queryFields map[string]string, opts Options) ([]MatchResult, error) {
 	// Apply default options if needed
 	if opts.Limit <= 0 {
 		opts.Limit = s.cfg.Matching.DefaultLimit
 	}
 
-	if opts.Threshold <= 0 {
+	if opts.Threshold == 0 {
 		opts.Threshold = s.cfg.Matching.SimilarityThreshold
 	}
 
@@ -182,27 +421,29 @@ func (s *Service) FindMatches(ctx context.Context, text string, opts Options) ([
 		opts.UseClustering = s.cfg.Clustering.Enabled
 	}
 
-	// Generate embedding for the query
-	vector, err := s.embeddingService.GetEmbedding(ctx, text)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate embedding for query: %w", err)
+	// Create a temporary entity to assign blocking keys, using the query fields when
+	// there are any and the raw text as a name otherwise
+	blockingFields := map[string]string{"name": text}
+	if len(queryFields) > 0 {
+		blockingFields = s.normalizer.NormalizeEntity(queryFields)
 	}
-
-	// Create a temporary entity to assign a cluster
-	tempEntity := &weaviate.EntityRecord{
-		Name:   text,
+	tempEntity := &store.EntityRecord{
+		Fields: blockingFields,
 		Vector: vector,
 	}
 
-	// Get cluster filter if clustering is enabled and we should use it
-	var filterParams map[string]string
+	// Get a filter per blocking pass if clustering is enabled and we should use it
+	var filters []map[string]string
 	if opts.UseClustering && s.cfg.Clustering.Enabled {
-		_, err = s.clusterService.AssignCluster(ctx, tempEntity)
+		if err := s.clusterService.LoadCanopies(ctx, s.store); err != nil {
+			return nil, err
+		}
+		_, err := s.clusterService.AssignQuery(ctx, tempEntity)
 		if err != nil {
 			return nil, fmt.Errorf("failed to assign cluster to query: %w", err)
 		}
 
-		filterParams = s.clusterService.GetClusterFilterForEntity(ctx, tempEntity)
+		filters = s.clusterService.GetClusterFiltersForEntity(ctx, tempEntity)
 	}
 
 	// Double the limit to account for filtering effect of clustering
@@ -213,19 +454,30 @@ func (s *Service) FindMatches(ctx context.Context, text string, opts Options) ([
 		searchLimit = opts.Limit
 	}
 
-	// Search in Weaviate
-	results, err := s.weaviateClient.SearchEntities(ctx, vector, searchLimit, filterParams)
+	// Search the vector store, taking the union of the candidates of every blocking pass
+	results, err := s.searchCandidates(ctx, vector, searchLimit, filters)
 	if err != nil {
-		return nil, fmt.Errorf("failed to search Weaviate: %w", err)
+		return nil, err
 	}
 
-	// Parse input fields if text contains field=value pairs
-	queryFields := parseQueryFields(text)
+	// Validate the scoring model
+	if opts.Scorer == "" {
+		opts.Scorer = s.cfg.Matching.Scorer
+	}
+	switch opts.Scorer {
+	case "", ScorerVector, ScorerFellegiSunter:
+	case ScorerLogistic:
+		if s.logisticModel == nil {
+			return nil, fmt.Errorf("the logistic scorer requires a trained model; set matching.model_path")
+		}
+	default:
+		return nil, fmt.Errorf("unknown scorer: %s", opts.Scorer)
+	}
 
 	// Convert to match results
 	matchResults := make([]MatchResult, 0, len(results))
 	for _, result := range results {
-		// Get score from metadata (distance is stored there by Weaviate client)
+		// Get score from metadata (distance is stored there by the vector store)
 		score := float32(1.0) // Default score
 		if result.Metadata != nil {
 			if distVal, ok := result.Metadata["distance"].(float64); ok {
@@ -234,13 +486,38 @@ func (s *Service) FindMatches(ctx context.Context, text string, opts Options) ([
 			}
 		}
 
+		// Score probabilistically; vector similarity only selects the candidates
+		if opts.Scorer == ScorerFellegiSunter {
+			matchResult := s.convertToMatchResult(result, score)
+			s.applyFellegiSunter(&matchResult, queryFields, opts)
+			if matchResult.Score >= opts.Threshold {
+				matchResults = append(matchResults, matchResult)
+			}
+			continue
+		}
+
+		// Score with the supervised model in place of field weights
+		if opts.Scorer == ScorerLogistic {
+			matchResult := s.convertToMatchResult(result, score)
+			features := s.pairFeatures(s.logisticModel.Fields, queryFields, result.Fields, vector, result.Vector)
+			matchResult.Score = float32(s.logisticModel.Predict(features))
+			matchResult.Explanation = generateExplanation(matchResult.Score, matchResult.MatchedOn)
+			if opts.IncludeFieldScores {
+				s.computeFieldScores(&matchResult, queryFields, Options{FieldTypeMappings: opts.FieldTypeMappings, ForceExactMatchFields: opts.ForceExactMatchFields})
+			}
+			if matchResult.Score >= opts.Threshold {
+				matchResults = append(matchResults, matchResult)
+			}
+			continue
+		}
+
 		// Skip results with a score below threshold
 		if score < opts.Threshold {
 			continue
 		}
 
 		// Convert to match result
-		matchResult := convertToMatchResult(result, score)
+		matchResult := s.convertToMatchResult(result, score)
 
 		// Apply field-level scoring if requested
 		if opts.IncludeFieldScores || len(queryFields) > 0 {
@@ -263,6 +540,35 @@ func (s *Service) FindMatches(ctx context.Context, text string, opts Options) ([
 	return matchResults, nil
 }
 
+// searchCandidates searches the vector store once per filter and merges the results by
+// entity ID. Without filters the whole store is searched.
+func (s *Service) searchCandidates(ctx context.Context, vector []float32, limit int, filters []map[string]string) ([]*store.EntityRecord, error) {
+	if len(filters) == 0 {
+		results, err := s.store.SearchEntities(ctx, vector, limit, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search vector store: %w", err)
+		}
+		return results, nil
+	}
+
+	seen := make(map[string]bool)
+	var candidates []*store.EntityRecord
+	for _, filter := range filters {
+		results, err := s.store.SearchEntities(ctx, vector, limit, filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search vector store: %w", err)
+		}
+		for _, result := range results {
+			if !seen[result.ID] {
+				seen[result.ID] = true
+				candidates = append(candidates, result)
+			}
+		}
+	}
+
+	return candidates, nil
+}
+
 // FindMatchesForEntity finds the best matching entities for the given entity
 func (s *Service) FindMatchesForEntity(ctx context.Context, entity EntityData, opts Options) ([]MatchResult, error) {
 	// Apply default options if needed
@@ -270,7 +576,7 @@ func (s *Service) FindMatchesForEntity(ctx context.Context, entity EntityData, o
 		opts.Limit = s.cfg.Matching.DefaultLimit
 	}
 
-	if opts.Threshold <= 0 {
+	if opts.Threshold == 0 {
 		opts.Threshold = s.cfg.Matching.SimilarityThreshold
 	}
 
@@ -280,8 +586,57 @@ func (s *Service) FindMatchesForEntity(ctx context.Context, entity EntityData, o
 	// Concatenate fields for embedding
 	textToEmbed := combineFields(normalizedFields)
 
-	// Then use the regular FindMatches method
-	return s.FindMatches(ctx, textToEmbed, opts)
+	// Compare candidates against the entity's own fields
+	return s.findMatches(ctx, textToEmbed, normalizedFields, opts)
+}
+
+// matchRecord finds the matches of a stored entity, searching with its stored vector so it
+// is not embedded again
+func (s *Service) matchRecord(ctx context.Context, entity *store.EntityRecord, opts Options) ([]MatchResult, error) {
+	data := s.entityDataFromRecord(entity)
+	if len(entity.Vector) == 0 {
+		return s.FindMatchesForEntity(ctx, data, opts)
+	}
+
+	// Compare candidates against the entity's own fields
+	normalizedFields := s.normalizer.NormalizeEntity(data.Fields)
+	return s.matchVector(ctx, combineFields(normalizedFields), entity.Vector, normalizedFields, opts)
+}
+
+// applyFellegiSunter replaces the match score with the Fellegi-Sunter posterior match probability
+func (s *Service) applyFellegiSunter(result *MatchResult, queryFields map[string]string, opts Options) {
+	fs := s.fsModel.Compare(queryFields, result.Fields, func(field string) similarity.Function {
+		simFn := s.similarityFunctionForField(field, opts)
+		for _, exactField := range opts.ForceExactMatchFields {
+			if exactField == field {
+				simFn = s.similarityReg.ExactMatch()
+				break
+			}
+		}
+		return simFn
+	})
+
+	result.Score = float32(fs.Probability)
+	result.MatchWeight = fs.Weight
+	result.Explanation = generateExplanation(result.Score, result.MatchedOn)
+
+	if result.FieldScores == nil {
+		result.FieldScores = make(map[string]FieldScore)
+	}
+	for field, level := range fs.Levels {
+		fieldScore := FieldScore{
+			Score:        float32(fs.Similarity[field]),
+			QueryValue:   queryFields[field],
+			MatchedValue: result.Fields[field],
+			Normalized:   true,
+			Level:        string(level),
+			Weight:       fs.Weights[field],
+		}
+		if fn, ok := fs.Functions[field]; ok {
+			fieldScore.SimilarityFn = fn.Name()
+		}
+		result.FieldScores[field] = fieldScore
+	}
 }
 
 // computeFieldScores calculates and adds field-level similarity scores to the match result
@@ -301,13 +656,7 @@ func (s *Service) computeFieldScores(result *MatchResult, queryFields map[string
 			}
 
 			// Get field similarity function
-			var simFn similarity.Function
-			if fieldType, ok := opts.FieldTypeMappings[fieldName]; ok {
-				simFn = s.similarityReg.GetByFieldType(fieldType)
-			} else {
-				// Infer field type from name
-				simFn = s.inferSimilarityFunction(fieldName)
-			}
+			simFn := s.similarityFunctionForField(fieldName, opts)
 
 			// Check if this field should use exact matching
 			for _, exactField := range opts.ForceExactMatchFields {
@@ -344,13 +693,7 @@ func (s *Service) computeFieldScores(result *MatchResult, queryFields map[string
 			}
 
 			// Get field similarity function
-			var simFn similarity.Function
-			if fieldType, ok := opts.FieldTypeMappings[queryField]; ok {
-				simFn = s.similarityReg.GetByFieldType(fieldType)
-			} else {
-				// Infer field type from name
-				simFn = s.inferSimilarityFunction(queryField)
-			}
+			simFn := s.similarityFunctionForField(queryField, opts)
 
 			// Check if this field should use exact matching
 			for _, exactField := range opts.ForceExactMatchFields {
@@ -384,42 +727,60 @@ func (s *Service) computeFieldScores(result *MatchResult, queryFields map[string
 	}
 }
 
-// inferSimilarityFunction infers the appropriate similarity function for a field based on its name
-func (s *Service) inferSimilarityFunction(fieldName string) similarity.Function {
-	fieldNameLower := strings.ToLower(fieldName)
-
-	// Check if the field name contains common indicators
-	if strings.Contains(fieldNameLower, "name") ||
-		strings.Contains(fieldNameLower, "company") ||
-		strings.Contains(fieldNameLower, "business") ||
-		strings.Contains(fieldNameLower, "organization") {
-		return s.similarityReg.Name()
+// similarityFunctionForField selects the similarity function for a field. Explicit
+// type mappings take precedence, then soft TF-IDF for the TF-IDF fields once statistics
+// are built, then the field type declared in the schema, and finally a guess based on
+// the field name.
+func (s *Service) similarityFunctionForField(fieldName string, opts Options) similarity.Function {
+	if fieldType, ok := opts.FieldTypeMappings[fieldName]; ok {
+		return s.similarityReg.GetByFieldType(fieldType)
+	}
+	if fn := s.tfidfFunction(fieldName); fn != nil {
+		return fn
 	}
 
-	if strings.Contains(fieldNameLower, "address") ||
-		strings.Contains(fieldNameLower, "street") {
-		return s.similarityReg.Address()
+	// Normalized values are compared like their source field
+	baseName := strings.TrimSuffix(fieldName, "_normalized")
+	for _, field := range s.schemaFields {
+		if field.Name == baseName && field.Type != "" {
+			return s.similarityReg.GetByFieldType(field.Type)
+		}
 	}
 
-	if strings.Contains(fieldNameLower, "phone") ||
-		strings.Contains(fieldNameLower, "tel") ||
-		strings.Contains(fieldNameLower, "mobile") ||
-		strings.Contains(fieldNameLower, "cell") ||
-		strings.Contains(fieldNameLower, "fax") {
-		return s.similarityReg.Phone()
+	// Infer field type from name
+	return s.inferSimilarityFunction(fieldName)
+}
+
+// validateFields checks that every input field is declared in the entity schema.
+// Precomputed "<field>_normalized" values of declared fields are also accepted.
+func (s *Service) validateFields(fields map[string]string) error {
+	if len(fields) == 0 {
+		return fmt.Errorf("%w: no fields provided", ErrInvalidEntity)
 	}
 
-	if strings.Contains(fieldNameLower, "email") {
-		return s.similarityReg.Email()
+	declared := make(map[string]bool, len(s.schemaFields))
+	for _, field := range s.schemaFields {
+		declared[field.Name] = true
 	}
 
-	if strings.Contains(fieldNameLower, "zip") ||
-		strings.Contains(fieldNameLower, "postal") {
-		return s.similarityReg.ZipCode()
+	var unknown []string
+	for name := range fields {
+		if !declared[strings.TrimSuffix(name, "_normalized")] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("%w: fields not declared in the entity schema: %s", ErrInvalidEntity, strings.Join(unknown, ", "))
 	}
 
-	// Default to generic text similarity
-	return s.similarityReg.Text()
+	return nil
+}
+
+// inferSimilarityFunction infers the appropriate similarity function for a field based on
+// its name, using the configured field patterns
+func (s *Service) inferSimilarityFunction(fieldName string) similarity.Function {
+	return s.similarityReg.GetByFieldName(fieldName)
 }
 
 // parseQueryFields attempts to parse field=value pairs from the input text
@@ -491,11 +852,13 @@ func computeWeightedScore(fieldScores map[string]FieldScore, fieldWeights map[st
 	return totalScore / totalWeight
 }
 
-// convertToWeaviateEntity converts EntityData to a Weaviate entity record
-func convertToWeaviateEntity(id string, fields map[string]string, vector []float32, metadata map[string]interface{}) *weaviate.EntityRecord {
+// convertToEntityRecord converts normalized entity fields to a store record.
+// Only fields declared in the schema, and their normalized forms, are kept.
+func (s *Service) convertToEntityRecord(id string, fields map[string]string, vector []float32, metadata map[string]interface{}) *store.EntityRecord {
 	// Create a new entity record
-	entity := &weaviate.EntityRecord{
+	entity := &store.EntityRecord{
 		ID:       id,
+		Fields:   make(map[string]string),
 		Vector:   vector,
 		Metadata: metadata,
 	}
@@ -512,103 +875,33 @@ func convertToWeaviateEntity(id string, fields map[string]string, vector []float
 		entity.Metadata["updated_at"] = now
 	}
 
-	// Map standard fields to the entity
-	if name, ok := fields["name"]; ok {
-		entity.Name = name
-	}
-	if name, ok := fields["name_normalized"]; ok {
-		entity.NameNormalized = name
-	}
-	if address, ok := fields["address"]; ok {
-		entity.Address = address
-	}
-	if address, ok := fields["address_normalized"]; ok {
-		entity.AddressNormalized = address
-	}
-	if city, ok := fields["city"]; ok {
-		entity.City = city
-	}
-	if city, ok := fields["city_normalized"]; ok {
-		entity.CityNormalized = city
-	}
-	if state, ok := fields["state"]; ok {
-		entity.State = state
-	}
-	if state, ok := fields["state_normalized"]; ok {
-		entity.StateNormalized = state
-	}
-	if zip, ok := fields["zip"]; ok {
-		entity.Zip = zip
-	}
-	if zip, ok := fields["zip_normalized"]; ok {
-		entity.ZipNormalized = zip
-	}
-	if phone, ok := fields["phone"]; ok {
-		entity.Phone = phone
-	}
-	if phone, ok := fields["phone_normalized"]; ok {
-		entity.PhoneNormalized = phone
-	}
-	if email, ok := fields["email"]; ok {
-		entity.Email = email
-	}
-	if email, ok := fields["email_normalized"]; ok {
-		entity.EmailNormalized = email
+	// Map schema fields to the entity
+	for _, field := range s.schemaFields {
+		if value, ok := fields[field.Name]; ok {
+			entity.Fields[field.Name] = value
+		}
+		if value, ok := fields[field.Name+"_normalized"]; ok {
+			entity.Fields[field.Name+"_normalized"] = value
+		}
 	}
 
 	return entity
 }
 
-// convertToMatchResult converts a Weaviate EntityRecord to a MatchResult
-func convertToMatchResult(entity *weaviate.EntityRecord, score float32) MatchResult {
-	// Create fields map from the entity's fields
-	fields := map[string]string{
-		"name":    entity.Name,
-		"address": entity.Address,
-		"city":    entity.City,
-		"state":   entity.State,
-		"zip":     entity.Zip,
-		"phone":   entity.Phone,
-		"email":   entity.Email,
-	}
-
-	// Add normalized fields if available
-	if entity.NameNormalized != "" {
-		fields["name_normalized"] = entity.NameNormalized
-	}
-	if entity.AddressNormalized != "" {
-		fields["address_normalized"] = entity.AddressNormalized
-	}
-	if entity.CityNormalized != "" {
-		fields["city_normalized"] = entity.CityNormalized
-	}
-	if entity.StateNormalized != "" {
-		fields["state_normalized"] = entity.StateNormalized
-	}
-	if entity.ZipNormalized != "" {
-		fields["zip_normalized"] = entity.ZipNormalized
-	}
-	if entity.PhoneNormalized != "" {
-		fields["phone_normalized"] = entity.PhoneNormalized
-	}
-	if entity.EmailNormalized != "" {
-		fields["email_normalized"] = entity.EmailNormalized
+// convertToMatchResult converts a stored EntityRecord to a MatchResult
+func (s *Service) convertToMatchResult(entity *store.EntityRecord, score float32) MatchResult {
+	// Create fields map from the schema fields, adding normalized values if available
+	fields := make(map[string]string, 2*len(s.schemaFields))
+	for _, field := range s.schemaFields {
+		fields[field.Name] = entity.Fields[field.Name]
+		if normalized := entity.Fields[field.Name+"_normalized"]; normalized != "" {
+			fields[field.Name+"_normalized"] = normalized
+		}
 	}
 
 	// Extract timestamps from metadata if available
-	var createdAt, updatedAt int64
-	if entity.Metadata != nil {
-		if val, ok := entity.Metadata["created_at"]; ok {
-			if timestamp, ok := val.(float64); ok {
-				createdAt = int64(timestamp)
-			}
-		}
-		if val, ok := entity.Metadata["updated_at"]; ok {
-			if timestamp, ok := val.(float64); ok {
-				updatedAt = int64(timestamp)
-			}
-		}
-	}
+	createdAt := metadataTimestamp(entity.Metadata["created_at"])
+	updatedAt := metadataTimestamp(entity.Metadata["updated_at"])
 
 	// Get matched fields for explanation
 	matchedOn := getMatchedFields(fields)
@@ -652,23 +945,44 @@ func generateExplanation(score float32, matchedFields []string) string {
 		confidence, score, strings.Join(matchedFields, ", "))
 }
 
-// combineFields concatenates field values for embedding
+// combineFields concatenates field values for embedding.
+// Fields are visited in name order so the same entity always produces the same text.
 func combineFields(fields map[string]string) string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
 	var values []string
-	for _, value := range fields {
-		if value != "" {
+	for _, name := range names {
+		if value := fields[name]; value != "" {
 			values = append(values, value)
 		}
 	}
 	return strings.Join(values, " ")
 }
 
-// RecomputeClusters recomputes clusters for all entities
+// ErrRecomputeDisabled is returned when there is nothing to recompute
+var ErrRecomputeDisabled = errors.New("neither clustering nor TF-IDF is enabled; set clustering.enabled or tfidf.enabled")
+
+// RecomputeClusters recomputes clusters for all entities. With TF-IDF scoring enabled, the
+// document frequencies are refreshed from the stored entities first; with TF-IDF enabled
+// and clustering disabled, only the statistics are refreshed.
 func (s *Service) RecomputeClusters(ctx context.Context) error {
+	if !s.cfg.Clustering.Enabled && !s.cfg.TFIDF.Enabled {
+		return ErrRecomputeDisabled
+	}
+
+	if s.cfg.TFIDF.Enabled {
+		if _, err := s.RefreshTFIDF(ctx); err != nil {
+			return fmt.Errorf("failed to refresh TF-IDF statistics: %w", err)
+		}
+	}
 	if !s.cfg.Clustering.Enabled {
-		return fmt.Errorf("clustering is not enabled in the configuration")
+		return nil
 	}
 
 	batchSize := 100 // Process entities in batches
-	return s.clusterService.RecomputeAllClusters(ctx, s.weaviateClient, batchSize)
+	return s.clusterService.RecomputeAllClusters(ctx, s.store, batchSize)
 }
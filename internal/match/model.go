@@ -0,0 +1,119 @@
+package match
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ModelVersion is the current version of the model artifact format
+const ModelVersion = 1
+
+// Model artifact types
+const (
+	ModelTypeFellegiSunter = "fellegi_sunter"
+	ModelTypeLogistic      = "logistic_regression"
+)
+
+// TrainingStats summarizes how a model was trained
+type TrainingStats struct {
+	Entities   int     `json:"entities"`             // Number of entities read from the store
+	Pairs      int     `json:"pairs"`                // Number of record pairs used for training
+	Iterations int     `json:"iterations,omitempty"` // Number of training iterations run
+	Converged  bool    `json:"converged,omitempty"`  // Whether EM converged before the iteration limit
+	Prior      float64 `json:"prior,omitempty"`      // Estimated match prior
+	L2         float64 `json:"l2,omitempty"`         // L2 regularization strength of supervised models
+
+	CrossValidation *CrossValidation `json:"cross_validation,omitempty"` // Supervised models only
+}
+
+// ModelArtifact is the versioned on-disk form of a trained scoring model
+type ModelArtifact struct {
+	Version       int                 `json:"version"`
+	Type          string              `json:"type"`
+	CreatedAt     time.Time           `json:"created_at"`
+	Stats         TrainingStats       `json:"stats"`
+	FellegiSunter *FellegiSunterModel `json:"fellegi_sunter,omitempty"`
+	Logistic      *LogisticModel      `json:"logistic,omitempty"`
+}
+
+// Validate checks that the artifact can be used by this version of Resolve
+func (a *ModelArtifact) Validate() error {
+	if a.Version != ModelVersion {
+		return fmt.Errorf("unsupported model version %d (expected %d)", a.Version, ModelVersion)
+	}
+
+	switch a.Type {
+	case ModelTypeFellegiSunter:
+		if a.FellegiSunter == nil {
+			return fmt.Errorf("model of type %s has no parameters", a.Type)
+		}
+		return a.FellegiSunter.Validate()
+	case ModelTypeLogistic:
+		if a.Logistic == nil {
+			return fmt.Errorf("model of type %s has no parameters", a.Type)
+		}
+		return a.Logistic.Validate()
+	default:
+		return fmt.Errorf("unknown model type: %s", a.Type)
+	}
+}
+
+// Save writes the artifact as indented JSON
+func (a *ModelArtifact) Save(path string) error {
+	data, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode model: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write model file: %w", err)
+	}
+
+	return nil
+}
+
+// LoadModel reads and validates a model artifact
+func LoadModel(path string) (*ModelArtifact, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read model file: %w", err)
+	}
+
+	var artifact ModelArtifact
+	if err := json.Unmarshal(data, &artifact); err != nil {
+		return nil, fmt.Errorf("failed to parse model file: %w", err)
+	}
+
+	if err := artifact.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid model file %s: %w", path, err)
+	}
+
+	return &artifact, nil
+}
+
+// UseModel makes the service score with the parameters of a trained model
+func (s *Service) UseModel(artifact *ModelArtifact) error {
+	if err := artifact.Validate(); err != nil {
+		return err
+	}
+
+	switch artifact.Type {
+	case ModelTypeFellegiSunter:
+		s.fsModel = artifact.FellegiSunter
+	case ModelTypeLogistic:
+		s.logisticModel = artifact.Logistic
+	}
+
+	return nil
+}
+
+// LoadModel loads a model artifact from disk and makes the service use it
+func (s *Service) LoadModel(path string) error {
+	artifact, err := LoadModel(path)
+	if err != nil {
+		return err
+	}
+	return s.UseModel(artifact)
+}
@@ -0,0 +1,175 @@
+package match
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/TFMV/resolve/internal/override"
+	"github.com/TFMV/resolve/internal/store"
+)
+
+// ErrOverridesDisabled is returned when no override file is configured
+var ErrOverridesDisabled = errors.New("overrides are not configured; set overrides.path")
+
+// ErrReresolveFailed is returned with an override that was recorded or removed when the
+// resolved entities of its records could not be resolved again. The override stands;
+// "resolve --recompute-resolved" brings the resolved entities up to date.
+var ErrReresolveFailed = errors.New("override saved but resolved entities were not updated")
+
+// ListOverrides returns every steward override in the order they were made
+func (s *Service) ListOverrides() ([]override.Override, error) {
+	if s.overrides == nil {
+		return nil, ErrOverridesDisabled
+	}
+	return s.overrides.List()
+}
+
+// AddOverride checks that the records of an override exist and records it. If resolution
+// is enabled, the resolved entities of the records are resolved again to honor it; if that
+// fails, the recorded override is returned with ErrReresolveFailed.
+func (s *Service) AddOverride(ctx context.Context, o override.Override) (override.Override, error) {
+	o, err := s.recordOverride(ctx, o)
+	if err != nil {
+		return override.Override{}, err
+	}
+	if err := s.reresolve(ctx, o.RecordIDs()); err != nil {
+		return o, fmt.Errorf("%w: %w", ErrReresolveFailed, err)
+	}
+	return o, nil
+}
+
+// recordOverride checks that the records of an override exist and records it without
+// resolving them again
+func (s *Service) recordOverride(ctx context.Context, o override.Override) (override.Override, error) {
+	if s.overrides == nil {
+		return override.Override{}, ErrOverridesDisabled
+	}
+	if err := o.Validate(); err != nil {
+		return override.Override{}, err
+	}
+	for _, id := range o.RecordIDs() {
+		if _, err := s.store.GetEntity(ctx, id); err != nil {
+			return override.Override{}, fmt.Errorf("%w: record %s not found", override.ErrInvalidOverride, id)
+		}
+	}
+	return s.overrides.Add(o)
+}
+
+// RemoveOverride deletes a steward override. If resolution is enabled, the resolved
+// entities of its records are resolved again without it; if that fails, the removed
+// override is returned with ErrReresolveFailed.
+func (s *Service) RemoveOverride(ctx context.Context, id string) (override.Override, error) {
+	if s.overrides == nil {
+		return override.Override{}, ErrOverridesDisabled
+	}
+	o, err := s.overrides.Remove(id)
+	if err != nil {
+		return override.Override{}, err
+	}
+	if err := s.reresolve(ctx, o.RecordIDs()); err != nil {
+		return o, fmt.Errorf("%w: %w", ErrReresolveFailed, err)
+	}
+	return o, nil
+}
+
+// constraints returns the link constraints of the steward overrides, or nil without overrides
+func (s *Service) constraints() (*override.Constraints, error) {
+	if s.overrides == nil {
+		return nil, nil
+	}
+	constraints, err := s.overrides.Constraints()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load overrides: %w", err)
+	}
+	return constraints, nil
+}
+
+// reresolve recomputes the resolved entities holding any of the records, keeping their IDs
+// by majority overlap as RecomputeResolved does. Nothing is done unless resolution is enabled.
+func (s *Service) reresolve(ctx context.Context, ids []string) error {
+	if !s.cfg.Resolution.Enabled {
+		return nil
+	}
+
+	s.resolveMutex.Lock()
+	defer s.resolveMutex.Unlock()
+
+	// Collect the records and every member of their resolved entities
+	seen := make(map[string]bool)
+	var entities []*store.EntityRecord
+	add := func(entity *store.EntityRecord) {
+		if !seen[entity.ID] {
+			seen[entity.ID] = true
+			entities = append(entities, entity)
+		}
+	}
+	for _, id := range ids {
+		entity, err := s.store.GetEntity(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve entity %s: %w", id, err)
+		}
+		add(entity)
+
+		if rid := resolvedID(entity); rid != "" {
+			members, err := s.resolvedMembers(ctx, rid)
+			if err != nil {
+				return err
+			}
+			for _, member := range members {
+				add(member)
+			}
+		}
+	}
+
+	if _, err := s.resolveComponents(ctx, entities); err != nil {
+		return fmt.Errorf("failed to re-resolve entities: %w", err)
+	}
+	return nil
+}
+
+// applyOverrides makes a match group honor the steward overrides: records must-linked to
+// the primary entity join the group, and records cannot-linked to the primary entity or
+// to one of those records leave it
+func (s *Service) applyOverrides(ctx context.Context, group *MatchGroup, primaryID string) error {
+	constraints, err := s.constraints()
+	if err != nil || constraints.Empty() {
+		return err
+	}
+	linked := constraints.Linked(primaryID)
+
+	// Drop cannot-linked records
+	anchors := append([]string{primaryID}, linked...)
+	kept := group.Entities[:0]
+	inGroup := make(map[string]bool)
+	for _, entity := range group.Entities {
+		separated := false
+		for _, anchor := range anchors {
+			if entity.ID != anchor && constraints.CannotLink(entity.ID, anchor) {
+				separated = true
+				break
+			}
+		}
+		if !separated {
+			kept = append(kept, entity)
+			inGroup[entity.ID] = true
+		}
+	}
+	group.Entities = kept
+
+	// Add must-linked records
+	for _, id := range linked {
+		if inGroup[id] {
+			continue
+		}
+		entity, err := s.store.GetEntity(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve must-linked entity %s: %w", id, err)
+		}
+		result := s.convertToMatchResult(entity, 1.0)
+		result.Explanation = "Linked by a steward override"
+		group.Entities = append(group.Entities, result)
+	}
+
+	return nil
+}
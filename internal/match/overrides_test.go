@@ -0,0 +1,110 @@
+package match
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/TFMV/resolve/internal/override"
+	"github.com/TFMV/resolve/internal/store"
+)
+
+func TestOverridesWinOverScores(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestService(t, false)
+	svc.cfg.Resolution.Enabled = true
+	svc.overrides = override.NewStore(filepath.Join(t.TempDir(), "overrides.json"))
+
+	if _, err := svc.AddEntities(ctx, testEntities); err != nil {
+		t.Fatalf("AddEntities: %v", err)
+	}
+
+	// Overrides need an author, a reason and existing records
+	if _, err := svc.AddOverride(ctx, override.Override{Kind: override.CannotLink, Records: []string{"acme-1", "acme-2"}}); !errors.Is(err, override.ErrInvalidOverride) {
+		t.Errorf("expected an invalid override without an author, got %v", err)
+	}
+	if _, err := svc.AddOverride(ctx, override.Override{Kind: override.MustLink, Records: []string{"acme-1", "missing"}, Author: "steward", Reason: "test"}); !errors.Is(err, override.ErrInvalidOverride) {
+		t.Errorf("expected an invalid override for a missing record, got %v", err)
+	}
+
+	// Separating acme-1 and acme-2 splits their resolved entity and their match group
+	cannot, err := svc.AddOverride(ctx, override.Override{Kind: override.CannotLink, Records: []string{"acme-1", "acme-2"}, Author: "steward", Reason: "different companies"})
+	if err != nil {
+		t.Fatalf("AddOverride: %v", err)
+	}
+	ids := storedResolvedIDs(t, svc)
+	if ids["acme-1"] == ids["acme-2"] {
+		t.Errorf("expected acme-1 and acme-2 to be resolved apart, got %v", ids)
+	}
+	group, err := svc.GetMatchGroup(ctx, "acme-1", MatchGroupOptions{Strategy: "transitive", ThresholdOverride: 0.95})
+	if err != nil {
+		t.Fatalf("GetMatchGroup: %v", err)
+	}
+	for _, entity := range group.Entities {
+		if entity.ID == "acme-2" {
+			t.Errorf("expected acme-2 to be dropped from the group of acme-1")
+		}
+	}
+
+	// Linking globex to acme-1 pulls it into the group and the resolved entity
+	if _, err := svc.AddOverride(ctx, override.Override{Kind: override.MustLink, Records: []string{"acme-1", "globex"}, Author: "steward", Reason: "acquired"}); err != nil {
+		t.Fatalf("AddOverride: %v", err)
+	}
+	if ids := storedResolvedIDs(t, svc); ids["globex"] != ids["acme-1"] {
+		t.Errorf("expected globex to join acme-1, got %v", ids)
+	}
+	group, err = svc.GetMatchGroup(ctx, "acme-1", MatchGroupOptions{Strategy: "transitive", ThresholdOverride: 0.95})
+	if err != nil {
+		t.Fatalf("GetMatchGroup: %v", err)
+	}
+	found := false
+	for _, entity := range group.Entities {
+		found = found || (entity.ID == "globex" && entity.Score == 1)
+	}
+	if !found {
+		t.Errorf("expected globex in the group of acme-1 with score 1, got %+v", group.Entities)
+	}
+
+	// Removing the cannot-link lets acme-2 rejoin
+	if _, err := svc.RemoveOverride(ctx, cannot.ID); err != nil {
+		t.Fatalf("RemoveOverride: %v", err)
+	}
+	if ids := storedResolvedIDs(t, svc); ids["acme-2"] != ids["acme-1"] {
+		t.Errorf("expected acme-2 to rejoin acme-1, got %v", ids)
+	}
+	if _, err := svc.RemoveOverride(ctx, cannot.ID); !errors.Is(err, override.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a removed override, got %v", err)
+	}
+}
+
+// failingUpdateStore fails every batch update
+type failingUpdateStore struct {
+	store.VectorStore
+}
+
+func (s failingUpdateStore) BatchUpdateEntities(ctx context.Context, entities []*store.EntityRecord) ([]string, error) {
+	return nil, errors.New("store unavailable")
+}
+
+func TestOverrideRecordedWhenReresolveFails(t *testing.T) {
+	ctx := context.Background()
+	svc, memStore := newTestService(t, false)
+	svc.cfg.Resolution.Enabled = true
+	svc.overrides = override.NewStore(filepath.Join(t.TempDir(), "overrides.json"))
+
+	if _, err := svc.AddEntities(ctx, testEntities); err != nil {
+		t.Fatalf("AddEntities: %v", err)
+	}
+	svc.store = failingUpdateStore{memStore}
+
+	// The override is kept and returned, with the failure reported separately
+	o, err := svc.AddOverride(ctx, override.Override{Kind: override.CannotLink, Records: []string{"acme-1", "acme-2"}, Author: "steward", Reason: "different companies"})
+	if !errors.Is(err, ErrReresolveFailed) || o.ID == "" {
+		t.Fatalf("expected a recorded override with ErrReresolveFailed, got %+v, %v", o, err)
+	}
+	if overrides, _ := svc.ListOverrides(); len(overrides) != 1 || overrides[0].ID != o.ID {
+		t.Errorf("expected the override to be recorded once, got %+v", overrides)
+	}
+
+}
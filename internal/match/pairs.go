@@ -0,0 +1,76 @@
+package match
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/TFMV/resolve/internal/store"
+)
+
+// PairScore is the match score of two stored entities
+type PairScore struct {
+	IDA   string  `json:"id_a"`
+	IDB   string  `json:"id_b"`
+	Score float32 `json:"score"`
+}
+
+// ScoreAllPairs matches every stored entity against the store and returns the IDs of
+// all entities along with each candidate pair scoring at or above opts.Threshold.
+// Pairs are reported once, with IDA < IDB, keeping the higher score when the two
+// directions differ.
+func (s *Service) ScoreAllPairs(ctx context.Context, opts Options) ([]string, []PairScore, error) {
+	entities, err := s.loadAllEntities(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ids := make([]string, 0, len(entities))
+	for _, entity := range entities {
+		ids = append(ids, entity.ID)
+	}
+
+	pairs, err := s.scorePairs(ctx, entities, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ids, pairs, nil
+}
+
+// scorePairs matches every entity against the store and returns each candidate pair
+// scoring at or above opts.Threshold, once, ordered by ID
+func (s *Service) scorePairs(ctx context.Context, entities []*store.EntityRecord, opts Options) ([]PairScore, error) {
+	scores := make(map[[2]string]float32)
+	for _, entity := range entities {
+		matches, err := s.FindMatchesForEntity(ctx, s.entityDataFromRecord(entity), opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to match entity %s: %w", entity.ID, err)
+		}
+
+		for _, m := range matches {
+			if m.ID == entity.ID {
+				continue
+			}
+			key := [2]string{entity.ID, m.ID}
+			if key[1] < key[0] {
+				key[0], key[1] = key[1], key[0]
+			}
+			if score, ok := scores[key]; !ok || m.Score > score {
+				scores[key] = m.Score
+			}
+		}
+	}
+
+	pairs := make([]PairScore, 0, len(scores))
+	for key, score := range scores {
+		pairs = append(pairs, PairScore{IDA: key[0], IDB: key[1], Score: score})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].IDA != pairs[j].IDA {
+			return pairs[i].IDA < pairs[j].IDA
+		}
+		return pairs[i].IDB < pairs[j].IDB
+	})
+
+	return pairs, nil
+}
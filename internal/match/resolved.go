@@ -0,0 +1,354 @@
+package match
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/TFMV/resolve/internal/override"
+	"github.com/TFMV/resolve/internal/store"
+	"github.com/TFMV/resolve/internal/survivorship"
+	"github.com/google/uuid"
+)
+
+// ResolvedMetadataKey is the metadata key holding the resolved entity ID of a record
+const ResolvedMetadataKey = "resolved_id"
+
+// resolveBatchSize is the page size used to read and write resolved entities
+const resolveBatchSize = 100
+
+// ResolveStats summarizes a recomputation of the resolved entities
+type ResolveStats struct {
+	Entities int `json:"entities"` // Records resolved
+	Resolved int `json:"resolved"` // Resolved entities (connected components)
+	Kept     int `json:"kept"`     // Resolved entities that kept an existing ID
+	Created  int `json:"created"`  // Resolved entities given a new ID
+	Changed  int `json:"changed"`  // Records whose resolved ID changed
+}
+
+// ResolvedEntity is a resolved entity with its member records and golden record
+type ResolvedEntity struct {
+	ID       string                     `json:"resolved_id"`
+	Size     int                        `json:"size"`
+	Golden   *survivorship.GoldenRecord `json:"golden_record"`
+	Entities []*store.EntityRecord      `json:"entities"`
+}
+
+// resolveThreshold returns the score at which a match is accepted into a resolved entity
+func (s *Service) resolveThreshold() float32 {
+	if s.cfg.Resolution.Threshold > 0 {
+		return s.cfg.Resolution.Threshold
+	}
+	if s.cfg.Review.Enabled {
+		return s.cfg.Review.AutoMatch
+	}
+	return s.cfg.Matching.SimilarityThreshold
+}
+
+// ResolveEntity assigns a stored entity to a resolved entity and returns its resolved ID.
+// The entity joins the resolved entity of its must-linked records and accepted matches; when
+// they belong to several, those are merged into the one with the most members. Resolved
+// entities holding a record cannot-linked to the entity are not joined. An entity without
+// resolved matches keeps its own resolved ID unless other records still share it, and gets
+// a new one otherwise.
+func (s *Service) ResolveEntity(ctx context.Context, id string) (string, error) {
+	s.resolveMutex.Lock()
+	defer s.resolveMutex.Unlock()
+
+	entity, err := s.store.GetEntity(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve entity %s: %w", id, err)
+	}
+	matches, err := s.matchRecord(ctx, entity, Options{Threshold: s.resolveThreshold()})
+	if err != nil {
+		return "", fmt.Errorf("failed to match entity %s: %w", id, err)
+	}
+	return s.resolveMatches(ctx, entity, matches)
+}
+
+// resolveMatches assigns a stored entity to a resolved entity given its matches, ignoring
+// those below the resolve threshold. Callers must hold the resolve lock.
+func (s *Service) resolveMatches(ctx context.Context, entity *store.EntityRecord, matches []MatchResult) (string, error) {
+	id := entity.ID
+	previous := resolvedID(entity)
+
+	constraints, err := s.constraints()
+	if err != nil {
+		return "", err
+	}
+
+	// Collect the resolved entities of the must-linked records, then of the accepted matches
+	seen := make(map[string]bool)
+	var candidates []string
+	addCandidate := func(rid string) {
+		if rid != "" && !seen[rid] {
+			seen[rid] = true
+			candidates = append(candidates, rid)
+		}
+	}
+	for _, linkedID := range constraints.Linked(id) {
+		if linked, err := s.store.GetEntity(ctx, linkedID); err == nil {
+			addCandidate(resolvedID(linked))
+		}
+	}
+	forced := len(candidates)
+
+	threshold := s.resolveThreshold()
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	for _, m := range matches {
+		if m.ID != id && m.Score >= threshold {
+			rid, _ := m.Metadata[ResolvedMetadataKey].(string)
+			addCandidate(rid)
+		}
+	}
+
+	// Join the candidates in order, skipping those holding a record cannot-linked to the
+	// entity or to an already joined candidate
+	joined := []*store.EntityRecord{entity}
+	var accepted []string
+	var groups [][]*store.EntityRecord
+	for i, rid := range candidates {
+		members, err := s.resolvedMembers(ctx, rid)
+		if err != nil {
+			return "", err
+		}
+		if i >= forced && conflicts(constraints, joined, members) {
+			continue
+		}
+		accepted = append(accepted, rid)
+		groups = append(groups, members)
+		joined = append(joined, members...)
+	}
+
+	// Without resolved matches the entity stands alone
+	if len(accepted) == 0 {
+		target := previous
+		if target != "" {
+			members, err := s.resolvedMembers(ctx, target)
+			if err != nil {
+				return "", err
+			}
+			if len(members) > 1 || (len(members) == 1 && members[0].ID != id) {
+				target = ""
+			}
+		}
+		if target == "" {
+			target = uuid.New().String()
+		}
+		return target, s.setResolvedIDs(ctx, []*store.EntityRecord{entity}, target)
+	}
+
+	// Merge into the resolved entity with the most members
+	target, largest := "", -1
+	for i, rid := range accepted {
+		if len(groups[i]) > largest || (len(groups[i]) == largest && rid < target) {
+			target, largest = rid, len(groups[i])
+		}
+	}
+
+	updates := []*store.EntityRecord{entity}
+	for i, rid := range accepted {
+		if rid == target {
+			continue
+		}
+		for _, member := range groups[i] {
+			if member.ID != id {
+				updates = append(updates, member)
+			}
+		}
+	}
+	return target, s.setResolvedIDs(ctx, updates, target)
+}
+
+// conflicts reports whether any record of a is cannot-linked to a record of b
+func conflicts(constraints *override.Constraints, a, b []*store.EntityRecord) bool {
+	if constraints.Empty() {
+		return false
+	}
+	for _, x := range a {
+		for _, y := range b {
+			if constraints.CannotLink(x.ID, y.ID) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RecomputeResolved rebuilds the resolved entities as the connected components of the
+// accepted matches between all stored entities, honoring the steward overrides. Each
+// component keeps the existing resolved ID it shares the most records with, largest
+// overlaps first, so IDs stay stable as components grow, shrink, merge or split; the
+// remaining components get new IDs.
+func (s *Service) RecomputeResolved(ctx context.Context) (*ResolveStats, error) {
+	s.resolveMutex.Lock()
+	defer s.resolveMutex.Unlock()
+
+	entities, err := s.loadAllEntities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.resolveComponents(ctx, entities)
+}
+
+// resolveComponents clusters the entities by their accepted matches among each other and the
+// steward overrides, assigns resolved IDs by majority overlap and writes back the records
+// whose resolved ID changed. Callers must hold the resolve lock.
+func (s *Service) resolveComponents(ctx context.Context, entities []*store.EntityRecord) (*ResolveStats, error) {
+	constraints, err := s.constraints()
+	if err != nil {
+		return nil, err
+	}
+	pairs, err := s.scorePairs(ctx, entities, Options{Threshold: s.resolveThreshold()})
+	if err != nil {
+		return nil, err
+	}
+
+	// Connect the accepted matches, honoring the overrides
+	ids := make([]string, len(entities))
+	byID := make(map[string]*store.EntityRecord, len(entities))
+	for i, entity := range entities {
+		ids[i] = entity.ID
+		byID[entity.ID] = entity
+	}
+	links := make([]override.Pair, len(pairs))
+	for i, pair := range pairs {
+		links[i] = override.Pair{A: pair.IDA, B: pair.IDB, Score: float64(pair.Score)}
+	}
+	components := constraints.Cluster(ids, links)
+
+	// Count the records each component shares with each existing resolved ID
+	type overlap struct {
+		component int
+		id        string
+		count     int
+	}
+	var overlaps []overlap
+	for i, members := range components {
+		counts := make(map[string]int)
+		for _, member := range members {
+			if rid := resolvedID(byID[member]); rid != "" {
+				counts[rid]++
+			}
+		}
+		for rid, count := range counts {
+			overlaps = append(overlaps, overlap{component: i, id: rid, count: count})
+		}
+	}
+	sort.Slice(overlaps, func(i, j int) bool {
+		if overlaps[i].count != overlaps[j].count {
+			return overlaps[i].count > overlaps[j].count
+		}
+		if overlaps[i].id != overlaps[j].id {
+			return overlaps[i].id < overlaps[j].id
+		}
+		return overlaps[i].component < overlaps[j].component
+	})
+
+	// Hand out existing IDs by largest overlap, each to one component
+	stats := &ResolveStats{Entities: len(entities), Resolved: len(components)}
+	assigned := make([]string, len(components))
+	used := make(map[string]bool)
+	for _, o := range overlaps {
+		if assigned[o.component] == "" && !used[o.id] {
+			assigned[o.component] = o.id
+			used[o.id] = true
+			stats.Kept++
+		}
+	}
+
+	// Write back the records whose resolved ID changed
+	var updates []*store.EntityRecord
+	for i, members := range components {
+		if assigned[i] == "" {
+			assigned[i] = uuid.New().String()
+			stats.Created++
+		}
+		for _, member := range members {
+			entity := byID[member]
+			if resolvedID(entity) != assigned[i] {
+				setResolvedID(entity, assigned[i])
+				updates = append(updates, entity)
+			}
+		}
+	}
+	stats.Changed = len(updates)
+
+	for start := 0; start < len(updates); start += resolveBatchSize {
+		end := min(start+resolveBatchSize, len(updates))
+		if _, err := s.store.BatchUpdateEntities(ctx, updates[start:end]); err != nil {
+			return nil, fmt.Errorf("failed to update resolved IDs: %w", err)
+		}
+	}
+
+	return stats, nil
+}
+
+// GetResolvedEntity retrieves the member records of a resolved entity and builds its golden record
+func (s *Service) GetResolvedEntity(ctx context.Context, resolvedID string) (*ResolvedEntity, error) {
+	members, err := s.resolvedMembers(ctx, resolvedID)
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return nil, fmt.Errorf("resolved entity not found: %s", resolvedID)
+	}
+	records := make([]survivorship.Record, len(members))
+	for i, member := range members {
+		member.Vector = nil
+		records[i] = s.survivorshipRecord(member.ID, member.Fields, member.Metadata, member.UpdatedAt)
+	}
+	golden, err := s.goldenRecord(records)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build golden record: %w", err)
+	}
+
+	return &ResolvedEntity{ID: resolvedID, Size: len(members), Golden: golden, Entities: members}, nil
+}
+
+// resolvedMembers lists the stored records carrying a resolved ID, with their vectors
+func (s *Service) resolvedMembers(ctx context.Context, resolvedID string) ([]*store.EntityRecord, error) {
+	var members []*store.EntityRecord
+	for offset := 0; ; offset += resolveBatchSize {
+		batch, err := s.store.QueryEntities(ctx, store.ListOptions{
+			Offset:        offset,
+			Limit:         resolveBatchSize,
+			Filters:       []store.Filter{{Path: "metadata." + ResolvedMetadataKey, Operator: store.OpEqual, Value: resolvedID}},
+			IncludeVector: true, // Updates replace the stored vector
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list members of resolved entity %s: %w", resolvedID, err)
+		}
+		members = append(members, batch...)
+		if len(batch) < resolveBatchSize {
+			return members, nil
+		}
+	}
+}
+
+// setResolvedIDs stores a resolved ID on every record
+func (s *Service) setResolvedIDs(ctx context.Context, entities []*store.EntityRecord, resolvedID string) error {
+	for _, entity := range entities {
+		setResolvedID(entity, resolvedID)
+	}
+	if _, err := s.store.BatchUpdateEntities(ctx, entities); err != nil {
+		return fmt.Errorf("failed to update resolved IDs: %w", err)
+	}
+	return nil
+}
+
+// resolvedID returns the resolved ID stored on a record
+func resolvedID(entity *store.EntityRecord) string {
+	rid, _ := entity.Metadata[ResolvedMetadataKey].(string)
+	return rid
+}
+
+// setResolvedID stores a resolved ID in the metadata of a record
+func setResolvedID(entity *store.EntityRecord, resolvedID string) {
+	if entity.Metadata == nil {
+		entity.Metadata = make(map[string]interface{})
+	}
+	entity.Metadata[ResolvedMetadataKey] = resolvedID
+}
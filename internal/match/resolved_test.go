@@ -0,0 +1,100 @@
+package match
+
+import (
+	"context"
+	"testing"
+)
+
+func TestResolveEntityIncremental(t *testing.T) {
+	ctx := context.Background()
+	svc, memStore := newTestService(t, false)
+	svc.cfg.Resolution.Enabled = true
+
+	if _, err := svc.AddEntities(ctx, testEntities); err != nil {
+		t.Fatalf("AddEntities: %v", err)
+	}
+	ids := storedResolvedIDs(t, svc)
+	if ids["acme-1"] == "" || ids["acme-1"] != ids["acme-2"] {
+		t.Fatalf("expected acme-1 and acme-2 to share a resolved ID, got %v", ids)
+	}
+	if ids["globex"] == "" || ids["globex"] == ids["acme-1"] {
+		t.Fatalf("expected globex to be resolved on its own, got %v", ids)
+	}
+
+	// An update that matches acme moves globex into its resolved entity
+	if err := svc.UpdateEntity(ctx, EntityData{ID: "globex", Fields: testEntities[1].Fields}); err != nil {
+		t.Fatalf("UpdateEntity: %v", err)
+	}
+	if got := storedResolvedIDs(t, svc)["globex"]; got != ids["acme-1"] {
+		t.Errorf("expected globex to join %s, got %s", ids["acme-1"], got)
+	}
+
+	resolved, err := svc.GetResolvedEntity(ctx, ids["acme-1"])
+	if err != nil {
+		t.Fatalf("GetResolvedEntity: %v", err)
+	}
+	if resolved.Size != 3 {
+		t.Errorf("expected 3 records, got %d", resolved.Size)
+	}
+
+	// The update keeps the vector and creation time
+	stored, _ := memStore.GetEntity(ctx, "globex")
+	if len(stored.Vector) == 0 || stored.CreatedAt == 0 {
+		t.Errorf("expected the updated record to keep its vector and creation time, got %+v", stored)
+	}
+}
+
+func TestRecomputeResolvedKeepsIDs(t *testing.T) {
+	ctx := context.Background()
+	svc, memStore := newTestService(t, false)
+	svc.cfg.Resolution.Enabled = true
+
+	if _, err := svc.AddEntities(ctx, testEntities); err != nil {
+		t.Fatalf("AddEntities: %v", err)
+	}
+	before := storedResolvedIDs(t, svc)
+
+	stats, err := svc.RecomputeResolved(ctx)
+	if err != nil {
+		t.Fatalf("RecomputeResolved: %v", err)
+	}
+	if stats.Resolved != 2 || stats.Kept != 2 || stats.Changed != 0 {
+		t.Errorf("expected both resolved entities to be kept unchanged, got %+v", stats)
+	}
+
+	// Wrongly linking globex to acme splits the resolved entity; acme keeps the ID
+	globex, _ := memStore.GetEntity(ctx, "globex")
+	globex.Metadata[ResolvedMetadataKey] = before["acme-1"]
+	if err := memStore.UpdateEntity(ctx, globex); err != nil {
+		t.Fatalf("UpdateEntity: %v", err)
+	}
+
+	stats, err = svc.RecomputeResolved(ctx)
+	if err != nil {
+		t.Fatalf("RecomputeResolved: %v", err)
+	}
+	if stats.Kept != 1 || stats.Created != 1 || stats.Changed != 1 {
+		t.Errorf("expected globex to split off with a new ID, got %+v", stats)
+	}
+	after := storedResolvedIDs(t, svc)
+	if after["acme-1"] != before["acme-1"] || after["acme-2"] != before["acme-1"] {
+		t.Errorf("expected acme to keep %s, got %v", before["acme-1"], after)
+	}
+	if after["globex"] == before["acme-1"] || after["globex"] == "" {
+		t.Errorf("expected globex to get a new ID, got %s", after["globex"])
+	}
+}
+
+// storedResolvedIDs returns the resolved ID of every stored entity
+func storedResolvedIDs(t *testing.T, svc *Service) map[string]string {
+	t.Helper()
+	entities, err := svc.loadAllEntities(context.Background())
+	if err != nil {
+		t.Fatalf("loadAllEntities: %v", err)
+	}
+	ids := make(map[string]string, len(entities))
+	for _, entity := range entities {
+		ids[entity.ID] = resolvedID(entity)
+	}
+	return ids
+}
@@ -0,0 +1,146 @@
+package match
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/TFMV/resolve/internal/override"
+	"github.com/TFMV/resolve/internal/review"
+)
+
+// ErrReviewDisabled is returned when the clerical review queue is not enabled
+var ErrReviewDisabled = errors.New("review is not enabled; set review.enabled")
+
+// ListReviewPairs returns the review pairs with the given status, or every pair if status is empty
+func (s *Service) ListReviewPairs(status string) ([]review.Pair, error) {
+	if s.review == nil {
+		return nil, ErrReviewDisabled
+	}
+	return s.review.List(status)
+}
+
+// DecideReviewPair accepts or rejects a pending review pair. If overrides are configured,
+// an accepted pair is recorded as a must-link and a rejected pair as a cannot-link, so the
+// decision is honored by match groups, resolution and dedupe. The override is recorded
+// while the pair is claimed, so concurrent decisions on a pair record one override. If
+// re-resolution then fails, the decided pair is returned with ErrReresolveFailed.
+func (s *Service) DecideReviewPair(ctx context.Context, id string, accept bool, decision review.Decision) (review.Pair, error) {
+	if s.review == nil {
+		return review.Pair{}, ErrReviewDisabled
+	}
+	if decision.Reviewer == "" {
+		return review.Pair{}, fmt.Errorf("%w: reviewer is required", review.ErrInvalidDecision)
+	}
+
+	// Record the decision as an override
+	var recorded *override.Override
+	pair, err := s.review.Decide(id, accept, decision, func(pair review.Pair) (string, error) {
+		if s.overrides == nil {
+			return "", nil
+		}
+		o := override.Override{
+			Kind:    override.CannotLink,
+			Records: []string{pair.A, pair.B},
+			Author:  decision.Reviewer,
+			Reason:  decision.Reason,
+		}
+		if accept {
+			o.Kind = override.MustLink
+		}
+		if o.Reason == "" {
+			o.Reason = fmt.Sprintf("Decided in clerical review of pair %s", pair.ID)
+		}
+		o, err := s.recordOverride(ctx, o)
+		if err != nil {
+			return "", fmt.Errorf("failed to record review decision: %w", err)
+		}
+		recorded = &o
+		return o.ID, nil
+	})
+	if err != nil {
+		return review.Pair{}, err
+	}
+
+	// Resolve the records again outside the review lock
+	if recorded != nil {
+		if err := s.reresolve(ctx, recorded.RecordIDs()); err != nil {
+			return pair, fmt.Errorf("%w: %w", ErrReresolveFailed, err)
+		}
+	}
+	return pair, nil
+}
+
+// ExportReviewLabels writes the decided review pairs as labeled training pairs
+func (s *Service) ExportReviewLabels(w io.Writer) error {
+	if s.review == nil {
+		return ErrReviewDisabled
+	}
+	pairs, err := s.review.List("")
+	if err != nil {
+		return err
+	}
+	return review.WriteLabels(w, pairs)
+}
+
+// reviewThreshold returns the auto-reject score below which matches are not queued for review
+func (s *Service) reviewThreshold() float32 {
+	if s.cfg.Review.AutoReject > 0 {
+		return s.cfg.Review.AutoReject
+	}
+	return s.cfg.Matching.SimilarityThreshold
+}
+
+// queueReview queues the matches of a stored entity that score between the auto-reject and
+// auto-match thresholds. Pairs already decided by an override are skipped. Nothing is done
+// unless review is enabled.
+func (s *Service) queueReview(id string, matches []MatchResult) error {
+	if s.review == nil {
+		return nil
+	}
+
+	constraints, err := s.constraints()
+	if err != nil {
+		return err
+	}
+	linked := make(map[string]bool)
+	for _, linkedID := range constraints.Linked(id) {
+		linked[linkedID] = true
+	}
+
+	var pairs []review.Pair
+	for _, m := range matches {
+		if m.ID == id || m.Score < s.reviewThreshold() || m.Score >= s.cfg.Review.AutoMatch || linked[m.ID] || constraints.CannotLink(id, m.ID) {
+			continue
+		}
+		pairs = append(pairs, review.Pair{A: id, B: m.ID, Score: m.Score, Fields: s.fieldDiffs(m.FieldScores)})
+	}
+	if len(pairs) == 0 {
+		return nil
+	}
+
+	if _, err := s.review.Enqueue(pairs); err != nil {
+		return fmt.Errorf("failed to queue pairs for review: %w", err)
+	}
+	return nil
+}
+
+// fieldDiffs turns the field scores of a match into the field diffs of a review pair,
+// keeping schema fields only
+func (s *Service) fieldDiffs(scores map[string]FieldScore) map[string]review.FieldDiff {
+	diffs := make(map[string]review.FieldDiff)
+	for _, field := range s.schemaFields {
+		score, ok := scores[field.Name]
+		if !ok {
+			continue
+		}
+		diffs[field.Name] = review.FieldDiff{
+			A:       score.QueryValue,
+			B:       score.MatchedValue,
+			Score:   score.Score,
+			Differs: score.Score < 1,
+		}
+	}
+	return diffs
+}
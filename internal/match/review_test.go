@@ -0,0 +1,105 @@
+package match
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/TFMV/resolve/internal/embed"
+	"github.com/TFMV/resolve/internal/override"
+	"github.com/TFMV/resolve/internal/review"
+)
+
+// countingEmbedder counts the texts it embeds
+type countingEmbedder struct {
+	embed.EmbeddingService
+	calls int
+}
+
+func (c *countingEmbedder) GetEmbedding(ctx context.Context, text string) ([]float32, error) {
+	c.calls++
+	return c.EmbeddingService.GetEmbedding(ctx, text)
+}
+
+func (c *countingEmbedder) GetEmbeddingBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	c.calls += len(texts)
+	return c.EmbeddingService.GetEmbeddingBatch(ctx, texts)
+}
+
+func TestReviewQueue(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestService(t, false)
+	dir := t.TempDir()
+	svc.cfg.Resolution.Enabled = true
+	svc.cfg.Review.Enabled = true
+	svc.cfg.Review.AutoMatch = 0.95
+	svc.cfg.Review.AutoReject = 0.8
+	svc.review = review.NewStore(filepath.Join(dir, "review.json"))
+	svc.overrides = override.NewStore(filepath.Join(dir, "overrides.json"))
+	embedder := &countingEmbedder{EmbeddingService: svc.embeddingService}
+	svc.embeddingService = embedder
+
+	if _, err := svc.AddEntities(ctx, testEntities); err != nil {
+		t.Fatalf("AddEntities: %v", err)
+	}
+
+	// Resolution and review share a match pass on the stored vectors
+	if embedder.calls != len(testEntities) {
+		t.Errorf("expected one embedding per entity, got %d", embedder.calls)
+	}
+
+	// The acme records match outright; both acme/globex pairs are borderline
+	pending, err := svc.ListReviewPairs(review.Pending)
+	if err != nil {
+		t.Fatalf("ListReviewPairs: %v", err)
+	}
+	if len(pending) != 2 {
+		t.Fatalf("expected 2 pending pairs, got %+v", pending)
+	}
+	for _, pair := range pending {
+		if pair.A != "globex" && pair.B != "globex" {
+			t.Errorf("expected only pairs with globex, got %s/%s", pair.A, pair.B)
+		}
+		if diff := pair.Fields["zip"]; !diff.Differs || diff.A == "" || diff.B == "" {
+			t.Errorf("expected a zip diff, got %+v", pair.Fields)
+		}
+	}
+
+	// Accepting a pair must-links it, so globex joins acme
+	if _, err := svc.DecideReviewPair(ctx, pending[0].ID, true, review.Decision{}); !errors.Is(err, review.ErrInvalidDecision) {
+		t.Errorf("expected an invalid decision without a reviewer, got %v", err)
+	}
+	accepted, err := svc.DecideReviewPair(ctx, pending[0].ID, true, review.Decision{Reviewer: "steward"})
+	if err != nil {
+		t.Fatalf("DecideReviewPair: %v", err)
+	}
+	if accepted.Status != review.Accepted || accepted.OverrideID == "" {
+		t.Errorf("expected an accepted pair with an override, got %+v", accepted)
+	}
+	if ids := storedResolvedIDs(t, svc); ids["globex"] != ids["acme-1"] {
+		t.Errorf("expected globex to join acme, got %v", ids)
+	}
+	if _, err := svc.DecideReviewPair(ctx, pending[0].ID, false, review.Decision{Reviewer: "steward"}); !errors.Is(err, review.ErrAlreadyDecided) {
+		t.Errorf("expected ErrAlreadyDecided, got %v", err)
+	}
+
+	// Decided pairs are not queued again
+	if err := svc.UpdateEntity(ctx, EntityData{ID: "globex", Fields: testEntities[2].Fields}); err != nil {
+		t.Fatalf("UpdateEntity: %v", err)
+	}
+	all, _ := svc.ListReviewPairs("")
+	if len(all) != 2 {
+		t.Errorf("expected 2 review pairs, got %d", len(all))
+	}
+
+	var labels bytes.Buffer
+	if err := svc.ExportReviewLabels(&labels); err != nil {
+		t.Fatalf("ExportReviewLabels: %v", err)
+	}
+	pairs, err := ReadLabeledPairs(&labels)
+	if err != nil || len(pairs) != 1 || !pairs[0].Match {
+		t.Errorf("expected one matching labeled pair, got %+v (%v)", pairs, err)
+	}
+}
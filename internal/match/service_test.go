@@ -0,0 +1,259 @@
+package match
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/TFMV/resolve/internal/cluster"
+	"github.com/TFMV/resolve/internal/config"
+	"github.com/TFMV/resolve/internal/embed"
+	"github.com/TFMV/resolve/internal/store"
+)
+
+func newTestService(t *testing.T, clustering bool) (*Service, *store.MemoryStore) {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.Matching.SimilarityThreshold = 0.95
+	cfg.Matching.DefaultLimit = 10
+	cfg.Normalization.EnableLowercase = true
+	cfg.Normalization.NameOptions = map[string]bool{"remove_legal_suffixes": true}
+	cfg.Clustering.Enabled = clustering
+	cfg.Clustering.Method = "canopy"
+	cfg.Clustering.Fields = []string{"name", "zip"}
+
+	memStore := store.NewMemoryStore()
+	svc, err := NewService(cfg, memStore, embed.NewMockEmbeddingService(32))
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	return svc, memStore
+}
+
+var testEntities = []EntityData{
+	{ID: "acme-1", Fields: map[string]string{"name": "Acme Corporation", "address": "123 Main Street", "zip": "10001"}},
+	{ID: "acme-2", Fields: map[string]string{"name": "Acme Corp", "address": "123 Main Street", "zip": "10001"}},
+	{ID: "globex", Fields: map[string]string{"name": "Globex", "address": "9 Elm Road", "zip": "94105"}},
+}
+
+func TestServiceFindMatchesInMemory(t *testing.T) {
+	ctx := context.Background()
+	svc, memStore := newTestService(t, false)
+
+	if _, err := svc.AddEntities(ctx, testEntities); err != nil {
+		t.Fatalf("AddEntities: %v", err)
+	}
+	if count, _ := memStore.GetCount(ctx); count != len(testEntities) {
+		t.Fatalf("expected %d stored entities, got %d", len(testEntities), count)
+	}
+
+	matches, err := svc.FindMatchesForEntity(ctx, testEntities[0], Options{})
+	if err != nil {
+		t.Fatalf("FindMatchesForEntity: %v", err)
+	}
+	if len(matches) == 0 || matches[0].ID != "acme-1" {
+		t.Fatalf("expected acme-1 as best match, got %+v", matches)
+	}
+	for _, m := range matches {
+		if m.ID == "globex" {
+			t.Errorf("globex should not match acme above threshold (score %.2f)", m.Score)
+		}
+	}
+
+	group, err := svc.GetMatchGroup(ctx, "acme-1", MatchGroupOptions{Strategy: "transitive", ThresholdOverride: 0.95})
+	if err != nil {
+		t.Fatalf("GetMatchGroup: %v", err)
+	}
+	ids := make(map[string]bool)
+	for _, e := range group.Entities {
+		ids[e.ID] = true
+	}
+	if !ids["acme-1"] || !ids["acme-2"] || ids["globex"] {
+		t.Errorf("unexpected group members: %v", ids)
+	}
+}
+
+func TestServiceRecomputeClustersInMemory(t *testing.T) {
+	ctx := context.Background()
+	svc, memStore := newTestService(t, true)
+
+	if _, err := svc.AddEntities(ctx, testEntities); err != nil {
+		t.Fatalf("AddEntities: %v", err)
+	}
+	if err := svc.RecomputeClusters(ctx); err != nil {
+		t.Fatalf("RecomputeClusters: %v", err)
+	}
+
+	a, _ := memStore.GetEntity(ctx, "acme-1")
+	b, _ := memStore.GetEntity(ctx, "acme-2")
+	c, _ := memStore.GetEntity(ctx, "globex")
+	clusterA := a.Metadata[cluster.ClusterMetadataKey]
+	if clusterA == nil || clusterA != b.Metadata[cluster.ClusterMetadataKey] {
+		t.Errorf("expected acme records to share a cluster, got %v and %v", clusterA, b.Metadata[cluster.ClusterMetadataKey])
+	}
+	if clusterA == c.Metadata[cluster.ClusterMetadataKey] {
+		t.Errorf("expected globex in a different cluster")
+	}
+}
+
+// failingAddStore fails every add
+type failingAddStore struct {
+	store.VectorStore
+}
+
+func (s failingAddStore) AddEntity(ctx context.Context, entity *store.EntityRecord) (string, error) {
+	return "", errors.New("store unavailable")
+}
+
+func TestServiceDropsCanopyWhenAddFails(t *testing.T) {
+	ctx := context.Background()
+	svc, memStore := newTestService(t, true)
+
+	svc.store = failingAddStore{memStore}
+	if _, err := svc.AddEntity(ctx, testEntities[0]); err == nil {
+		t.Fatal("expected the add to fail")
+	}
+
+	// The failed add left no canopy behind, so the entity starts its own
+	svc.store = memStore
+	if _, err := svc.AddEntity(ctx, testEntities[0]); err != nil {
+		t.Fatalf("AddEntity: %v", err)
+	}
+	entity, _ := memStore.GetEntity(ctx, testEntities[0].ID)
+	if entity.Metadata[cluster.CanopyCenterMetadataKey] == nil {
+		t.Errorf("expected the entity to be a canopy center, got %v", entity.Metadata)
+	}
+}
+
+func TestNewServiceRejectsInvalidSimilarity(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Similarity.NameBlend = "no_such_comparator"
+	if _, err := NewService(cfg, store.NewMemoryStore(), embed.NewMockEmbeddingService(32)); err == nil {
+		t.Error("expected an error for a name blend that does not compile")
+	}
+}
+
+func TestServiceCustomSchema(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{}
+	cfg.Matching.SimilarityThreshold = 0.95
+	cfg.Matching.DefaultLimit = 10
+	cfg.Schema.Fields = []config.FieldConfig{
+		{Name: "name", Type: "name"},
+		{Name: "tax_id", Type: "identifier"},
+	}
+	memStore := store.NewMemoryStore()
+	svc, err := NewService(cfg, memStore, embed.NewMockEmbeddingService(32))
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+
+	person := EntityData{ID: "p1", Fields: map[string]string{"name": "Jane Doe", "tax_id": "12-345 678"}}
+	if _, err := svc.AddEntity(ctx, person); err != nil {
+		t.Fatalf("AddEntity: %v", err)
+	}
+
+	stored, err := memStore.GetEntity(ctx, "p1")
+	if err != nil {
+		t.Fatalf("GetEntity: %v", err)
+	}
+	if stored.Fields["tax_id"] != "12-345 678" || stored.Fields["tax_id_normalized"] != "12345678" {
+		t.Errorf("custom field not stored: %+v", stored.Fields)
+	}
+
+	matches, err := svc.FindMatchesForEntity(ctx, person, Options{IncludeFieldScores: true})
+	if err != nil || len(matches) == 0 {
+		t.Fatalf("FindMatchesForEntity: matches=%v err=%v", matches, err)
+	}
+	if _, ok := matches[0].Fields["tax_id"]; !ok {
+		t.Errorf("custom field missing from match result: %+v", matches[0].Fields)
+	}
+	if fn := matches[0].FieldScores["tax_id"].SimilarityFn; fn != "ExactMatch" {
+		t.Errorf("expected exact match similarity for identifier field, got %q", fn)
+	}
+
+	_, err = svc.AddEntity(ctx, EntityData{Fields: map[string]string{"name": "John", "website": "example.com"}})
+	if !errors.Is(err, ErrInvalidEntity) {
+		t.Errorf("expected error for field not declared in the schema")
+	}
+}
+
+func TestServiceScoreAllPairs(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestService(t, false)
+
+	if _, err := svc.AddEntities(ctx, testEntities); err != nil {
+		t.Fatalf("AddEntities: %v", err)
+	}
+
+	ids, pairs, err := svc.ScoreAllPairs(ctx, Options{Threshold: 0.01, Limit: 10})
+	if err != nil {
+		t.Fatalf("ScoreAllPairs: %v", err)
+	}
+	if len(ids) != len(testEntities) {
+		t.Errorf("expected %d IDs, got %d", len(testEntities), len(ids))
+	}
+
+	seen := make(map[[2]string]bool)
+	for _, pair := range pairs {
+		if pair.IDA >= pair.IDB {
+			t.Errorf("pair not ordered: %+v", pair)
+		}
+		if seen[[2]string{pair.IDA, pair.IDB}] {
+			t.Errorf("duplicate pair: %+v", pair)
+		}
+		seen[[2]string{pair.IDA, pair.IDB}] = true
+	}
+	if !seen[[2]string{"acme-1", "acme-2"}] {
+		t.Errorf("expected acme-1/acme-2 among scored pairs, got %+v", pairs)
+	}
+
+	// NoThreshold keeps pairs below the configured similarity threshold
+	_, all, err := svc.ScoreAllPairs(ctx, Options{Threshold: NoThreshold, Limit: 10})
+	if err != nil {
+		t.Fatalf("ScoreAllPairs: %v", err)
+	}
+	below := false
+	for _, pair := range all {
+		below = below || pair.Score < svc.cfg.Matching.SimilarityThreshold
+	}
+	if len(all) != 3 || !below {
+		t.Errorf("expected every pair including those below the threshold, got %+v", all)
+	}
+}
+
+func TestServiceMultiPassBlocking(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{}
+	cfg.Matching.SimilarityThreshold = 0.1
+	cfg.Matching.DefaultLimit = 10
+	cfg.Normalization.EnableLowercase = true
+	cfg.Clustering.Enabled = true
+	cfg.Clustering.Fields = []string{"name", "zip"}
+	cfg.Clustering.Rules = []config.BlockingRule{
+		{Name: "name_zip", Fields: []config.BlockingField{{Field: "name", Transform: "prefix", Length: 3}, {Field: "zip"}}},
+		{Name: "phone", Fields: []config.BlockingField{{Field: "phone", Transform: "last_digits", Length: 7}}},
+	}
+	svc, err := NewService(cfg, store.NewMemoryStore(), embed.NewMockEmbeddingService(32))
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+
+	stored := []EntityData{
+		{ID: "acme", Fields: map[string]string{"name": "Acme Corporation", "zip": "10001", "phone": "555-123-4567"}},
+		{ID: "other", Fields: map[string]string{"name": "Zenith Labs", "zip": "94105", "phone": "555-999-0000"}},
+	}
+	if _, err := svc.AddEntities(ctx, stored); err != nil {
+		t.Fatalf("AddEntities: %v", err)
+	}
+
+	// A typo in the name prefix and a different zip still share the phone block
+	query := EntityData{Fields: map[string]string{"name": "Akme Corporation", "zip": "10002", "phone": "(555) 123-4567"}}
+	matches, err := svc.FindMatchesForEntity(ctx, query, Options{})
+	if err != nil {
+		t.Fatalf("FindMatchesForEntity: %v", err)
+	}
+	if len(matches) != 1 || matches[0].ID != "acme" {
+		t.Errorf("expected only acme via the phone pass, got %+v", matches)
+	}
+}
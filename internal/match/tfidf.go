@@ -0,0 +1,80 @@
+package match
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/TFMV/resolve/internal/similarity"
+)
+
+// RefreshTFIDF rebuilds the document frequencies of the TF-IDF fields from every stored
+// entity, saves them to the configured path and starts scoring with them
+func (s *Service) RefreshTFIDF(ctx context.Context) (*similarity.TFIDFStats, error) {
+	if !s.cfg.TFIDF.Enabled {
+		return nil, fmt.Errorf("TF-IDF scoring is not enabled; set tfidf.enabled")
+	}
+
+	entities, err := s.loadAllEntities(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Count tokens of the normalized values, which is what matching compares
+	records := make([]map[string]string, len(entities))
+	for i, entity := range entities {
+		record := make(map[string]string, len(s.cfg.TFIDF.Fields))
+		for _, field := range s.cfg.TFIDF.Fields {
+			value := entity.Fields[field+"_normalized"]
+			if value == "" {
+				value = entity.Fields[field]
+			}
+			record[field] = value
+		}
+		records[i] = record
+	}
+	stats := similarity.BuildTFIDFStats(records, s.cfg.TFIDF.Fields)
+
+	if s.cfg.TFIDF.Path != "" {
+		if err := stats.Save(s.cfg.TFIDF.Path); err != nil {
+			return nil, err
+		}
+	}
+	s.UseTFIDF(stats)
+	return stats, nil
+}
+
+// LoadTFIDF loads TF-IDF statistics saved by RefreshTFIDF. Until statistics exist, the
+// TF-IDF fields keep the comparator of their type.
+func (s *Service) LoadTFIDF(path string) error {
+	stats, err := similarity.LoadTFIDFStats(path)
+	if err != nil {
+		return err
+	}
+	if stats != nil {
+		s.UseTFIDF(stats)
+	}
+	return nil
+}
+
+// UseTFIDF makes the service score the TF-IDF fields with soft TF-IDF under the given statistics
+func (s *Service) UseTFIDF(stats *similarity.TFIDFStats) {
+	functions := make(map[string]similarity.Function, len(s.cfg.TFIDF.Fields))
+	for _, field := range s.cfg.TFIDF.Fields {
+		if fn := stats.Function(field, s.cfg.TFIDF.Threshold); fn != nil {
+			functions[field] = fn
+		}
+	}
+
+	s.tfidfMutex.Lock()
+	defer s.tfidfMutex.Unlock()
+	s.tfidf = functions
+}
+
+// tfidfFunction returns the soft TF-IDF comparator of a field, or nil if the field is not
+// scored with TF-IDF or has no statistics yet
+func (s *Service) tfidfFunction(fieldName string) similarity.Function {
+	s.tfidfMutex.RLock()
+	defer s.tfidfMutex.RUnlock()
+	return s.tfidf[strings.TrimSuffix(fieldName, "_normalized")]
+}
@@ -0,0 +1,70 @@
+package match
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestRefreshTFIDF(t *testing.T) {
+	ctx := context.Background()
+	svc, memStore := newTestService(t, true)
+	path := filepath.Join(t.TempDir(), "tfidf.json")
+	svc.cfg.TFIDF.Enabled = true
+	svc.cfg.TFIDF.Fields = []string{"name"}
+	svc.cfg.TFIDF.Path = path
+
+	if _, err := svc.AddEntities(ctx, testEntities); err != nil {
+		t.Fatalf("AddEntities: %v", err)
+	}
+	if got := svc.similarityFunctionForField("name", Options{}).Name(); got != "NameSimilarity" {
+		t.Errorf("expected the type comparator before statistics are built, got %s", got)
+	}
+
+	// Recomputing clusters refreshes and saves the statistics
+	if err := svc.RecomputeClusters(ctx); err != nil {
+		t.Fatalf("RecomputeClusters: %v", err)
+	}
+	for _, field := range []string{"name", "name_normalized"} {
+		if got := svc.similarityFunctionForField(field, Options{}).Name(); got != "SoftTFIDF" {
+			t.Errorf("expected soft TF-IDF for %s, got %s", field, got)
+		}
+	}
+	if got := svc.similarityFunctionForField("address", Options{}).Name(); got != "AddressSimilarity" {
+		t.Errorf("expected fields outside tfidf.fields to keep their comparator, got %s", got)
+	}
+
+	// A new service picks up the saved statistics
+	other, err := NewService(svc.cfg, memStore, svc.embeddingService)
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+	if err := other.LoadTFIDF(path); err != nil {
+		t.Fatalf("LoadTFIDF: %v", err)
+	}
+	if got := other.similarityFunctionForField("name", Options{}).Name(); got != "SoftTFIDF" {
+		t.Errorf("expected soft TF-IDF after loading, got %s", got)
+	}
+}
+
+func TestRecomputeClustersRefreshesTFIDFWithoutClustering(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestService(t, false)
+	if err := svc.RecomputeClusters(ctx); !errors.Is(err, ErrRecomputeDisabled) {
+		t.Errorf("expected ErrRecomputeDisabled without clustering or TF-IDF, got %v", err)
+	}
+
+	svc.cfg.TFIDF.Enabled = true
+	svc.cfg.TFIDF.Fields = []string{"name"}
+	svc.cfg.TFIDF.Path = filepath.Join(t.TempDir(), "tfidf.json")
+	if _, err := svc.AddEntities(ctx, testEntities); err != nil {
+		t.Fatalf("AddEntities: %v", err)
+	}
+	if err := svc.RecomputeClusters(ctx); err != nil {
+		t.Fatalf("RecomputeClusters: %v", err)
+	}
+	if got := svc.similarityFunctionForField("name", Options{}).Name(); got != "SoftTFIDF" {
+		t.Errorf("expected soft TF-IDF after a TF-IDF only recompute, got %s", got)
+	}
+}
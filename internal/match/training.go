@@ -0,0 +1,335 @@
+package match
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/TFMV/resolve/internal/similarity"
+	"github.com/TFMV/resolve/internal/store"
+)
+
+// Training defaults
+const (
+	DefaultTrainMaxPairs      = 100000
+	DefaultTrainMaxIterations = 100
+	DefaultTrainTolerance     = 1e-4
+	defaultTrainBatchSize     = 100
+	emPseudoCount             = 0.1 // Smoothing added to every level count in the M step
+)
+
+// TrainOptions controls model training
+type TrainOptions struct {
+	MaxPairs      int     // Maximum number of blocked pairs to sample
+	RandomPairs   int     // Number of random pairs added to represent non-matches (defaults to the blocked pair count)
+	MaxIterations int     // Maximum number of EM iterations
+	Tolerance     float64 // EM stops once no parameter changes by more than this
+	Seed          int64   // Seed for pair sampling
+}
+
+// ComparisonVector holds the comparison level of every model field for a record pair
+type ComparisonVector map[string]ComparisonLevel
+
+// recordPair identifies two records by their index in the training set
+type recordPair struct {
+	a, b int
+}
+
+// TrainFellegiSunter estimates Fellegi-Sunter m/u parameters and the match prior from
+// the stored corpus with expectation-maximization. Candidate pairs are sampled within
+// the blocks produced by the cluster service and mixed with random pairs, so no labeled
+// data is required.
+func (s *Service) TrainFellegiSunter(ctx context.Context, opts TrainOptions) (*ModelArtifact, error) {
+	// Apply defaults
+	if opts.MaxPairs <= 0 {
+		opts.MaxPairs = DefaultTrainMaxPairs
+	}
+	if opts.MaxIterations <= 0 {
+		opts.MaxIterations = DefaultTrainMaxIterations
+	}
+	if opts.Tolerance <= 0 {
+		opts.Tolerance = DefaultTrainTolerance
+	}
+
+	// Load the corpus
+	entities, err := s.loadAllEntities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(entities) < 2 {
+		return nil, fmt.Errorf("at least 2 entities are required for training, found %d", len(entities))
+	}
+
+	// Sample candidate pairs from the blocks plus random pairs
+	rng := rand.New(rand.NewSource(opts.Seed))
+	pairs := s.sampleBlockedPairs(ctx, entities, opts.MaxPairs, rng)
+	randomPairs := opts.RandomPairs
+	if randomPairs <= 0 {
+		randomPairs = len(pairs)
+	}
+	pairs = append(pairs, sampleRandomPairs(len(entities), randomPairs, rng)...)
+
+	// Build comparison vectors
+	init := DefaultFellegiSunterModel(s.schemaFields)
+	vectors := make([]ComparisonVector, len(pairs))
+	for i, pair := range pairs {
+		vectors[i] = s.CompareRecords(init, entities[pair.a].Fields, entities[pair.b].Fields)
+	}
+
+	// Estimate parameters
+	model, iterations, converged := EstimateFellegiSunter(vectors, init, opts.MaxIterations, opts.Tolerance)
+
+	return &ModelArtifact{
+		Version:   ModelVersion,
+		Type:      ModelTypeFellegiSunter,
+		CreatedAt: time.Now().UTC(),
+		Stats: TrainingStats{
+			Entities:   len(entities),
+			Pairs:      len(pairs),
+			Iterations: iterations,
+			Converged:  converged,
+			Prior:      model.Prior,
+		},
+		FellegiSunter: model,
+	}, nil
+}
+
+// CompareRecords returns the comparison vector of two records' fields under the model
+func (s *Service) CompareRecords(model *FellegiSunterModel, a, b map[string]string) ComparisonVector {
+	result := model.Compare(a, b, func(field string) similarity.Function {
+		return s.similarityFunctionForField(field, Options{})
+	})
+	return ComparisonVector(result.Levels)
+}
+
+// loadAllEntities reads every entity from the store in batches
+func (s *Service) loadAllEntities(ctx context.Context) ([]*store.EntityRecord, error) {
+	var entities []*store.EntityRecord
+	err := store.ScanEntities(ctx, s.store, store.ListOptions{Limit: defaultTrainBatchSize, IncludeVector: true}, func(batch []*store.EntityRecord) error {
+		entities = append(entities, batch...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list entities: %w", err)
+	}
+	return entities, nil
+}
+
+// sampleBlockedPairs samples up to maxPairs record pairs that share a blocking key in any pass
+func (s *Service) sampleBlockedPairs(ctx context.Context, entities []*store.EntityRecord, maxPairs int, rng *rand.Rand) []recordPair {
+	// Group records by the key of every blocking pass
+	blocks := make(map[string][]int)
+	for i, entity := range entities {
+		for pass, key := range s.clusterService.BlockingKeys(ctx, entity.Fields) {
+			blocks[pass+"="+key] = append(blocks[pass+"="+key], i)
+		}
+	}
+
+	// Visit blocks in a stable order so sampling is reproducible for a given seed
+	keys := make([]string, 0, len(blocks))
+	for key := range blocks {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// Reservoir sample over the pairs of every block
+	pairs := make([]recordPair, 0)
+	seen := 0
+	add := func(pair recordPair) {
+		seen++
+		if len(pairs) < maxPairs {
+			pairs = append(pairs, pair)
+		} else if j := rng.Intn(seen); j < maxPairs {
+			pairs[j] = pair
+		}
+	}
+
+	for _, key := range keys {
+		members := blocks[key]
+		n := len(members)
+		if n < 2 {
+			continue
+		}
+
+		// Oversized blocks are sampled rather than enumerated
+		if n*(n-1)/2 > maxPairs {
+			for _, pair := range sampleRandomPairs(n, maxPairs, rng) {
+				add(recordPair{a: members[pair.a], b: members[pair.b]})
+			}
+			continue
+		}
+
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				add(recordPair{a: members[i], b: members[j]})
+			}
+		}
+	}
+
+	return pairs
+}
+
+// sampleRandomPairs draws count random pairs of distinct indexes below n
+func sampleRandomPairs(n, count int, rng *rand.Rand) []recordPair {
+	pairs := make([]recordPair, 0, count)
+	if n < 2 {
+		return pairs
+	}
+	for len(pairs) < count {
+		a, b := rng.Intn(n), rng.Intn(n)
+		if a != b {
+			pairs = append(pairs, recordPair{a: a, b: b})
+		}
+	}
+	return pairs
+}
+
+// EstimateFellegiSunter runs expectation-maximization over comparison vectors, starting
+// from the parameters of init. It returns the fitted model, the number of iterations run
+// and whether the parameters converged within tolerance.
+func EstimateFellegiSunter(vectors []ComparisonVector, init *FellegiSunterModel, maxIterations int, tolerance float64) (*FellegiSunterModel, int, bool) {
+	model := copyFellegiSunterModel(init)
+	if len(vectors) == 0 {
+		return model, 0, false
+	}
+
+	fields := make([]string, 0, len(model.Fields))
+	for field := range model.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	// Identical comparison vectors are processed once, weighted by their count
+	patterns := make(map[string]ComparisonVector)
+	counts := make(map[string]float64)
+	for _, vector := range vectors {
+		var key strings.Builder
+		for _, field := range fields {
+			key.WriteString(string(vector[field]))
+			key.WriteByte('|')
+		}
+		patterns[key.String()] = vector
+		counts[key.String()]++
+	}
+
+	if model.Prior <= 0 || model.Prior >= 1 {
+		model.Prior = 0.1
+	}
+
+	iteration := 0
+	converged := false
+	for iteration < maxIterations && !converged {
+		iteration++
+
+		// E step: expected match membership of each pattern
+		mCounts := make(map[string]map[ComparisonLevel]float64, len(fields))
+		uCounts := make(map[string]map[ComparisonLevel]float64, len(fields))
+		for _, field := range fields {
+			mCounts[field] = make(map[ComparisonLevel]float64)
+			uCounts[field] = make(map[ComparisonLevel]float64)
+		}
+
+		var matchMass float64
+		for key, vector := range patterns {
+			count := counts[key]
+			logM := math.Log(model.Prior)
+			logU := math.Log(1 - model.Prior)
+			for _, field := range fields {
+				level := vector[field]
+				if level == "" || level == LevelMissing {
+					continue
+				}
+				probs := model.Fields[field]
+				logM += math.Log(math.Max(probs.M[level], probabilityFloor))
+				logU += math.Log(math.Max(probs.U[level], probabilityFloor))
+			}
+			g := 1 / (1 + math.Exp(logU-logM))
+
+			matchMass += count * g
+			for _, field := range fields {
+				level := vector[field]
+				if level == "" || level == LevelMissing {
+					continue
+				}
+				mCounts[field][level] += count * g
+				uCounts[field][level] += count * (1 - g)
+			}
+		}
+
+		// M step: re-estimate the prior and the level probabilities
+		maxChange := 0.0
+		prior := math.Min(math.Max(matchMass/float64(len(vectors)), probabilityFloor), 1-probabilityFloor)
+		maxChange = math.Max(maxChange, math.Abs(prior-model.Prior))
+		model.Prior = prior
+
+		for _, field := range fields {
+			probs := model.Fields[field]
+			maxChange = math.Max(maxChange, updateLevelProbabilities(probs.M, mCounts[field]))
+			maxChange = math.Max(maxChange, updateLevelProbabilities(probs.U, uCounts[field]))
+		}
+
+		converged = maxChange < tolerance
+	}
+
+	orientModel(model)
+	return model, iteration, converged
+}
+
+// updateLevelProbabilities replaces probs with smoothed level frequencies and returns the largest change
+func updateLevelProbabilities(probs map[ComparisonLevel]float64, counts map[ComparisonLevel]float64) float64 {
+	total := 0.0
+	for _, level := range ComparisonLevels {
+		total += counts[level] + emPseudoCount
+	}
+
+	maxChange := 0.0
+	for _, level := range ComparisonLevels {
+		updated := (counts[level] + emPseudoCount) / total
+		maxChange = math.Max(maxChange, math.Abs(updated-probs[level]))
+		probs[level] = updated
+	}
+	return maxChange
+}
+
+// orientModel swaps the match and non-match classes if EM converged to the mirrored
+// solution, where agreement is more likely among non-matches than matches
+func orientModel(model *FellegiSunterModel) {
+	inverted := 0
+	for _, probs := range model.Fields {
+		if probs.M[LevelExact] < probs.U[LevelExact] {
+			inverted++
+		}
+	}
+	if inverted*2 <= len(model.Fields) {
+		return
+	}
+
+	for field, probs := range model.Fields {
+		model.Fields[field] = FieldProbabilities{M: probs.U, U: probs.M}
+	}
+	model.Prior = 1 - model.Prior
+}
+
+// copyFellegiSunterModel returns a deep copy of the model
+func copyFellegiSunterModel(model *FellegiSunterModel) *FellegiSunterModel {
+	c := *model
+	c.Fields = make(map[string]FieldProbabilities, len(model.Fields))
+	for field, probs := range model.Fields {
+		fieldCopy := FieldProbabilities{
+			M: make(map[ComparisonLevel]float64, len(probs.M)),
+			U: make(map[ComparisonLevel]float64, len(probs.U)),
+		}
+		for level, p := range probs.M {
+			fieldCopy.M[level] = p
+		}
+		for level, p := range probs.U {
+			fieldCopy.U[level] = p
+		}
+		c.Fields[field] = fieldCopy
+	}
+	return &c
+}
@@ -0,0 +1,79 @@
+package match
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	"github.com/TFMV/resolve/internal/config"
+)
+
+func TestEstimateFellegiSunter(t *testing.T) {
+	fields := []config.FieldConfig{{Name: "name", Type: "name"}, {Name: "phone", Type: "phone"}, {Name: "zip", Type: "zip"}}
+	rng := rand.New(rand.NewSource(7))
+
+	// 30% matches that mostly agree, 70% non-matches that mostly disagree
+	draw := func(pAgree float64) ComparisonLevel {
+		if rng.Float64() < pAgree {
+			return LevelExact
+		}
+		return LevelDisagree
+	}
+	var vectors []ComparisonVector
+	for i := 0; i < 3000; i++ {
+		pAgree := 0.05
+		if i%10 < 3 {
+			pAgree = 0.9
+		}
+		vectors = append(vectors, ComparisonVector{"name": draw(pAgree), "phone": draw(pAgree), "zip": draw(pAgree)})
+	}
+
+	model, iterations, converged := EstimateFellegiSunter(vectors, DefaultFellegiSunterModel(fields), 200, 1e-6)
+	if !converged {
+		t.Errorf("EM did not converge in %d iterations", iterations)
+	}
+	if math.Abs(model.Prior-0.3) > 0.05 {
+		t.Errorf("expected prior near 0.3, got %.3f", model.Prior)
+	}
+	for _, field := range fields {
+		probs := model.Fields[field.Name]
+		if math.Abs(probs.M[LevelExact]-0.9) > 0.05 || math.Abs(probs.U[LevelExact]-0.05) > 0.03 {
+			t.Errorf("%s: unexpected m/u for exact agreement: m=%.3f u=%.3f", field.Name, probs.M[LevelExact], probs.U[LevelExact])
+		}
+	}
+}
+
+func TestTrainFellegiSunterModelArtifact(t *testing.T) {
+	ctx := context.Background()
+	svc, _ := newTestService(t, true)
+
+	if _, err := svc.AddEntities(ctx, testEntities); err != nil {
+		t.Fatalf("AddEntities: %v", err)
+	}
+
+	artifact, err := svc.TrainFellegiSunter(ctx, TrainOptions{Seed: 1})
+	if err != nil {
+		t.Fatalf("TrainFellegiSunter: %v", err)
+	}
+	if artifact.Stats.Entities != len(testEntities) || artifact.Stats.Pairs == 0 {
+		t.Errorf("unexpected training stats: %+v", artifact.Stats)
+	}
+
+	path := filepath.Join(t.TempDir(), "model.json")
+	if err := artifact.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := svc.LoadModel(path); err != nil {
+		t.Fatalf("LoadModel: %v", err)
+	}
+	if svc.fsModel.Prior != artifact.FellegiSunter.Prior {
+		t.Errorf("loaded model prior %f, want %f", svc.fsModel.Prior, artifact.FellegiSunter.Prior)
+	}
+
+	artifact.Version = ModelVersion + 1
+	if err := artifact.Validate(); err == nil {
+		t.Errorf("expected error for unsupported model version")
+	}
+}
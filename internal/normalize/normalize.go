@@ -293,42 +293,80 @@ func (n *Normalizer) NormalizeZip(zip string) string {
 	return zip
 }
 
-// NormalizeEntity applies normalization to all fields of an entity map
-func (n *Normalizer) NormalizeEntity(entity map[string]string) map[string]string {
-	normalized := make(map[string]string)
-
-	// Copy original values
-	for k, v := range entity {
-		normalized[k] = v
+// NormalizeIdentifier normalizes identifiers such as tax IDs or account numbers
+// by removing punctuation and whitespace and converting to lowercase
+func (n *Normalizer) NormalizeIdentifier(id string) string {
+	if id == "" {
+		return ""
 	}
 
-	// Apply specific normalizations
-	if name, exists := entity["name"]; exists {
-		normalized["name_normalized"] = n.NormalizeName(name)
-	}
+	return strings.ToLower(n.nonAlphanumericRegex.ReplaceAllString(id, ""))
+}
 
-	if address, exists := entity["address"]; exists {
-		normalized["address_normalized"] = n.NormalizeAddress(address)
+// NormalizeURL normalizes website URLs by removing the scheme, "www." prefix and trailing slash
+func (n *Normalizer) NormalizeURL(url string) string {
+	if url == "" {
+		return ""
 	}
 
-	if phone, exists := entity["phone"]; exists {
-		normalized["phone_normalized"] = n.NormalizePhone(phone)
-	}
+	url = strings.ToLower(strings.TrimSpace(url))
+	url = strings.TrimPrefix(url, "https://")
+	url = strings.TrimPrefix(url, "http://")
+	url = strings.TrimPrefix(url, "www.")
 
-	if email, exists := entity["email"]; exists {
-		normalized["email_normalized"] = n.NormalizeEmail(email)
-	}
+	return strings.TrimSuffix(url, "/")
+}
 
-	if state, exists := entity["state"]; exists {
-		normalized["state_normalized"] = n.NormalizeState(state)
+// NormalizeValue applies the named normalizer to a single value.
+// Unknown normalizer names fall back to basic text normalization.
+func (n *Normalizer) NormalizeValue(normalizer, value string) string {
+	switch strings.ToLower(normalizer) {
+	case "name":
+		return n.NormalizeName(value)
+	case "address":
+		return n.NormalizeAddress(value)
+	case "phone":
+		return n.NormalizePhone(value)
+	case "email":
+		return n.NormalizeEmail(value)
+	case "state":
+		return n.NormalizeState(value)
+	case "zip":
+		return n.NormalizeZip(value)
+	case "identifier":
+		return n.NormalizeIdentifier(value)
+	case "url":
+		return n.NormalizeURL(value)
+	case "none":
+		return value
+	default:
+		return n.NormalizeText(value)
 	}
+}
+
+// NormalizeEntity applies normalization to all fields of an entity map.
+// Each schema field present in the entity gets a "<field>_normalized" value
+// produced by its configured normalizer (or by the normalizer named after its type).
+func (n *Normalizer) NormalizeEntity(entity map[string]string) map[string]string {
+	normalized := make(map[string]string)
 
-	if zip, exists := entity["zip"]; exists {
-		normalized["zip_normalized"] = n.NormalizeZip(zip)
+	// Copy original values
+	for k, v := range entity {
+		normalized[k] = v
 	}
 
-	if city, exists := entity["city"]; exists {
-		normalized["city_normalized"] = n.NormalizeText(city)
+	// Apply field-specific normalizations
+	for _, field := range n.cfg.SchemaFields() {
+		value, exists := entity[field.Name]
+		if !exists {
+			continue
+		}
+
+		normalizer := field.Normalizer
+		if normalizer == "" {
+			normalizer = field.Type
+		}
+		normalized[field.Name+"_normalized"] = n.NormalizeValue(normalizer, value)
 	}
 
 	return normalized
@@ -0,0 +1,478 @@
+package override
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/TFMV/resolve/internal/fileutil"
+	"github.com/TFMV/resolve/internal/unionfind"
+	"github.com/google/uuid"
+)
+
+// Override kinds
+const (
+	MustLink   = "must_link"   // Force two records into the same group
+	CannotLink = "cannot_link" // Keep two records in different groups
+	Split      = "split"       // Split records into subsets that are kept apart
+)
+
+// ErrInvalidOverride is returned when an override cannot be recorded as given
+var ErrInvalidOverride = errors.New("invalid override")
+
+// ErrNotFound is returned when an override does not exist
+var ErrNotFound = errors.New("override not found")
+
+// Override is a steward decision that wins over any match score
+type Override struct {
+	ID        string     `json:"id"`
+	Kind      string     `json:"kind"`
+	Records   []string   `json:"records,omitempty"` // The two records of a must_link or cannot_link
+	Subsets   [][]string `json:"subsets,omitempty"` // The subsets of a split; each subset is kept together
+	Author    string     `json:"author"`
+	Reason    string     `json:"reason"`
+	CreatedAt int64      `json:"created_at"`
+}
+
+// Validate checks that an override is complete
+func (o *Override) Validate() error {
+	if o.Author == "" || o.Reason == "" {
+		return fmt.Errorf("%w: author and reason are required", ErrInvalidOverride)
+	}
+
+	switch o.Kind {
+	case MustLink, CannotLink:
+		if len(o.Records) != 2 || o.Records[0] == "" || o.Records[1] == "" {
+			return fmt.Errorf("%w: %s takes two record IDs", ErrInvalidOverride, o.Kind)
+		}
+		if o.Records[0] == o.Records[1] {
+			return fmt.Errorf("%w: %s needs two different records", ErrInvalidOverride, o.Kind)
+		}
+	case Split:
+		if len(o.Subsets) < 2 {
+			return fmt.Errorf("%w: split takes at least two subsets", ErrInvalidOverride)
+		}
+		seen := make(map[string]bool)
+		for i, subset := range o.Subsets {
+			if len(subset) == 0 {
+				return fmt.Errorf("%w: split subset %d is empty", ErrInvalidOverride, i)
+			}
+			for _, id := range subset {
+				if id == "" || seen[id] {
+					return fmt.Errorf("%w: split record IDs must be non-empty and appear once", ErrInvalidOverride)
+				}
+				seen[id] = true
+			}
+		}
+	default:
+		return fmt.Errorf("%w: unknown kind %q", ErrInvalidOverride, o.Kind)
+	}
+
+	return nil
+}
+
+// RecordIDs returns every record an override refers to
+func (o *Override) RecordIDs() []string {
+	ids := append([]string(nil), o.Records...)
+	for _, subset := range o.Subsets {
+		ids = append(ids, subset...)
+	}
+	return ids
+}
+
+// Store is a durable log of overrides kept in a JSON file. The file is reread on every
+// call and writes hold a lock on it, so that the API server and the CLI can share it.
+type Store struct {
+	path  string
+	mutex sync.Mutex
+
+	// Constraints of the file as last read, reused until the file changes
+	constraints     *Constraints
+	constraintsFile os.FileInfo
+	constraintsMu   sync.Mutex
+}
+
+// NewStore creates a store backed by the file at path, which is created on the first write
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// List returns every override in the order they were made
+func (s *Store) List() ([]Override, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.load()
+}
+
+// Add validates and records an override, assigning its ID and creation time
+func (s *Store) Add(o Override) (Override, error) {
+	if err := o.Validate(); err != nil {
+		return Override{}, err
+	}
+	o.ID = uuid.New().String()
+	o.CreatedAt = time.Now().Unix()
+
+	unlock, err := s.lock()
+	if err != nil {
+		return Override{}, err
+	}
+	defer unlock()
+
+	overrides, err := s.load()
+	if err != nil {
+		return Override{}, err
+	}
+	updated := append(overrides, o)
+	if err := checkConsistent(overrides, updated); err != nil {
+		return Override{}, err
+	}
+	if err := s.save(updated); err != nil {
+		return Override{}, err
+	}
+	return o, nil
+}
+
+// Remove deletes an override
+func (s *Store) Remove(id string) (Override, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return Override{}, err
+	}
+	defer unlock()
+
+	overrides, err := s.load()
+	if err != nil {
+		return Override{}, err
+	}
+	for i, o := range overrides {
+		if o.ID == id {
+			updated := append(overrides[:i:i], overrides[i+1:]...)
+			if err := checkConsistent(overrides, updated); err != nil {
+				return Override{}, err
+			}
+			if err := s.save(updated); err != nil {
+				return Override{}, err
+			}
+			return o, nil
+		}
+	}
+	return Override{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+}
+
+// checkConsistent rejects a change to the overrides that leaves records cannot-linked while
+// a chain of must-links through other records joins them. Only the pairs the change newly
+// contradicts are rejected, so an older contradiction does not block unrelated changes.
+func checkConsistent(before, after []Override) error {
+	known := make(map[[2]string]bool)
+	for _, pair := range NewConstraints(before).contradictions() {
+		known[pair] = true
+	}
+	for _, pair := range NewConstraints(after).contradictions() {
+		if !known[pair] {
+			return fmt.Errorf("%w: %s and %s would be cannot-linked but joined by must-links through other records; remove those must-links first",
+				ErrInvalidOverride, pair[0], pair[1])
+		}
+	}
+	return nil
+}
+
+// Constraints returns the link constraints of every override. They are derived again only
+// when the file has changed since the last call, so callers may ask on every match.
+func (s *Store) Constraints() (*Constraints, error) {
+	s.constraintsMu.Lock()
+	defer s.constraintsMu.Unlock()
+
+	// Writes replace the file, so an unchanged file is the same file with the same size and time
+	info, err := os.Stat(s.path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read overrides file: %w", err)
+	}
+	if s.constraints != nil && sameFile(info, s.constraintsFile) {
+		return s.constraints, nil
+	}
+
+	overrides, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	s.constraints, s.constraintsFile = NewConstraints(overrides), info
+	return s.constraints, nil
+}
+
+// sameFile reports whether two stats of the overrides file describe the same contents;
+// nil stats describe a missing file
+func sameFile(a, b os.FileInfo) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return os.SameFile(a, b) && a.Size() == b.Size() && a.ModTime().Equal(b.ModTime())
+}
+
+// lock serializes writers of the overrides file, within this process and across processes
+func (s *Store) lock() (func(), error) {
+	s.mutex.Lock()
+	unlock, err := fileutil.Lock(s.path)
+	if err != nil {
+		s.mutex.Unlock()
+		return nil, fmt.Errorf("failed to lock overrides file: %w", err)
+	}
+	return func() {
+		unlock()
+		s.mutex.Unlock()
+	}, nil
+}
+
+// load reads the overrides file; a missing file holds no overrides
+func (s *Store) load() ([]Override, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read overrides file: %w", err)
+	}
+
+	var overrides []Override
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("failed to parse overrides file: %w", err)
+	}
+	return overrides, nil
+}
+
+// save writes the overrides file atomically
+func (s *Store) save(overrides []Override) error {
+	data, err := json.MarshalIndent(overrides, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode overrides: %w", err)
+	}
+	if err := fileutil.WriteFile(s.path, data); err != nil {
+		return fmt.Errorf("failed to write overrides file: %w", err)
+	}
+	return nil
+}
+
+// Constraints are the must-link and cannot-link pairs implied by a list of overrides. When
+// overrides disagree about a pair, the latest one wins. A Store never records overrides
+// that cannot-link records joined by a chain of must-links.
+type Constraints struct {
+	must     map[[2]string]bool
+	cannot   map[string]map[string]bool
+	adjacent map[string][]string // Must-linked records of each record
+}
+
+// NewConstraints derives the link constraints of overrides applied in order. A split
+// cannot-links every pair of records in different subsets and must-links each subset.
+func NewConstraints(overrides []Override) *Constraints {
+	c := &Constraints{must: make(map[[2]string]bool), cannot: make(map[string]map[string]bool)}
+	for _, o := range overrides {
+		switch o.Kind {
+		case MustLink:
+			c.link(o.Records[0], o.Records[1])
+		case CannotLink:
+			c.separate(o.Records[0], o.Records[1])
+		case Split:
+			for i, subset := range o.Subsets {
+				for j := 1; j < len(subset); j++ {
+					c.link(subset[0], subset[j])
+				}
+				for _, other := range o.Subsets[i+1:] {
+					for _, a := range subset {
+						for _, b := range other {
+							c.separate(a, b)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	c.adjacent = make(map[string][]string)
+	for pair := range c.must {
+		c.adjacent[pair[0]] = append(c.adjacent[pair[0]], pair[1])
+		c.adjacent[pair[1]] = append(c.adjacent[pair[1]], pair[0])
+	}
+	return c
+}
+
+// Empty reports whether there are no constraints
+func (c *Constraints) Empty() bool {
+	return c == nil || (len(c.must) == 0 && len(c.cannot) == 0)
+}
+
+// CannotLink reports whether two records must be kept apart
+func (c *Constraints) CannotLink(a, b string) bool {
+	return c != nil && c.cannot[a][b]
+}
+
+// MustLinks returns the must-link pairs, ordered by ID
+func (c *Constraints) MustLinks() [][2]string {
+	if c == nil {
+		return nil
+	}
+	pairs := make([][2]string, 0, len(c.must))
+	for pair := range c.must {
+		pairs = append(pairs, pair)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+	return pairs
+}
+
+// contradictions returns the cannot-linked pairs joined by a chain of must-links, ordered by ID
+func (c *Constraints) contradictions() [][2]string {
+	if len(c.must) == 0 || len(c.cannot) == 0 {
+		return nil
+	}
+	uf := unionfind.New()
+	for pair := range c.must {
+		uf.Union(pair[0], pair[1])
+	}
+
+	var pairs [][2]string
+	for a, others := range c.cannot {
+		for b := range others {
+			if a < b && uf.Connected(a, b) {
+				pairs = append(pairs, [2]string{a, b})
+			}
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+	return pairs
+}
+
+// Linked returns the records connected to id through must-links, excluding id itself
+func (c *Constraints) Linked(id string) []string {
+	if c.Empty() {
+		return nil
+	}
+
+	visited := map[string]bool{id: true}
+	queue := []string{id}
+	var linked []string
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range c.adjacent[current] {
+			if !visited[next] {
+				visited[next] = true
+				linked = append(linked, next)
+				queue = append(queue, next)
+			}
+		}
+	}
+	sort.Strings(linked)
+	return linked
+}
+
+// Pair is a scored link between two records
+type Pair struct {
+	A, B  string
+	Score float64
+}
+
+// Cluster groups records into the connected components of the must-links and the scored
+// pairs. Must-links are applied first, then pairs from the highest score down, skipping
+// any pair that would join a cannot-linked pair of records. Links involving records
+// outside ids are ignored. Groups are sorted and ordered by their first ID.
+func (c *Constraints) Cluster(ids []string, pairs []Pair) [][]string {
+	uf := unionfind.New()
+	known := make(map[string]bool, len(ids))
+	members := make(map[string][]string, len(ids))
+	for _, id := range ids {
+		if !known[id] {
+			known[id] = true
+			uf.Add(id)
+			members[id] = []string{id}
+		}
+	}
+
+	join := func(a, b string, force bool) {
+		if !known[a] || !known[b] {
+			return
+		}
+		rootA, rootB := uf.Find(a), uf.Find(b)
+		if rootA == rootB || (!force && c.conflict(uf, members[rootA], members[rootB], rootB)) {
+			return
+		}
+		uf.Union(a, b)
+		root := uf.Find(a)
+		merged := append(members[rootA], members[rootB]...)
+		delete(members, rootA)
+		delete(members, rootB)
+		members[root] = merged
+	}
+
+	for _, pair := range c.MustLinks() {
+		join(pair[0], pair[1], true)
+	}
+
+	sorted := append([]Pair(nil), pairs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Score > sorted[j].Score
+	})
+	for _, pair := range sorted {
+		join(pair.A, pair.B, false)
+	}
+
+	return uf.Groups()
+}
+
+// conflict reports whether joining two groups would join a cannot-linked pair
+func (c *Constraints) conflict(uf *unionfind.UnionFind, a, b []string, rootB string) bool {
+	if c == nil || len(c.cannot) == 0 {
+		return false
+	}
+	for _, id := range a {
+		for other := range c.cannot[id] {
+			if uf.Find(other) == rootB {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// link records a must-link pair, replacing a cannot-link
+func (c *Constraints) link(a, b string) {
+	if a == b {
+		return
+	}
+	delete(c.cannot[a], b)
+	delete(c.cannot[b], a)
+	c.must[pairKey(a, b)] = true
+}
+
+// separate records a cannot-link pair, replacing a must-link
+func (c *Constraints) separate(a, b string) {
+	if a == b {
+		return
+	}
+	delete(c.must, pairKey(a, b))
+	for _, pair := range [][2]string{{a, b}, {b, a}} {
+		if c.cannot[pair[0]] == nil {
+			c.cannot[pair[0]] = make(map[string]bool)
+		}
+		c.cannot[pair[0]][pair[1]] = true
+	}
+}
+
+// pairKey orders a pair of IDs
+func pairKey(a, b string) [2]string {
+	if b < a {
+		return [2]string{b, a}
+	}
+	return [2]string{a, b}
+}
@@ -0,0 +1,170 @@
+package override
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestStoreAddRemove(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "overrides.json"))
+
+	if _, err := store.Add(Override{Kind: MustLink, Records: []string{"a", "b"}}); !errors.Is(err, ErrInvalidOverride) {
+		t.Errorf("expected an invalid override without an author and reason, got %v", err)
+	}
+	if _, err := store.Add(Override{Kind: Split, Subsets: [][]string{{"a"}}, Author: "ann", Reason: "test"}); !errors.Is(err, ErrInvalidOverride) {
+		t.Errorf("expected an invalid split with one subset, got %v", err)
+	}
+
+	o, err := store.Add(Override{Kind: MustLink, Records: []string{"a", "b"}, Author: "ann", Reason: "same customer"})
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if o.ID == "" || o.CreatedAt == 0 {
+		t.Errorf("expected an ID and creation time, got %+v", o)
+	}
+
+	// A second store on the same file sees the override
+	overrides, err := NewStore(store.path).List()
+	if err != nil || len(overrides) != 1 || overrides[0].Author != "ann" {
+		t.Fatalf("expected the recorded override, got %+v (%v)", overrides, err)
+	}
+
+	if _, err := store.Remove(o.ID); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, err := store.Remove(o.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestStoreRejectsMustLinkChainConflicts(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "overrides.json"))
+	add := func(o Override) (Override, error) {
+		o.Author, o.Reason = "ann", "test"
+		return store.Add(o)
+	}
+
+	for _, pair := range [][]string{{"a", "x"}, {"x", "b"}} {
+		if _, err := add(Override{Kind: MustLink, Records: pair}); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+
+	// a and b are joined through x, so splitting or separating them directly would lose
+	if _, err := add(Override{Kind: Split, Subsets: [][]string{{"a"}, {"b"}}}); !errors.Is(err, ErrInvalidOverride) {
+		t.Errorf("expected a split across a must-link chain to be rejected, got %v", err)
+	}
+	if _, err := add(Override{Kind: CannotLink, Records: []string{"a", "b"}}); !errors.Is(err, ErrInvalidOverride) {
+		t.Errorf("expected a cannot-link across a must-link chain to be rejected, got %v", err)
+	}
+	if constraints, _ := store.Constraints(); constraints.CannotLink("a", "b") {
+		t.Error("expected the rejected overrides not to be recorded")
+	}
+
+	// Separating a directly must-linked pair still replaces the must-link
+	cannot, err := add(Override{Kind: CannotLink, Records: []string{"a", "x"}})
+	if err != nil {
+		t.Fatalf("expected a direct cannot-link to replace the must-link, got %v", err)
+	}
+	if _, err := add(Override{Kind: CannotLink, Records: []string{"a", "b"}}); err != nil {
+		t.Fatalf("expected a and b to be separable once the chain is cut, got %v", err)
+	}
+	constraints, _ := store.Constraints()
+	if groups := constraints.Cluster([]string{"a", "b", "x"}, nil); !reflect.DeepEqual(groups, [][]string{{"a"}, {"b", "x"}}) {
+		t.Errorf("expected a kept apart from b and x, got %v", groups)
+	}
+
+	// Removing the cut would rejoin the cannot-linked pair, and a must-link may not rejoin it either
+	if _, err := store.Remove(cannot.ID); !errors.Is(err, ErrInvalidOverride) {
+		t.Errorf("expected a removal that rejoins a cannot-linked pair to be rejected, got %v", err)
+	}
+	if _, err := add(Override{Kind: MustLink, Records: []string{"a", "x"}}); !errors.Is(err, ErrInvalidOverride) {
+		t.Errorf("expected a must-link that rejoins a cannot-linked pair to be rejected, got %v", err)
+	}
+}
+
+func TestStoreConstraintsCache(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "overrides.json"))
+
+	empty, err := store.Constraints()
+	if err != nil || !empty.Empty() {
+		t.Fatalf("expected no constraints without a file, got %+v (%v)", empty, err)
+	}
+	if _, err := store.Add(Override{Kind: MustLink, Records: []string{"a", "b"}, Author: "ann", Reason: "test"}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	first, err := store.Constraints()
+	if err != nil || !reflect.DeepEqual(first.Linked("a"), []string{"b"}) {
+		t.Fatalf("expected a linked to b, got %v (%v)", first.Linked("a"), err)
+	}
+	if again, _ := store.Constraints(); again != first {
+		t.Error("expected unchanged overrides to reuse the constraints")
+	}
+
+	// A write through another store is picked up
+	if _, err := NewStore(store.path).Add(Override{Kind: MustLink, Records: []string{"b", "c"}, Author: "ann", Reason: "test"}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	second, _ := store.Constraints()
+	if !reflect.DeepEqual(second.Linked("a"), []string{"b", "c"}) {
+		t.Errorf("expected the new override to be applied, got %v", second.Linked("a"))
+	}
+}
+
+func TestStoreSharedWriters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "overrides.json")
+
+	// Separate stores on one file stand in for the API server and the CLI
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(store *Store) {
+			defer wg.Done()
+			if _, err := store.Add(Override{Kind: CannotLink, Records: []string{"a", "b"}, Author: "ann", Reason: "test"}); err != nil {
+				t.Errorf("Add: %v", err)
+			}
+		}(NewStore(path))
+	}
+	wg.Wait()
+
+	overrides, err := NewStore(path).List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(overrides) != 50 {
+		t.Errorf("expected every write to be kept, got %d overrides", len(overrides))
+	}
+}
+
+func TestConstraintsCluster(t *testing.T) {
+	overrides := []Override{
+		{Kind: MustLink, Records: []string{"a", "b"}},
+		{Kind: CannotLink, Records: []string{"c", "d"}},
+		// The latest override wins: a and b are separated again
+		{Kind: Split, Subsets: [][]string{{"a", "e"}, {"b"}}},
+	}
+	c := NewConstraints(overrides)
+
+	if !c.CannotLink("a", "b") || !c.CannotLink("e", "b") || !c.CannotLink("d", "c") {
+		t.Errorf("expected cannot-links between a/b, e/b and c/d")
+	}
+	if got := c.Linked("a"); !reflect.DeepEqual(got, []string{"e"}) {
+		t.Errorf("expected a to be linked to e, got %v", got)
+	}
+
+	// High scores cannot join cannot-linked records; must-links hold without a score
+	pairs := []Pair{
+		{A: "a", B: "b", Score: 0.99},
+		{A: "c", B: "d", Score: 0.98},
+		{A: "b", B: "f", Score: 0.9},
+		{A: "d", B: "f", Score: 0.8},
+	}
+	got := c.Cluster([]string{"a", "b", "c", "d", "e", "f"}, pairs)
+	want := [][]string{{"a", "e"}, {"b", "d", "f"}, {"c"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,280 @@
+package review
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/TFMV/resolve/internal/fileutil"
+	"github.com/google/uuid"
+)
+
+// Review statuses
+const (
+	Pending  = "pending"
+	Accepted = "accepted"
+	Rejected = "rejected"
+)
+
+// ErrNotFound is returned when a review pair does not exist
+var ErrNotFound = errors.New("review pair not found")
+
+// ErrAlreadyDecided is returned when a decided pair is decided again
+var ErrAlreadyDecided = errors.New("review pair already decided")
+
+// ErrInvalidDecision is returned when a decision is incomplete
+var ErrInvalidDecision = errors.New("invalid review decision")
+
+// FieldDiff compares the values of a field in the two records of a pair
+type FieldDiff struct {
+	A       string  `json:"a"`
+	B       string  `json:"b"`
+	Score   float32 `json:"score"`
+	Differs bool    `json:"differs"`
+}
+
+// Pair is a borderline match waiting for, or decided by, a clerical reviewer
+type Pair struct {
+	ID         string               `json:"id"`
+	A          string               `json:"id_a"`
+	B          string               `json:"id_b"`
+	Score      float32              `json:"score"`
+	Fields     map[string]FieldDiff `json:"fields,omitempty"`
+	Status     string               `json:"status"`
+	CreatedAt  int64                `json:"created_at"`
+	Reviewer   string               `json:"reviewer,omitempty"`
+	Reason     string               `json:"reason,omitempty"`
+	DecidedAt  int64                `json:"decided_at,omitempty"`
+	OverrideID string               `json:"override_id,omitempty"` // Override recording the decision
+}
+
+// Decision is a reviewer's verdict on a pair
+type Decision struct {
+	Reviewer string `json:"reviewer"`
+	Reason   string `json:"reason"`
+}
+
+// Store is a durable review queue kept in a JSON file. The file is reread on every call
+// and writes hold a lock on it, so that the API server and the CLI can share it.
+type Store struct {
+	path  string
+	mutex sync.Mutex
+}
+
+// NewStore creates a review queue backed by the file at path, which is created on the first write
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// List returns the pairs with the given status, or every pair if status is empty, in the
+// order they were queued
+func (s *Store) List(status string) ([]Pair, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	pairs, err := s.load()
+	if err != nil || status == "" {
+		return pairs, err
+	}
+	filtered := make([]Pair, 0, len(pairs))
+	for _, pair := range pairs {
+		if pair.Status == status {
+			filtered = append(filtered, pair)
+		}
+	}
+	return filtered, nil
+}
+
+// Get returns a review pair
+func (s *Store) Get(id string) (Pair, error) {
+	pairs, err := s.List("")
+	if err != nil {
+		return Pair{}, err
+	}
+	for _, pair := range pairs {
+		if pair.ID == id {
+			return pair, nil
+		}
+	}
+	return Pair{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+}
+
+// Enqueue queues pairs for review and returns how many were new. A pair already pending
+// is refreshed with the new score and field diffs; a decided pair is not queued again.
+func (s *Store) Enqueue(pairs []Pair) (int, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	queued, err := s.load()
+	if err != nil {
+		return 0, err
+	}
+	index := make(map[[2]string]int, len(queued))
+	for i, pair := range queued {
+		index[pairKey(pair.A, pair.B)] = i
+	}
+
+	added, changed := 0, false
+	for _, pair := range pairs {
+		if pair.A == pair.B {
+			continue
+		}
+		key := pairKey(pair.A, pair.B)
+		if i, ok := index[key]; ok {
+			if queued[i].Status == Pending {
+				queued[i].Score, queued[i].Fields = pair.Score, pair.Fields
+				changed = true
+			}
+			continue
+		}
+
+		pair.ID = uuid.New().String()
+		pair.Status = Pending
+		pair.CreatedAt = time.Now().Unix()
+		index[key] = len(queued)
+		queued = append(queued, pair)
+		added++
+		changed = true
+	}
+
+	if !changed {
+		return 0, nil
+	}
+	return added, s.save(queued)
+}
+
+// Decide accepts or rejects a pending pair, recording the reviewer and the reason. If
+// record is not nil, it is called with the pending pair while the queue is locked, so no
+// other decision can claim the pair, and returns the ID of the override carrying the
+// decision; if it fails the pair stays pending.
+func (s *Store) Decide(id string, accept bool, decision Decision, record func(Pair) (string, error)) (Pair, error) {
+	if decision.Reviewer == "" {
+		return Pair{}, fmt.Errorf("%w: reviewer is required", ErrInvalidDecision)
+	}
+
+	unlock, err := s.lock()
+	if err != nil {
+		return Pair{}, err
+	}
+	defer unlock()
+
+	pairs, err := s.load()
+	if err != nil {
+		return Pair{}, err
+	}
+	for i := range pairs {
+		if pairs[i].ID != id {
+			continue
+		}
+		if pairs[i].Status != Pending {
+			return Pair{}, fmt.Errorf("%w: %s was %s", ErrAlreadyDecided, id, pairs[i].Status)
+		}
+
+		if record != nil {
+			overrideID, err := record(pairs[i])
+			if err != nil {
+				return Pair{}, err
+			}
+			pairs[i].OverrideID = overrideID
+		}
+		pairs[i].Status = Rejected
+		if accept {
+			pairs[i].Status = Accepted
+		}
+		pairs[i].Reviewer = decision.Reviewer
+		pairs[i].Reason = decision.Reason
+		pairs[i].DecidedAt = time.Now().Unix()
+		if err := s.save(pairs); err != nil {
+			return Pair{}, err
+		}
+		return pairs[i], nil
+	}
+	return Pair{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+}
+
+// WriteLabels writes the decided pairs as id_a,id_b,label rows, the format read by
+// "resolve train --labels". Accepted pairs are labeled 1 and rejected pairs 0.
+func WriteLabels(w io.Writer, pairs []Pair) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"id_a", "id_b", "label"}); err != nil {
+		return fmt.Errorf("failed to write labels: %w", err)
+	}
+	for _, pair := range pairs {
+		label := ""
+		switch pair.Status {
+		case Accepted:
+			label = "1"
+		case Rejected:
+			label = "0"
+		default:
+			continue
+		}
+		if err := writer.Write([]string{pair.A, pair.B, label}); err != nil {
+			return fmt.Errorf("failed to write labels: %w", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write labels: %w", err)
+	}
+	return nil
+}
+
+// lock serializes writers of the review file, within this process and across processes
+func (s *Store) lock() (func(), error) {
+	s.mutex.Lock()
+	unlock, err := fileutil.Lock(s.path)
+	if err != nil {
+		s.mutex.Unlock()
+		return nil, fmt.Errorf("failed to lock review file: %w", err)
+	}
+	return func() {
+		unlock()
+		s.mutex.Unlock()
+	}, nil
+}
+
+// load reads the review file; a missing file holds no pairs
+func (s *Store) load() ([]Pair, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read review file: %w", err)
+	}
+
+	var pairs []Pair
+	if err := json.Unmarshal(data, &pairs); err != nil {
+		return nil, fmt.Errorf("failed to parse review file: %w", err)
+	}
+	return pairs, nil
+}
+
+// save writes the review file atomically
+func (s *Store) save(pairs []Pair) error {
+	data, err := json.MarshalIndent(pairs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode review pairs: %w", err)
+	}
+	if err := fileutil.WriteFile(s.path, data); err != nil {
+		return fmt.Errorf("failed to write review file: %w", err)
+	}
+	return nil
+}
+
+// pairKey orders a pair of IDs
+func pairKey(a, b string) [2]string {
+	if b < a {
+		return [2]string{b, a}
+	}
+	return [2]string{a, b}
+}
@@ -0,0 +1,83 @@
+package review
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestStoreEnqueueAndDecide(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "review.json"))
+
+	added, err := store.Enqueue([]Pair{{A: "a", B: "b", Score: 0.8}, {A: "b", B: "a", Score: 0.85}, {A: "a", B: "c", Score: 0.9}})
+	if err != nil || added != 2 {
+		t.Fatalf("expected 2 new pairs, got %d (%v)", added, err)
+	}
+	pending, _ := store.List(Pending)
+	if len(pending) != 2 || pending[0].Score != 0.85 {
+		t.Fatalf("expected a refreshed a/b pair, got %+v", pending)
+	}
+
+	if _, err := store.Decide(pending[0].ID, false, Decision{Reviewer: "ann", Reason: "different people"}, nil); err != nil {
+		t.Fatalf("Decide: %v", err)
+	}
+	if _, err := store.Decide("missing", true, Decision{Reviewer: "ann"}, nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	// A decided pair is not queued again
+	if added, _ := store.Enqueue([]Pair{{A: "a", B: "b", Score: 0.9}}); added != 0 {
+		t.Errorf("expected the decided pair to stay decided")
+	}
+
+	all, _ := store.List("")
+	var labels bytes.Buffer
+	if err := WriteLabels(&labels, all); err != nil {
+		t.Fatalf("WriteLabels: %v", err)
+	}
+	if got := labels.String(); got != "id_a,id_b,label\na,b,0\n" {
+		t.Errorf("unexpected labels: %q", got)
+	}
+}
+
+func TestStoreDecideRecordsOnce(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "review.json"))
+	if _, err := store.Enqueue([]Pair{{A: "a", B: "b", Score: 0.8}}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	pending, _ := store.List(Pending)
+	id := pending[0].ID
+
+	// A failed record leaves the pair pending
+	failed := errors.New("failed")
+	if _, err := store.Decide(id, true, Decision{Reviewer: "ann"}, func(Pair) (string, error) { return "", failed }); !errors.Is(err, failed) {
+		t.Fatalf("expected the record error, got %v", err)
+	}
+
+	// Concurrent decisions on the pair record one override
+	var mu sync.Mutex
+	records := 0
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(accept bool) {
+			defer wg.Done()
+			store.Decide(id, accept, Decision{Reviewer: "ann"}, func(Pair) (string, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				records++
+				return "override-1", nil
+			})
+		}(i%2 == 0)
+	}
+	wg.Wait()
+	if records != 1 {
+		t.Errorf("expected one recorded override, got %d", records)
+	}
+	pair, _ := store.Get(id)
+	if pair.Status == Pending || pair.OverrideID != "override-1" {
+		t.Errorf("expected a decided pair carrying its override, got %+v", pair)
+	}
+}
@@ -0,0 +1,219 @@
+package similarity
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Default blends of the name and address comparators, scored on preprocessed values
+const (
+	DefaultNameBlend    = "0.6*jarowinkler + 0.3*jaccard + 0.1*contains"
+	DefaultAddressBlend = "0.5*jaccard + 0.2*jarowinkler + 0.3*contains"
+)
+
+// Term is one weighted comparator of a composite
+type Term struct {
+	Weight   float64
+	Function string // Comparator name
+}
+
+// Expression is a parsed composite: a weighted sum of comparators applied after optional
+// preprocessing steps
+type Expression struct {
+	Terms      []Term
+	Preprocess []string
+}
+
+// PreprocessorNames lists the preprocessing steps a composite expression can name after "after"
+var PreprocessorNames = []string{"lowercase", "collapse_whitespace", "strip_punctuation", "digits", "legal_suffixes", "address"}
+
+// preprocessor returns the function of a preprocessing step
+func preprocessor(step string) (func(string) string, bool) {
+	switch step {
+	case "lowercase":
+		return strings.ToLower, true
+	case "collapse_whitespace":
+		return collapseWhitespace, true
+	case "strip_punctuation":
+		return stripPunctuation, true
+	case "digits":
+		return extractDigits, true
+	case "legal_suffixes":
+		return func(s string) string {
+			return strings.TrimSpace(legalSuffixRegex.ReplaceAllString(s, ""))
+		}, true
+	case "address":
+		return standardizeAddress, true
+	default:
+		return nil, false
+	}
+}
+
+// legalSuffixRegex matches a trailing legal form such as "Inc." or "LLC"
+var legalSuffixRegex = regexp.MustCompile(`(?i)\s+(inc\.?|incorporated|corp\.?|corporation|llc|ltd\.?|limited|llp|l\.l\.p\.?|pllc|p\.l\.l\.c\.?|pc|p\.c\.?)$`)
+
+// addressStandardizer standardizes addresses for the "address" preprocessing step
+var (
+	addressStandardizer     *AddressSimilarity
+	addressStandardizerOnce sync.Once
+)
+
+// standardizeAddress lowercases an address, drops unit numbers and abbreviates street
+// types and directionals
+func standardizeAddress(s string) string {
+	addressStandardizerOnce.Do(func() {
+		addressStandardizer = NewAddressSimilarity()
+	})
+	return addressStandardizer.preprocess(s)
+}
+
+// afterRegex separates the terms of an expression from its preprocessing steps
+var afterRegex = regexp.MustCompile(`(?i)\s+after\s+`)
+
+// ParseExpression parses a composite expression such as
+// "0.6*jarowinkler + 0.4*jaccard after lowercase, collapse_whitespace". A term without
+// a weight weighs 1. Comparator names are not resolved here.
+func ParseExpression(expression string) (Expression, error) {
+	var parsed Expression
+	parts := afterRegex.Split(strings.TrimSpace(expression), 2)
+	if parts[0] == "" {
+		return parsed, fmt.Errorf("empty expression")
+	}
+
+	// Parse the weighted terms
+	for _, part := range strings.Split(parts[0], "+") {
+		part = strings.TrimSpace(part)
+		term := Term{Weight: 1, Function: part}
+		if star := strings.Index(part, "*"); star >= 0 {
+			weight, err := strconv.ParseFloat(strings.TrimSpace(part[:star]), 64)
+			if err != nil {
+				return parsed, fmt.Errorf("invalid weight in term %q", part)
+			}
+			term.Weight, term.Function = weight, strings.TrimSpace(part[star+1:])
+		}
+		term.Function = strings.ToLower(term.Function)
+		if term.Function == "" {
+			return parsed, fmt.Errorf("missing function in term %q", part)
+		}
+		if term.Weight <= 0 {
+			return parsed, fmt.Errorf("weight of %s must be positive", term.Function)
+		}
+		parsed.Terms = append(parsed.Terms, term)
+	}
+
+	// Parse the preprocessing steps
+	if len(parts) == 2 {
+		for _, step := range strings.Split(parts[1], ",") {
+			step = strings.ToLower(strings.TrimSpace(step))
+			if _, ok := preprocessor(step); !ok {
+				return parsed, fmt.Errorf("unknown preprocessing step %q (expected one of %s)", step, strings.Join(PreprocessorNames, ", "))
+			}
+			parsed.Preprocess = append(parsed.Preprocess, step)
+		}
+	}
+
+	return parsed, nil
+}
+
+// Composite scores a pair as the weighted average of several comparators, after applying
+// its preprocessing steps to both values
+type Composite struct {
+	name       string
+	functions  []Function
+	weights    []float64
+	preprocess []func(string) string
+}
+
+// NewComposite resolves the comparators of a parsed expression with lookup
+func NewComposite(name string, expression Expression, lookup func(string) (Function, bool)) (*Composite, error) {
+	if len(expression.Terms) == 0 {
+		return nil, fmt.Errorf("composite %s has no terms", name)
+	}
+
+	c := &Composite{name: name}
+	for _, term := range expression.Terms {
+		fn, ok := lookup(term.Function)
+		if !ok {
+			return nil, fmt.Errorf("composite %s uses unknown similarity function %s", name, term.Function)
+		}
+		c.functions = append(c.functions, fn)
+		c.weights = append(c.weights, term.Weight)
+	}
+	for _, step := range expression.Preprocess {
+		preprocess, ok := preprocessor(step)
+		if !ok {
+			return nil, fmt.Errorf("composite %s uses unknown preprocessing step %s", name, step)
+		}
+		c.preprocess = append(c.preprocess, preprocess)
+	}
+	return c, nil
+}
+
+// Compare returns the weighted average of the comparator scores
+func (c *Composite) Compare(a, b string) float64 {
+	for _, preprocess := range c.preprocess {
+		a, b = preprocess(a), preprocess(b)
+	}
+	if a == "" && b == "" {
+		return 1.0
+	}
+	if a == "" || b == "" {
+		return 0.0
+	}
+
+	var total, weights float64
+	for i, fn := range c.functions {
+		total += c.weights[i] * fn.Compare(a, b)
+		weights += c.weights[i]
+	}
+	return total / weights
+}
+
+func (c *Composite) Name() string {
+	return c.name
+}
+
+// mustCompileBlend builds a default blend from the generic comparators
+func mustCompileBlend(name, expression string) *Composite {
+	parsed, err := ParseExpression(expression)
+	if err != nil {
+		panic(err)
+	}
+	generic := genericFunctions()
+	composite, err := NewComposite(name, parsed, func(name string) (Function, bool) {
+		return lookupFunction(generic, name)
+	})
+	if err != nil {
+		panic(err)
+	}
+	return composite
+}
+
+// extractDigits returns only the digits of a string
+func extractDigits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// collapseWhitespace trims a string and reduces runs of whitespace to one space
+func collapseWhitespace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// stripPunctuation removes everything but letters, digits and whitespace
+func stripPunctuation(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSpace(r) {
+			return r
+		}
+		return -1
+	}, s)
+}
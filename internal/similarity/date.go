@@ -0,0 +1,236 @@
+package similarity
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default tolerances of the date comparator
+const (
+	DefaultDateToleranceDays = 30  // Full dates further apart score 0
+	DefaultDateYearTolerance = 1   // Years apart at which partial dates still score above 0
+	DefaultTransposedScore   = 0.8 // Score of dates that differ only by swapped day and month
+)
+
+// Date is a calendar date parsed from a value. Month and Day are 0 when the value does not
+// give them, as in "1985" or "March 1985".
+type Date struct {
+	Year  int
+	Month int
+	Day   int
+}
+
+var (
+	// numericDateRegex matches dates such as 1985-03-04, 03/04/1985 and 4.3.85
+	numericDateRegex = regexp.MustCompile(`^(\d{1,4})[-/.](\d{1,2})[-/.](\d{1,4})$`)
+	// yearMonthRegex matches 1985-03 and 03/1985
+	yearMonthRegex = regexp.MustCompile(`^(?:(\d{4})[-/.](\d{1,2})|(\d{1,2})[-/.](\d{4}))$`)
+	// yearRegex matches a four-digit year
+	yearRegex = regexp.MustCompile(`^\d{4}$`)
+	// compactDateRegex matches 19850304
+	compactDateRegex = regexp.MustCompile(`^\d{8}$`)
+)
+
+// dateLayouts are the textual date layouts tried after the numeric forms
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"January 2, 2006",
+	"January 2 2006",
+	"Jan 2, 2006",
+	"Jan 2 2006",
+	"Jan. 2, 2006",
+	"2 January 2006",
+	"2 Jan 2006",
+	"02-Jan-2006",
+	"Monday, January 2, 2006",
+	"Mon, 02 Jan 2006",
+}
+
+// monthLayouts are the textual layouts of dates without a day
+var monthLayouts = []string{
+	"January 2006",
+	"Jan 2006",
+	"Jan. 2006",
+}
+
+// ParseDate parses a date in one of the common numeric and textual formats. Numeric dates
+// with the year last are read month first, like 03/04/1985, unless dayFirst is set or the
+// first number cannot be a month. Two-digit years are read as 1969 to 2068.
+func ParseDate(value string, dayFirst bool) (Date, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return Date{}, false
+	}
+
+	// Year only
+	if yearRegex.MatchString(value) {
+		year, _ := strconv.Atoi(value)
+		return Date{Year: year}, true
+	}
+
+	// Compact YYYYMMDD
+	if compactDateRegex.MatchString(value) {
+		year, _ := strconv.Atoi(value[:4])
+		month, _ := strconv.Atoi(value[4:6])
+		day, _ := strconv.Atoi(value[6:])
+		return validDate(year, month, day)
+	}
+
+	// Numeric dates with separators
+	if m := numericDateRegex.FindStringSubmatch(value); m != nil {
+		first, _ := strconv.Atoi(m[1])
+		second, _ := strconv.Atoi(m[2])
+		third, _ := strconv.Atoi(m[3])
+		if len(m[1]) == 4 {
+			return validDate(first, second, third)
+		}
+		if len(m[3]) != 4 && len(m[3]) != 2 {
+			return Date{}, false
+		}
+		year := expandYear(third, len(m[3]))
+		if dayFirst || first > 12 {
+			first, second = second, first
+		}
+		return validDate(year, first, second)
+	}
+
+	// Year and month
+	if m := yearMonthRegex.FindStringSubmatch(value); m != nil {
+		year, month := m[1], m[2]
+		if year == "" {
+			year, month = m[4], m[3]
+		}
+		y, _ := strconv.Atoi(year)
+		mo, _ := strconv.Atoi(month)
+		if mo < 1 || mo > 12 {
+			return Date{}, false
+		}
+		return Date{Year: y, Month: mo}, true
+	}
+
+	// Textual dates
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return Date{Year: t.Year(), Month: int(t.Month()), Day: t.Day()}, true
+		}
+	}
+	for _, layout := range monthLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return Date{Year: t.Year(), Month: int(t.Month())}, true
+		}
+	}
+
+	return Date{}, false
+}
+
+// expandYear reads a two-digit year as 1969 to 2068, like Go's time package
+func expandYear(year, digits int) int {
+	if digits != 2 {
+		return year
+	}
+	if year >= 69 {
+		return 1900 + year
+	}
+	return 2000 + year
+}
+
+// validDate returns the date if it exists in the calendar
+func validDate(year, month, day int) (Date, bool) {
+	if month < 1 || month > 12 || day < 1 {
+		return Date{}, false
+	}
+	t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if t.Day() != day {
+		return Date{}, false
+	}
+	return Date{Year: year, Month: month, Day: day}, true
+}
+
+// toTime returns the date at midnight UTC; it must be a full date
+func (d Date) toTime() time.Time {
+	return time.Date(d.Year, time.Month(d.Month), d.Day, 0, 0, 0, 0, time.UTC)
+}
+
+// DateSimilarity compares dates written in different formats, so "1985-03-04",
+// "03/04/1985" and "March 4, 1985" all agree. Full dates score by the number of days
+// between them, dates that differ only by swapped day and month still score, and values
+// giving only a year or month are compared by year.
+type DateSimilarity struct {
+	// Days apart at which full dates score 0
+	ToleranceDays int
+	// Years apart at which dates without a day score 0
+	YearTolerance int
+	// Score of full dates that differ only by swapped day and month
+	TransposedScore float64
+	// Read numeric dates with the year last as day first, like 04/03/1985
+	DayFirst bool
+}
+
+func NewDateSimilarity() DateSimilarity {
+	return DateSimilarity{
+		ToleranceDays:   DefaultDateToleranceDays,
+		YearTolerance:   DefaultDateYearTolerance,
+		TransposedScore: DefaultTransposedScore,
+	}
+}
+
+func (f DateSimilarity) Compare(a, b string) float64 {
+	// Handle empty strings
+	if a == "" && b == "" {
+		return 1.0
+	}
+	if a == "" || b == "" {
+		return 0.0
+	}
+
+	// Values that are not dates only match exactly
+	da, okA := ParseDate(a, f.DayFirst)
+	db, okB := ParseDate(b, f.DayFirst)
+	if !okA || !okB {
+		if strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b)) {
+			return 1.0
+		}
+		return 0.0
+	}
+
+	// Partial dates are compared by year
+	if da.Day == 0 || db.Day == 0 {
+		return toleranceScore(abs(da.Year-db.Year), f.YearTolerance)
+	}
+	if da == db {
+		return 1.0
+	}
+
+	days := int(da.toTime().Sub(db.toTime()).Hours() / 24)
+	score := toleranceScore(abs(days), f.ToleranceDays)
+	if da.Year == db.Year && da.Month == db.Day && da.Day == db.Month && f.TransposedScore > score {
+		score = f.TransposedScore
+	}
+	return score
+}
+
+func (f DateSimilarity) Name() string {
+	return "DateSimilarity"
+}
+
+// toleranceScore falls linearly from 1 for no difference to 0 past the tolerance
+func toleranceScore(diff, tolerance int) float64 {
+	if diff == 0 {
+		return 1.0
+	}
+	if diff > tolerance {
+		return 0.0
+	}
+	return 1.0 - float64(diff)/float64(tolerance+1)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -8,12 +8,16 @@ import (
 // NameSimilarity is specialized for comparing person or business names
 type NameSimilarity struct {
 	// Internal algorithms
-	jaroWinkler     JaroWinkler
-	tokenJaccard    Jaccard
-	containedIn     ContainedIn
 	exactMatch      ExactMatch
 	caseInsensitive CaseInsensitiveMatch
 
+	// Weighted blend of comparators scoring preprocessed names
+	blend Function
+
+	// Optional phonetic comparator and its weight in the combined score
+	phonetic       Function
+	phoneticWeight float64
+
 	// Legal suffix removal regex
 	legalSuffixRegex *regexp.Regexp
 }
@@ -21,15 +25,42 @@ type NameSimilarity struct {
 // NewNameSimilarity creates a new name similarity function
 func NewNameSimilarity() *NameSimilarity {
 	return &NameSimilarity{
-		jaroWinkler:      NewJaroWinkler(),
-		tokenJaccard:     Jaccard{},
-		containedIn:      ContainedIn{IgnoreCase: true},
 		exactMatch:       ExactMatch{},
 		caseInsensitive:  CaseInsensitiveMatch{},
-		legalSuffixRegex: regexp.MustCompile(`(?i)\s+(inc\.?|incorporated|corp\.?|corporation|llc|ltd\.?|limited|llp|l\.l\.p\.?|pllc|p\.l\.l\.c\.?|pc|p\.c\.?)$`),
+		blend:            mustCompileBlend("NameBlend", DefaultNameBlend),
+		legalSuffixRegex: legalSuffixRegex,
 	}
 }
 
+// NewPhoneticNameSimilarity creates a name similarity function that folds a phonetic
+// comparator into the combined score, so that "Smyth" and "Smith" score higher than
+// their spelling alone suggests. A weight outside (0, 1) defaults to 0.2.
+func NewPhoneticNameSimilarity(phonetic Function, weight float64) *NameSimilarity {
+	f := NewNameSimilarity()
+	f.phonetic = phonetic
+	f.phoneticWeight = phoneticWeight(weight)
+	return f
+}
+
+// NewBlendedNameSimilarity creates a name similarity function that scores preprocessed
+// names with blend instead of the default blend, for example NewMongeElkan(nil) for names
+// whose tokens are reordered or dropped
+func NewBlendedNameSimilarity(blend Function) *NameSimilarity {
+	f := NewNameSimilarity()
+	if blend != nil {
+		f.blend = blend
+	}
+	return f
+}
+
+// phoneticWeight defaults a phonetic weight outside (0, 1) to 0.2
+func phoneticWeight(weight float64) float64 {
+	if weight <= 0 || weight >= 1 {
+		return 0.2
+	}
+	return weight
+}
+
 // Compare calculates similarity between two names using a combination of metrics
 func (f *NameSimilarity) Compare(a, b string) float64 {
 	// Handle empty strings
@@ -54,14 +85,14 @@ func (f *NameSimilarity) Compare(a, b string) float64 {
 		return 1.0
 	}
 
-	// Compute various similarity scores
-	jaroScore := f.jaroWinkler.Compare(a, b)
-	tokenScore := f.tokenJaccard.Compare(a, b)
-	containmentScore := f.containedIn.Compare(a, b)
+	// Combine scores with the blend, which by default gives more weight to
+	// Jaro-Winkler as it's particularly good for names
+	combinedScore := f.blend.Compare(a, b)
 
-	// Combine scores with appropriate weighting
-	// Give more weight to Jaro-Winkler for names as it's particularly good for names
-	combinedScore := (jaroScore * 0.6) + (tokenScore * 0.3) + (containmentScore * 0.1)
+	// Blend in how the names sound, if configured
+	if f.phonetic != nil {
+		combinedScore = combinedScore*(1-f.phoneticWeight) + f.phonetic.Compare(a, b)*f.phoneticWeight
+	}
 
 	return combinedScore
 }
@@ -89,12 +120,12 @@ func (f *NameSimilarity) Name() string {
 // AddressSimilarity is specialized for comparing address strings
 type AddressSimilarity struct {
 	// Internal algorithms
-	tokenJaccard    Jaccard
-	jaroWinkler     JaroWinkler
-	containedIn     ContainedIn
 	exactMatch      ExactMatch
 	caseInsensitive CaseInsensitiveMatch
 
+	// Weighted blend of comparators scoring preprocessed addresses
+	blend Function
+
 	// Address normalization regexes
 	numericRegex     *regexp.Regexp
 	directionalRegex *regexp.Regexp
@@ -109,11 +140,9 @@ type AddressSimilarity struct {
 // NewAddressSimilarity creates a new address similarity function
 func NewAddressSimilarity() *AddressSimilarity {
 	return &AddressSimilarity{
-		tokenJaccard:     Jaccard{},
-		jaroWinkler:      NewJaroWinkler(),
-		containedIn:      ContainedIn{IgnoreCase: true},
 		exactMatch:       ExactMatch{},
 		caseInsensitive:  CaseInsensitiveMatch{},
+		blend:            mustCompileBlend("AddressBlend", DefaultAddressBlend),
 		numericRegex:     regexp.MustCompile(`\d+`),
 		directionalRegex: regexp.MustCompile(`(?i)\b(north|south|east|west|n\.?|s\.?|e\.?|w\.?|ne|nw|se|sw)\b`),
 		streetTypeRegex:  regexp.MustCompile(`(?i)\b(street|st\.?|avenue|ave\.?|boulevard|blvd\.?|road|rd\.?|drive|dr\.?|lane|ln\.?|court|ct\.?|circle|cir\.?|place|pl\.?|way|parkway|pkwy\.?|highway|hwy\.?|expressway|expy\.?)\b`),
@@ -196,18 +225,9 @@ func (f *AddressSimilarity) Compare(a, b string) float64 {
 		}
 	}
 
-	// Calculate token-based similarity (works well for addresses)
-	tokenScore := f.tokenJaccard.Compare(a, b)
-
-	// Calculate string-based similarity
-	jaroScore := f.jaroWinkler.Compare(a, b)
-
-	// Calculate containment (handles abbreviations and partial matches)
-	containmentScore := f.containedIn.Compare(a, b)
-
-	// Combine scores with weights appropriate for addresses
-	// Token-based similarity is more important for addresses
-	combinedScore := (tokenScore * 0.5) + (jaroScore * 0.2) + (containmentScore * 0.3)
+	// Combine scores with the blend, which by default favors token-based similarity
+	// and containment (abbreviations and partial matches) for addresses
+	combinedScore := f.blend.Compare(a, b)
 
 	// Apply house number penalty
 	return combinedScore * numberMatch
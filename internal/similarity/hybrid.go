@@ -0,0 +1,178 @@
+package similarity
+
+import (
+	"math"
+	"strings"
+)
+
+// higherOrder builds comparators parameterized by an inner comparator, looked up by name
+// as "outer(inner)", for example "mongeelkan(levenshtein)"
+var higherOrder = map[string]func(inner Function) Function{
+	"mongeelkan":  func(inner Function) Function { return NewMongeElkan(inner) },
+	"monge_elkan": func(inner Function) Function { return NewMongeElkan(inner) },
+}
+
+// lookupFunction finds a comparator by name, building "outer(inner)" comparators from
+// their inner comparator
+func lookupFunction(functions map[string]Function, name string) (Function, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if fn, ok := functions[name]; ok {
+		return fn, true
+	}
+
+	open := strings.Index(name, "(")
+	if open <= 0 || !strings.HasSuffix(name, ")") {
+		return nil, false
+	}
+	build, ok := higherOrder[strings.TrimSpace(name[:open])]
+	if !ok {
+		return nil, false
+	}
+	inner, ok := lookupFunction(functions, name[open+1:len(name)-1])
+	if !ok {
+		return nil, false
+	}
+	return build(inner), true
+}
+
+// MongeElkan compares multi-token values token by token: each token is scored against its
+// best match among the other value's tokens with an inner function, and the best scores
+// are averaged. Good for names with reordered or missing tokens, such as
+// "Acme Global Holdings" and "Global Acme"
+type MongeElkan struct {
+	// Inner compares single tokens, default is Jaro-Winkler
+	Inner Function
+	// Symmetric averages both directions; otherwise only the tokens of the first value
+	// must find a partner, so a value scores 1 against any value containing it
+	Symmetric bool
+}
+
+// NewMongeElkan creates a symmetric Monge-Elkan comparator. A nil inner function defaults
+// to Jaro-Winkler.
+func NewMongeElkan(inner Function) MongeElkan {
+	if inner == nil {
+		inner = NewJaroWinkler()
+	}
+	return MongeElkan{Inner: inner, Symmetric: true}
+}
+
+func (f MongeElkan) Compare(a, b string) float64 {
+	tokensA, tokensB := tokenize(a), tokenize(b)
+	if len(tokensA) == 0 && len(tokensB) == 0 {
+		return 1.0
+	}
+	if len(tokensA) == 0 || len(tokensB) == 0 {
+		return 0.0
+	}
+
+	inner := f.Inner
+	if inner == nil {
+		inner = NewJaroWinkler()
+	}
+	score := mongeElkan(inner, tokensA, tokensB)
+	if f.Symmetric {
+		score = (score + mongeElkan(inner, tokensB, tokensA)) / 2
+	}
+	return score
+}
+
+// mongeElkan averages, over the tokens of a, the best inner score against the tokens of b
+func mongeElkan(inner Function, a, b []string) float64 {
+	var total float64
+	for _, x := range a {
+		best := 0.0
+		for _, y := range b {
+			if score := inner.Compare(x, y); score > best {
+				best = score
+			}
+		}
+		total += best
+	}
+	return total / float64(len(a))
+}
+
+func (f MongeElkan) Name() string {
+	if f.Inner == nil {
+		return "MongeElkan"
+	}
+	return "MongeElkan(" + f.Inner.Name() + ")"
+}
+
+// SmithWaterman scores the best local alignment of two strings with affine gap penalties,
+// so a shared stretch such as "acme" counts even when the rest differs and a dropped
+// middle part costs a single gap rather than one edit per character. The alignment score
+// is divided by the best possible score of the shorter string and scaled by the square
+// root of the length ratio, so "co" inside "acme co" is a partial match, not a full one.
+type SmithWaterman struct {
+	// Score of aligning equal characters, default is 2
+	Match float64
+	// Score of aligning different characters, default is -1
+	Mismatch float64
+	// Penalty for the first character of a gap, default is 1.5
+	GapOpen float64
+	// Penalty for each further character of a gap, default is 0.5
+	GapExtend float64
+}
+
+func NewSmithWaterman() SmithWaterman {
+	return SmithWaterman{
+		Match:     2,
+		Mismatch:  -1,
+		GapOpen:   1.5,
+		GapExtend: 0.5,
+	}
+}
+
+func (f SmithWaterman) Compare(a, b string) float64 {
+	// Handle empty strings
+	if a == "" && b == "" {
+		return 1.0
+	}
+	if a == "" || b == "" {
+		return 0.0
+	}
+	if f.Match <= 0 {
+		f = NewSmithWaterman()
+	}
+
+	s1, s2 := []rune(a), []rune(b)
+	shorter, longer := float64(min(len(s1), len(s2))), float64(max(len(s1), len(s2)))
+	best := f.align(s1, s2)
+	return math.Min(best/(f.Match*shorter), 1.0) * math.Sqrt(shorter/longer)
+}
+
+// align returns the best local alignment score by Gotoh's algorithm, keeping one row of
+// each matrix: h ends in an aligned pair, e in a gap in s1 and f in a gap in s2
+func (f SmithWaterman) align(s1, s2 []rune) float64 {
+	negInf := math.Inf(-1)
+	cols := len(s2) + 1
+	h, e, g := make([]float64, cols), make([]float64, cols), make([]float64, cols)
+	prevH, prevG := make([]float64, cols), make([]float64, cols)
+	for j := range prevG {
+		prevG[j] = negInf
+	}
+
+	best := 0.0
+	for i := 1; i <= len(s1); i++ {
+		h[0], e[0], g[0] = 0, negInf, negInf
+		for j := 1; j < cols; j++ {
+			pair := f.Mismatch
+			if s1[i-1] == s2[j-1] {
+				pair = f.Match
+			}
+			e[j] = math.Max(e[j-1]-f.GapExtend, h[j-1]-f.GapOpen)
+			g[j] = math.Max(prevG[j]-f.GapExtend, prevH[j]-f.GapOpen)
+			h[j] = math.Max(math.Max(0, prevH[j-1]+pair), math.Max(e[j], g[j]))
+			if h[j] > best {
+				best = h[j]
+			}
+		}
+		h, prevH = prevH, h
+		g, prevG = prevG, g
+	}
+	return best
+}
+
+func (f SmithWaterman) Name() string {
+	return "SmithWaterman"
+}
@@ -0,0 +1,107 @@
+package similarity
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMongeElkan(t *testing.T) {
+	me := NewMongeElkan(nil)
+
+	if got := me.Compare("Acme Global Holdings", "Global Acme Holdings"); math.Abs(got-1) > 1e-9 {
+		t.Errorf("expected reordered tokens to score 1, got %f", got)
+	}
+	if got := me.Compare("", ""); got != 1 {
+		t.Errorf("expected empty values to score 1, got %f", got)
+	}
+	if got := me.Compare("Acme", ""); got != 0 {
+		t.Errorf("expected one empty value to score 0, got %f", got)
+	}
+
+	// Reordered and dropped tokens score higher than with the default name blend
+	a, b := "Acme Global Holdings", "Global Acme"
+	meScore := me.Compare(a, b)
+	blendScore := NewNameSimilarity().Compare(a, b)
+	if meScore <= blendScore {
+		t.Errorf("expected Monge-Elkan (%f) to beat the default name blend (%f)", meScore, blendScore)
+	}
+	if got := NewBlendedNameSimilarity(me).Compare(a, b); got <= blendScore {
+		t.Errorf("expected name similarity with a Monge-Elkan blend (%f) to beat the default (%f)", got, blendScore)
+	}
+
+	// Asymmetric scoring only asks the first value's tokens to find a partner
+	asymmetric := MongeElkan{Inner: &ExactMatch{}}
+	if got := asymmetric.Compare("Global Acme", a); got != 1 {
+		t.Errorf("expected a contained value to score 1 asymmetrically, got %f", got)
+	}
+	if got := asymmetric.Compare(a, "Global Acme"); math.Abs(got-2.0/3.0) > 1e-9 {
+		t.Errorf("expected 2 of 3 tokens to match, got %f", got)
+	}
+}
+
+func TestSmithWaterman(t *testing.T) {
+	sw := NewSmithWaterman()
+
+	if got := sw.Compare("acme", "acme"); got != 1 {
+		t.Errorf("expected identical strings to score 1, got %f", got)
+	}
+	// A contained string scores by the square root of the length ratio
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"acme", "the acme company", 0.5},
+		{"co", "acme co", math.Sqrt(2.0 / 7)},
+		{"a", "acme", 0.5},
+	}
+	for _, tt := range tests {
+		if got := sw.Compare(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Compare(%q, %q) = %f, want %f", tt.a, tt.b, got, tt.want)
+		}
+	}
+	if got := sw.Compare("abc", "xyz"); got != 0 {
+		t.Errorf("expected unrelated strings to score 0, got %f", got)
+	}
+
+	// An affine gap makes dropping a middle part cheaper than scattered differences
+	dropped := sw.Compare("acme holdings", "acme global holdings")
+	scattered := sw.Compare("acme holdings", "xcmx hxldxngs")
+	if dropped <= scattered {
+		t.Errorf("expected a dropped middle part (%f) to beat scattered edits (%f)", dropped, scattered)
+	}
+	// "acme " and "holdings" match (26) with one gap of 7 characters (1.5 + 6*0.5)
+	if want := (26 - 4.5) / 26 * math.Sqrt(13.0/20); math.Abs(dropped-want) > 1e-9 {
+		t.Errorf("expected %f, got %f", want, dropped)
+	}
+}
+
+func TestHybridRegistry(t *testing.T) {
+	reg, err := NewRegistryWithOptions(RegistryOptions{
+		NameBlend:  "0.5*mongeelkan(levenshtein) + 0.3*smith_waterman + 0.2*jaccard after lowercase",
+		FieldTypes: map[string]string{"company_name": "mongeelkan(jaro)"},
+	})
+	if err != nil {
+		t.Fatalf("NewRegistryWithOptions: %v", err)
+	}
+
+	if got := reg.GetByName("MongeElkan").Name(); got != "MongeElkan(JaroWinkler)" {
+		t.Errorf("expected Monge-Elkan over Jaro-Winkler, got %s", got)
+	}
+	if got := reg.GetByName("mongeelkan(levenshtein)").Name(); got != "MongeElkan(Levenshtein)" {
+		t.Errorf("expected Monge-Elkan over Levenshtein, got %s", got)
+	}
+	if got := reg.GetByFieldType("company_name").Name(); got != "MongeElkan(JaroWinkler)" {
+		t.Errorf("expected the field type alias to build Monge-Elkan, got %s", got)
+	}
+	if got := reg.SmithWaterman().Name(); got != "SmithWaterman" {
+		t.Errorf("expected Smith-Waterman, got %s", got)
+	}
+	if _, ok := reg.Lookup("smithwaterman(jaro)"); ok {
+		t.Error("expected Smith-Waterman not to take an inner function")
+	}
+
+	a, b := "Acme Global Holdings", "Global Acme"
+	if got, base := reg.Name().Compare(a, b), NewNameSimilarity().Compare(a, b); got <= base {
+		t.Errorf("expected the hybrid name blend (%f) to beat the default (%f)", got, base)
+	}
+}
@@ -0,0 +1,179 @@
+package similarity
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Default tolerances of the numeric and year comparators
+const (
+	DefaultNumericRelativeScale = 0.5 // Relative difference at which numbers score 0
+	DefaultYearTolerance        = 2   // Years apart at which years and ages score 0
+)
+
+// numberRegex matches a number with optional thousands separators and a magnitude word
+var numberRegex = regexp.MustCompile(`^([-+]?)\s*((?:\d[\d,_ ]*)?\.?\d*)\s*([a-z]*)$`)
+
+// commaGroupsRegex matches an integer part whose commas separate groups of three digits
+var commaGroupsRegex = regexp.MustCompile(`^\d{1,3}(?:,\d{3})+$`)
+
+// numberMagnitudes are the multipliers of the magnitude suffixes
+var numberMagnitudes = map[string]float64{
+	"":         1,
+	"k":        1e3,
+	"thousand": 1e3,
+	"m":        1e6,
+	"mm":       1e6,
+	"mn":       1e6,
+	"mil":      1e6,
+	"million":  1e6,
+	"b":        1e9,
+	"bn":       1e9,
+	"billion":  1e9,
+	"t":        1e12,
+	"tn":       1e12,
+	"trillion": 1e12,
+}
+
+// currencyReplacer removes currency symbols and codes
+var currencyReplacer = strings.NewReplacer("$", "", "€", "", "£", "", "¥", "", "usd", "", "eur", "", "gbp", "")
+
+// ParseNumber parses a number written with thousands separators, currency symbols or a
+// magnitude suffix, so "1,200,000", "$1.2M" and "1.2 million" all give 1200000. Commas
+// must separate groups of three digits; a decimal comma such as "1,5" does not parse.
+func ParseNumber(value string) (float64, bool) {
+	value = strings.TrimSpace(strings.ToLower(value))
+	if value == "" {
+		return 0, false
+	}
+	if n, err := strconv.ParseFloat(value, 64); err == nil {
+		return n, !math.IsNaN(n) && !math.IsInf(n, 0)
+	}
+
+	m := numberRegex.FindStringSubmatch(strings.TrimSpace(currencyReplacer.Replace(value)))
+	if m == nil {
+		return 0, false
+	}
+	magnitude, ok := numberMagnitudes[m[3]]
+	if !ok {
+		return 0, false
+	}
+	integer, _, _ := strings.Cut(m[2], ".")
+	if strings.Contains(integer, ",") && !commaGroupsRegex.MatchString(integer) {
+		return 0, false
+	}
+	digits := strings.NewReplacer(",", "", "_", "", " ", "").Replace(m[2])
+	n, err := strconv.ParseFloat(digits, 64)
+	if err != nil {
+		return 0, false
+	}
+	if m[1] == "-" {
+		n = -n
+	}
+	return n * magnitude, true
+}
+
+// NumericSimilarity compares numbers written in different formats by how far apart they
+// are. A pair scores by its absolute difference relative to Scale or its relative
+// difference relative to RelativeScale, whichever is closer, so small numbers can be
+// judged in units and large ones in proportion.
+type NumericSimilarity struct {
+	// Absolute difference at which numbers score 0; 0 scores the relative difference only
+	Scale float64
+	// Relative difference, as a share of the larger magnitude, at which numbers score 0;
+	// 0 scores the absolute difference only
+	RelativeScale float64
+}
+
+func NewNumericSimilarity() NumericSimilarity {
+	return NumericSimilarity{RelativeScale: DefaultNumericRelativeScale}
+}
+
+func (f NumericSimilarity) Compare(a, b string) float64 {
+	// Handle empty strings
+	if a == "" && b == "" {
+		return 1.0
+	}
+	if a == "" || b == "" {
+		return 0.0
+	}
+
+	// Values that are not numbers only match exactly
+	na, okA := ParseNumber(a)
+	nb, okB := ParseNumber(b)
+	if !okA || !okB {
+		if strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b)) {
+			return 1.0
+		}
+		return 0.0
+	}
+	if na == nb {
+		return 1.0
+	}
+
+	diff := math.Abs(na - nb)
+	score := 0.0
+	if f.Scale > 0 {
+		score = math.Max(score, 1-diff/f.Scale)
+	}
+	if f.RelativeScale > 0 {
+		relative := diff / math.Max(math.Abs(na), math.Abs(nb))
+		score = math.Max(score, 1-relative/f.RelativeScale)
+	}
+	return score
+}
+
+func (f NumericSimilarity) Name() string {
+	return "NumericSimilarity"
+}
+
+// YearSimilarity compares years or ages within a tolerance window: equal values score 1,
+// and the score falls linearly to 0 past Tolerance years. Dates are reduced to their year.
+type YearSimilarity struct {
+	// Years apart at which values score 0
+	Tolerance int
+	// Read numeric dates with the year last as day first
+	DayFirst bool
+}
+
+func NewYearSimilarity() YearSimilarity {
+	return YearSimilarity{Tolerance: DefaultYearTolerance}
+}
+
+func (f YearSimilarity) Compare(a, b string) float64 {
+	// Handle empty strings
+	if a == "" && b == "" {
+		return 1.0
+	}
+	if a == "" || b == "" {
+		return 0.0
+	}
+
+	ya, okA := f.parse(a)
+	yb, okB := f.parse(b)
+	if !okA || !okB {
+		if strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b)) {
+			return 1.0
+		}
+		return 0.0
+	}
+	return toleranceScore(abs(ya-yb), f.Tolerance)
+}
+
+// parse reads a year from a date or a whole number such as an age
+func (f YearSimilarity) parse(value string) (int, bool) {
+	if date, ok := ParseDate(value, f.DayFirst); ok {
+		return date.Year, true
+	}
+	n, ok := ParseNumber(value)
+	if !ok {
+		return 0, false
+	}
+	return int(math.Round(n)), true
+}
+
+func (f YearSimilarity) Name() string {
+	return "YearSimilarity"
+}
@@ -0,0 +1,861 @@
+package similarity
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Phonetic encoders accepted by PhoneticKey
+const (
+	PhoneticSoundex   = "soundex"
+	PhoneticMetaphone = "metaphone"
+	PhoneticNYSIIS    = "nysiis"
+)
+
+// SoundexSimilarity compares strings by the American Soundex codes of their tokens
+type SoundexSimilarity struct{}
+
+// Compare returns the share of tokens whose Soundex codes match
+func (f SoundexSimilarity) Compare(a, b string) float64 {
+	return comparePhonetic(a, b, func(token string) []string {
+		return []string{Soundex(token)}
+	})
+}
+
+func (f SoundexSimilarity) Name() string {
+	return "Soundex"
+}
+
+// DoubleMetaphoneSimilarity compares strings by the Double Metaphone codes of their
+// tokens. Two tokens match when any of their primary and alternate codes agree.
+type DoubleMetaphoneSimilarity struct{}
+
+// Compare returns the share of tokens whose Double Metaphone codes match
+func (f DoubleMetaphoneSimilarity) Compare(a, b string) float64 {
+	return comparePhonetic(a, b, func(token string) []string {
+		primary, alternate := DoubleMetaphone(token)
+		return []string{primary, alternate}
+	})
+}
+
+func (f DoubleMetaphoneSimilarity) Name() string {
+	return "DoubleMetaphone"
+}
+
+// NYSIISSimilarity compares strings by the NYSIIS codes of their tokens
+type NYSIISSimilarity struct{}
+
+// Compare returns the share of tokens whose NYSIIS codes match
+func (f NYSIISSimilarity) Compare(a, b string) float64 {
+	return comparePhonetic(a, b, func(token string) []string {
+		return []string{NYSIIS(token)}
+	})
+}
+
+func (f NYSIISSimilarity) Name() string {
+	return "NYSIIS"
+}
+
+// NewPhoneticSimilarity returns the comparator for a phonetic encoder, or nil if the
+// encoder is unknown
+func NewPhoneticSimilarity(encoder string) Function {
+	switch strings.ToLower(encoder) {
+	case PhoneticSoundex:
+		return SoundexSimilarity{}
+	case PhoneticMetaphone, "doublemetaphone", "double_metaphone":
+		return DoubleMetaphoneSimilarity{}
+	case PhoneticNYSIIS:
+		return NYSIISSimilarity{}
+	default:
+		return nil
+	}
+}
+
+// PhoneticKey encodes each token of a value with a phonetic encoder and joins the codes
+// with spaces, for use as a blocking key. Double Metaphone contributes its primary code.
+// Tokens without letters are kept as they are.
+func PhoneticKey(encoder, value string) string {
+	var encode func(string) string
+	switch strings.ToLower(encoder) {
+	case PhoneticSoundex:
+		encode = Soundex
+	case PhoneticMetaphone, "doublemetaphone", "double_metaphone":
+		encode = func(token string) string {
+			primary, _ := DoubleMetaphone(token)
+			return primary
+		}
+	case PhoneticNYSIIS:
+		encode = NYSIIS
+	default:
+		return value
+	}
+
+	tokens := tokenize(value)
+	codes := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		if code := encode(token); code != "" {
+			codes = append(codes, code)
+		} else {
+			codes = append(codes, token)
+		}
+	}
+	return strings.Join(codes, " ")
+}
+
+// comparePhonetic pairs up tokens that share a code and returns the Dice coefficient of
+// the matched tokens. Tokens without letters match only themselves.
+func comparePhonetic(a, b string, codes func(token string) []string) float64 {
+	tokensA, tokensB := tokenize(a), tokenize(b)
+	if len(tokensA) == 0 && len(tokensB) == 0 {
+		return 1.0
+	}
+	if len(tokensA) == 0 || len(tokensB) == 0 {
+		return 0.0
+	}
+
+	codesB := make([][]string, len(tokensB))
+	for i, token := range tokensB {
+		codesB[i] = tokenCodes(token, codes)
+	}
+	used := make([]bool, len(tokensB))
+	matches := 0
+	for _, token := range tokensA {
+		codesA := tokenCodes(token, codes)
+		for i := range tokensB {
+			if !used[i] && shareCode(codesA, codesB[i]) {
+				used[i] = true
+				matches++
+				break
+			}
+		}
+	}
+
+	return 2 * float64(matches) / float64(len(tokensA)+len(tokensB))
+}
+
+// tokenCodes returns the non-empty codes of a token, or the token itself if it has none
+func tokenCodes(token string, codes func(string) []string) []string {
+	var result []string
+	for _, code := range codes(token) {
+		if code != "" {
+			result = append(result, code)
+		}
+	}
+	if len(result) == 0 {
+		result = append(result, token)
+	}
+	return result
+}
+
+// shareCode reports whether two code lists have a code in common
+func shareCode(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// phoneticLetters returns the ASCII letters of a string in upper case
+func phoneticLetters(s string) []byte {
+	letters := make([]byte, 0, len(s))
+	for _, r := range strings.ToUpper(s) {
+		if r < unicode.MaxASCII && unicode.IsLetter(r) {
+			letters = append(letters, byte(r))
+		}
+	}
+	return letters
+}
+
+// Soundex returns the four character American Soundex code of a string, such as S530 for
+// both "Smith" and "Smyth". It returns an empty string if the string has no letters.
+func Soundex(s string) string {
+	letters := phoneticLetters(s)
+	if len(letters) == 0 {
+		return ""
+	}
+
+	code := []byte{letters[0]}
+	last := soundexDigit(letters[0])
+	for _, c := range letters[1:] {
+		// H and W do not separate letters with the same code
+		if c == 'H' || c == 'W' {
+			continue
+		}
+		digit := soundexDigit(c)
+		if digit == '0' {
+			last = digit
+			continue
+		}
+		if digit != last {
+			code = append(code, digit)
+			if len(code) == 4 {
+				break
+			}
+		}
+		last = digit
+	}
+	for len(code) < 4 {
+		code = append(code, '0')
+	}
+	return string(code)
+}
+
+// soundexDigit returns the Soundex digit of a letter, '0' for vowels
+func soundexDigit(c byte) byte {
+	switch c {
+	case 'B', 'F', 'P', 'V':
+		return '1'
+	case 'C', 'G', 'J', 'K', 'Q', 'S', 'X', 'Z':
+		return '2'
+	case 'D', 'T':
+		return '3'
+	case 'L':
+		return '4'
+	case 'M', 'N':
+		return '5'
+	case 'R':
+		return '6'
+	default:
+		return '0'
+	}
+}
+
+// NYSIIS returns the New York State Identification and Intelligence System code of a
+// string, truncated to six characters. It returns an empty string if the string has no
+// letters.
+func NYSIIS(s string) string {
+	name := phoneticLetters(s)
+	if len(name) == 0 {
+		return ""
+	}
+	isVowel := func(c byte) bool {
+		return c == 'A' || c == 'E' || c == 'I' || c == 'O' || c == 'U'
+	}
+
+	// Translate the first characters
+	switch {
+	case hasPrefix(name, "MAC"):
+		name[1] = 'C'
+	case hasPrefix(name, "KN"):
+		name[0] = 'N'
+	case hasPrefix(name, "K"):
+		name[0] = 'C'
+	case hasPrefix(name, "PH"), hasPrefix(name, "PF"):
+		name[0], name[1] = 'F', 'F'
+	case hasPrefix(name, "SCH"):
+		name[1], name[2] = 'S', 'S'
+	}
+
+	// Translate the last characters
+	switch {
+	case hasSuffix(name, "EE"), hasSuffix(name, "IE"):
+		name = append(name[:len(name)-2], 'Y')
+	case hasSuffix(name, "DT"), hasSuffix(name, "RT"), hasSuffix(name, "RD"), hasSuffix(name, "NT"), hasSuffix(name, "ND"):
+		name = append(name[:len(name)-2], 'D')
+	}
+
+	// Translate the remaining characters, skipping repeats
+	key := []byte{name[0]}
+	for i := 1; i < len(name); i++ {
+		next := byte(0)
+		if i+1 < len(name) {
+			next = name[i+1]
+		}
+		switch c := name[i]; {
+		case c == 'E' && next == 'V':
+			name[i], name[i+1] = 'A', 'F'
+		case isVowel(c):
+			name[i] = 'A'
+		case c == 'Q':
+			name[i] = 'G'
+		case c == 'Z':
+			name[i] = 'S'
+		case c == 'M':
+			name[i] = 'N'
+		case c == 'K' && next == 'N':
+			name[i] = 'N'
+		case c == 'K':
+			name[i] = 'C'
+		case c == 'S' && next == 'C' && i+2 < len(name) && name[i+2] == 'H':
+			name[i+1], name[i+2] = 'S', 'S'
+		case c == 'P' && next == 'H':
+			name[i], name[i+1] = 'F', 'F'
+		case c == 'H' && (!isVowel(name[i-1]) || !isVowel(next)):
+			name[i] = name[i-1]
+		case c == 'W' && isVowel(name[i-1]):
+			name[i] = name[i-1]
+		}
+		if name[i] != key[len(key)-1] {
+			key = append(key, name[i])
+		}
+	}
+
+	// Clean up the end of the key
+	if len(key) > 1 && key[len(key)-1] == 'S' {
+		key = key[:len(key)-1]
+	}
+	if hasSuffix(key, "AY") {
+		key = append(key[:len(key)-2], 'Y')
+	}
+	if len(key) > 1 && key[len(key)-1] == 'A' {
+		key = key[:len(key)-1]
+	}
+	if len(key) > 6 {
+		key = key[:6]
+	}
+	return string(key)
+}
+
+func hasPrefix(s []byte, prefix string) bool {
+	return strings.HasPrefix(string(s), prefix)
+}
+
+func hasSuffix(s []byte, suffix string) bool {
+	return strings.HasSuffix(string(s), suffix)
+}
+
+// DoubleMetaphone returns the primary and alternate Double Metaphone codes of a string,
+// each up to four characters. "Catherine" and "Kathryn" both encode to K0RN and KTRN.
+// It returns empty codes if the string has no letters.
+func DoubleMetaphone(s string) (string, string) {
+	m := &metaphone{value: string(phoneticLetters(s)), maxLength: 4}
+	if m.value == "" {
+		return "", ""
+	}
+	m.encode()
+	return m.primary.String(), m.alternate.String()
+}
+
+// metaphone holds the state of a Double Metaphone encoding, following Lawrence Philips'
+// original rules
+type metaphone struct {
+	value              string
+	maxLength          int
+	slavoGermanic      bool
+	primary, alternate strings.Builder
+}
+
+func (m *metaphone) encode() {
+	m.slavoGermanic = strings.ContainsAny(m.value, "WK") || strings.Contains(m.value, "CZ") || strings.Contains(m.value, "WITZ")
+
+	index := 0
+	// Skip silent first letters
+	if m.at(0, 2, "GN", "KN", "PN", "WR", "PS") {
+		index = 1
+	}
+	// An initial X is pronounced Z, which maps to S
+	if m.char(0) == 'X' {
+		m.add("S")
+		index = 1
+	}
+
+	for (m.primary.Len() < m.maxLength || m.alternate.Len() < m.maxLength) && index < len(m.value) {
+		switch m.char(index) {
+		case 'A', 'E', 'I', 'O', 'U', 'Y':
+			if index == 0 {
+				m.add("A")
+			}
+			index++
+		case 'B':
+			m.add("P")
+			index = m.skip(index, 'B')
+		case 'C':
+			index = m.handleC(index)
+		case 'D':
+			index = m.handleD(index)
+		case 'F':
+			m.add("F")
+			index = m.skip(index, 'F')
+		case 'G':
+			index = m.handleG(index)
+		case 'H':
+			index = m.handleH(index)
+		case 'J':
+			index = m.handleJ(index)
+		case 'K':
+			m.add("K")
+			index = m.skip(index, 'K')
+		case 'L':
+			index = m.handleL(index)
+		case 'M':
+			m.add("M")
+			if m.char(index+1) == 'M' || (m.at(index-1, 3, "UMB") && (index+1 == len(m.value)-1 || m.at(index+2, 2, "ER"))) {
+				index += 2
+			} else {
+				index++
+			}
+		case 'N':
+			m.add("N")
+			index = m.skip(index, 'N')
+		case 'P':
+			if m.char(index+1) == 'H' {
+				m.add("F")
+				index += 2
+			} else {
+				m.add("P")
+				if m.at(index+1, 1, "P", "B") {
+					index += 2
+				} else {
+					index++
+				}
+			}
+		case 'Q':
+			m.add("K")
+			index = m.skip(index, 'Q')
+		case 'R':
+			index = m.handleR(index)
+		case 'S':
+			index = m.handleS(index)
+		case 'T':
+			index = m.handleT(index)
+		case 'V':
+			m.add("F")
+			index = m.skip(index, 'V')
+		case 'W':
+			index = m.handleW(index)
+		case 'X':
+			index = m.handleX(index)
+		case 'Z':
+			index = m.handleZ(index)
+		default:
+			index++
+		}
+	}
+}
+
+func (m *metaphone) handleC(index int) int {
+	switch {
+	case m.germanicCH(index):
+		m.add("K")
+		return index + 2
+	case index == 0 && m.at(index, 6, "CAESAR"):
+		m.add("S")
+		return index + 2
+	case m.at(index, 2, "CH"):
+		return m.handleCH(index)
+	case m.at(index, 2, "CZ") && !m.at(index-2, 4, "WICZ"):
+		m.addBoth("S", "X")
+		return index + 2
+	case m.at(index+1, 3, "CIA"):
+		m.add("X")
+		return index + 3
+	case m.at(index, 2, "CC") && !(index == 1 && m.char(0) == 'M'):
+		if m.at(index+2, 1, "I", "E", "H") && !m.at(index+2, 2, "HU") {
+			if (index == 1 && m.char(index-1) == 'A') || m.at(index-1, 5, "UCCEE", "UCCES") {
+				m.add("KS")
+			} else {
+				m.add("X")
+			}
+			return index + 3
+		}
+		m.add("K")
+		return index + 2
+	case m.at(index, 2, "CK", "CG", "CQ"):
+		m.add("K")
+		return index + 2
+	case m.at(index, 2, "CI", "CE", "CY"):
+		if m.at(index, 3, "CIO", "CIE", "CIA") {
+			m.addBoth("S", "X")
+		} else {
+			m.add("S")
+		}
+		return index + 2
+	}
+
+	m.add("K")
+	switch {
+	case m.at(index+1, 2, " C", " Q", " G"):
+		return index + 3
+	case m.at(index+1, 1, "C", "K", "Q") && !m.at(index+1, 2, "CE", "CI"):
+		return index + 2
+	default:
+		return index + 1
+	}
+}
+
+// germanicCH reports a C pronounced K as in "Bacher" and "Macher"
+func (m *metaphone) germanicCH(index int) bool {
+	if m.at(index, 4, "CHIA") {
+		return true
+	}
+	if index <= 1 || isMetaphoneVowel(m.char(index-2)) || !m.at(index-1, 3, "ACH") {
+		return false
+	}
+	c := m.char(index + 2)
+	return (c != 'I' && c != 'E') || m.at(index-2, 6, "BACHER", "MACHER")
+}
+
+func (m *metaphone) handleCH(index int) int {
+	switch {
+	case index > 0 && m.at(index, 4, "CHAE"):
+		m.addBoth("K", "X")
+	case index == 0 && (m.at(index+1, 5, "HARAC", "HARIS") || m.at(index+1, 3, "HOR", "HYM", "HIA", "HEM")) && !m.at(0, 5, "CHORE"):
+		m.add("K")
+	case m.at(0, 4, "VAN ", "VON ") || m.at(0, 3, "SCH") ||
+		m.at(index-2, 6, "ORCHES", "ARCHIT", "ORCHID") ||
+		m.at(index+2, 1, "T", "S") ||
+		((m.at(index-1, 1, "A", "O", "U", "E") || index == 0) &&
+			(m.at(index+2, 1, "L", "R", "N", "M", "B", "H", "F", "V", "W", " ") || index+1 == len(m.value)-1)):
+		m.add("K")
+	case index > 0:
+		if m.at(0, 2, "MC") {
+			m.add("K")
+		} else {
+			m.addBoth("X", "K")
+		}
+	default:
+		m.add("X")
+	}
+	return index + 2
+}
+
+func (m *metaphone) handleD(index int) int {
+	switch {
+	case m.at(index, 2, "DG"):
+		if m.at(index+2, 1, "I", "E", "Y") {
+			m.add("J")
+			return index + 3
+		}
+		m.add("TK")
+		return index + 2
+	case m.at(index, 2, "DT", "DD"):
+		m.add("T")
+		return index + 2
+	default:
+		m.add("T")
+		return index + 1
+	}
+}
+
+func (m *metaphone) handleG(index int) int {
+	switch {
+	case m.char(index+1) == 'H':
+		return m.handleGH(index)
+	case m.char(index+1) == 'N':
+		switch {
+		case index == 1 && isMetaphoneVowel(m.char(0)) && !m.slavoGermanic:
+			m.addBoth("KN", "N")
+		case !m.at(index+2, 2, "EY") && m.char(index+1) != 'Y' && !m.slavoGermanic:
+			m.addBoth("N", "KN")
+		default:
+			m.add("KN")
+		}
+		return index + 2
+	case m.at(index+1, 2, "LI") && !m.slavoGermanic:
+		m.addBoth("KL", "L")
+		return index + 2
+	case index == 0 && (m.char(index+1) == 'Y' || m.at(index+1, 2, "ES", "EP", "EB", "EL", "EY", "IB", "IL", "IN", "IE", "EI", "ER")):
+		m.addBoth("K", "J")
+		return index + 2
+	case (m.at(index+1, 2, "ER") || m.char(index+1) == 'Y') &&
+		!m.at(0, 6, "DANGER", "RANGER", "MANGER") &&
+		!m.at(index-1, 1, "E", "I") && !m.at(index-1, 3, "RGY", "OGY"):
+		m.addBoth("K", "J")
+		return index + 2
+	case m.at(index+1, 1, "E", "I", "Y") || m.at(index-1, 4, "AGGI", "OGGI"):
+		switch {
+		case m.at(0, 4, "VAN ", "VON ") || m.at(0, 3, "SCH") || m.at(index+1, 2, "ET"):
+			m.add("K")
+		case m.at(index+1, 3, "IER"):
+			m.add("J")
+		default:
+			m.addBoth("J", "K")
+		}
+		return index + 2
+	case m.char(index+1) == 'G':
+		m.add("K")
+		return index + 2
+	default:
+		m.add("K")
+		return index + 1
+	}
+}
+
+func (m *metaphone) handleGH(index int) int {
+	switch {
+	case index > 0 && !isMetaphoneVowel(m.char(index-1)):
+		m.add("K")
+	case index == 0:
+		if m.char(index+2) == 'I' {
+			m.add("J")
+		} else {
+			m.add("K")
+		}
+	case (index > 1 && m.at(index-2, 1, "B", "H", "D")) ||
+		(index > 2 && m.at(index-3, 1, "B", "H", "D")) ||
+		(index > 3 && m.at(index-4, 1, "B", "H")):
+		// Silent, as in "Hugh" and "bough"
+	case index > 2 && m.char(index-1) == 'U' && m.at(index-3, 1, "C", "G", "L", "R", "T"):
+		m.add("F")
+	case m.char(index-1) != 'I':
+		m.add("K")
+	}
+	return index + 2
+}
+
+func (m *metaphone) handleH(index int) int {
+	// Keep an H between vowels or at the start before a vowel
+	if (index == 0 || isMetaphoneVowel(m.char(index-1))) && isMetaphoneVowel(m.char(index+1)) {
+		m.add("H")
+		return index + 2
+	}
+	return index + 1
+}
+
+func (m *metaphone) handleJ(index int) int {
+	if m.at(index, 4, "JOSE") || m.at(0, 4, "SAN ") {
+		if (index == 0 && m.char(index+4) == ' ') || len(m.value) == 4 || m.at(0, 4, "SAN ") {
+			m.add("H")
+		} else {
+			m.addBoth("J", "H")
+		}
+		return index + 1
+	}
+
+	switch {
+	case index == 0:
+		m.addBoth("J", "A")
+	case isMetaphoneVowel(m.char(index-1)) && !m.slavoGermanic && (m.char(index+1) == 'A' || m.char(index+1) == 'O'):
+		m.addBoth("J", "H")
+	case index == len(m.value)-1:
+		m.addPrimary("J")
+	case !m.at(index+1, 1, "L", "T", "K", "S", "N", "M", "B", "Z") && !m.at(index-1, 1, "S", "K", "L"):
+		m.add("J")
+	}
+	return m.skip(index, 'J')
+}
+
+func (m *metaphone) handleL(index int) int {
+	if m.char(index+1) != 'L' {
+		m.add("L")
+		return index + 1
+	}
+
+	// Spanish endings such as "Cabrillo" and "Gallegos" drop the L in the alternate
+	last := len(m.value) - 1
+	if (index == last-2 && m.at(index-1, 4, "ILLO", "ILLA", "ALLE")) ||
+		((m.at(last-1, 2, "AS", "OS") || m.at(last, 1, "A", "O")) && m.at(index-1, 4, "ALLE")) {
+		m.addPrimary("L")
+	} else {
+		m.add("L")
+	}
+	return index + 2
+}
+
+func (m *metaphone) handleR(index int) int {
+	// French endings such as "Rogier" drop the R in the primary
+	if index == len(m.value)-1 && !m.slavoGermanic && m.at(index-2, 2, "IE") && !m.at(index-4, 2, "ME", "MA") {
+		m.addAlternate("R")
+	} else {
+		m.add("R")
+	}
+	return m.skip(index, 'R')
+}
+
+func (m *metaphone) handleS(index int) int {
+	switch {
+	case m.at(index-1, 3, "ISL", "YSL"):
+		// Silent, as in "island" and "Carlisle"
+		return index + 1
+	case index == 0 && m.at(index, 5, "SUGAR"):
+		m.addBoth("X", "S")
+		return index + 1
+	case m.at(index, 2, "SH"):
+		if m.at(index+1, 4, "HEIM", "HOEK", "HOLM", "HOLZ") {
+			m.add("S")
+		} else {
+			m.add("X")
+		}
+		return index + 2
+	case m.at(index, 3, "SIO", "SIA") || m.at(index, 4, "SIAN"):
+		if m.slavoGermanic {
+			m.add("S")
+		} else {
+			m.addBoth("S", "X")
+		}
+		return index + 3
+	case (index == 0 && m.at(index+1, 1, "M", "N", "L", "W")) || m.at(index+1, 1, "Z"):
+		m.addBoth("S", "X")
+		if m.at(index+1, 1, "Z") {
+			return index + 2
+		}
+		return index + 1
+	case m.at(index, 2, "SC"):
+		return m.handleSC(index)
+	}
+
+	// French endings such as "Artois" drop the S in the primary
+	if index == len(m.value)-1 && m.at(index-2, 2, "AI", "OI") {
+		m.addAlternate("S")
+	} else {
+		m.add("S")
+	}
+	if m.at(index+1, 1, "S", "Z") {
+		return index + 2
+	}
+	return index + 1
+}
+
+func (m *metaphone) handleSC(index int) int {
+	switch {
+	case m.char(index+2) == 'H':
+		switch {
+		case m.at(index+3, 2, "ER", "EN"):
+			m.addBoth("X", "SK")
+		case m.at(index+3, 2, "OO", "UY", "ED", "EM"):
+			m.add("SK")
+		case index == 0 && !isMetaphoneVowel(m.char(3)) && m.char(3) != 'W':
+			m.addBoth("X", "S")
+		default:
+			m.add("X")
+		}
+	case m.at(index+2, 1, "I", "E", "Y"):
+		m.add("S")
+	default:
+		m.add("SK")
+	}
+	return index + 3
+}
+
+func (m *metaphone) handleT(index int) int {
+	switch {
+	case m.at(index, 4, "TION"), m.at(index, 3, "TIA", "TCH"):
+		m.add("X")
+		return index + 3
+	case m.at(index, 2, "TH") || m.at(index, 3, "TTH"):
+		if m.at(index+2, 2, "OM", "AM") || m.at(0, 4, "VAN ", "VON ") || m.at(0, 3, "SCH") {
+			m.add("T")
+		} else {
+			m.addBoth("0", "T")
+		}
+		return index + 2
+	}
+
+	m.add("T")
+	if m.at(index+1, 1, "T", "D") {
+		return index + 2
+	}
+	return index + 1
+}
+
+func (m *metaphone) handleW(index int) int {
+	switch {
+	case m.at(index, 2, "WR"):
+		m.add("R")
+		return index + 2
+	case index == 0 && (isMetaphoneVowel(m.char(index+1)) || m.at(index, 2, "WH")):
+		if isMetaphoneVowel(m.char(index + 1)) {
+			m.addBoth("A", "F")
+		} else {
+			m.add("A")
+		}
+		return index + 1
+	case (index == len(m.value)-1 && isMetaphoneVowel(m.char(index-1))) ||
+		m.at(index-1, 5, "EWSKI", "EWSKY", "OWSKI", "OWSKY") || m.at(0, 3, "SCH"):
+		m.addAlternate("F")
+		return index + 1
+	case m.at(index, 4, "WICZ", "WITZ"):
+		m.addBoth("TS", "FX")
+		return index + 4
+	default:
+		return index + 1
+	}
+}
+
+func (m *metaphone) handleX(index int) int {
+	if index == 0 {
+		m.add("S")
+		return index + 1
+	}
+	// French endings such as "Breaux" are silent
+	if !(index == len(m.value)-1 && (m.at(index-3, 3, "IAU", "EAU") || m.at(index-2, 2, "AU", "OU"))) {
+		m.add("KS")
+	}
+	if m.at(index+1, 1, "C", "X") {
+		return index + 2
+	}
+	return index + 1
+}
+
+func (m *metaphone) handleZ(index int) int {
+	if m.char(index+1) == 'H' {
+		m.add("J")
+		return index + 2
+	}
+	if m.at(index+1, 2, "ZO", "ZI", "ZA") || (m.slavoGermanic && index > 0 && m.char(index-1) != 'T') {
+		m.addBoth("S", "TS")
+	} else {
+		m.add("S")
+	}
+	return m.skip(index, 'Z')
+}
+
+// char returns the letter at index, or 0 outside the value
+func (m *metaphone) char(index int) byte {
+	if index < 0 || index >= len(m.value) {
+		return 0
+	}
+	return m.value[index]
+}
+
+// at reports whether the value holds one of the options at start
+func (m *metaphone) at(start, length int, options ...string) bool {
+	if start < 0 || start+length > len(m.value) {
+		return false
+	}
+	target := m.value[start : start+length]
+	for _, option := range options {
+		if target == option {
+			return true
+		}
+	}
+	return false
+}
+
+// skip steps past a letter and a doubled copy of it
+func (m *metaphone) skip(index int, letter byte) int {
+	if m.char(index+1) == letter {
+		return index + 2
+	}
+	return index + 1
+}
+
+func (m *metaphone) add(code string) {
+	m.addBoth(code, code)
+}
+
+func (m *metaphone) addBoth(primary, alternate string) {
+	m.addPrimary(primary)
+	m.addAlternate(alternate)
+}
+
+func (m *metaphone) addPrimary(code string) {
+	appendCode(&m.primary, code, m.maxLength)
+}
+
+func (m *metaphone) addAlternate(code string) {
+	appendCode(&m.alternate, code, m.maxLength)
+}
+
+// appendCode appends a code, truncating the result at maxLength
+func appendCode(b *strings.Builder, code string, maxLength int) {
+	if remaining := maxLength - b.Len(); remaining > 0 {
+		if len(code) > remaining {
+			code = code[:remaining]
+		}
+		b.WriteString(code)
+	}
+}
+
+func isMetaphoneVowel(c byte) bool {
+	switch c {
+	case 'A', 'E', 'I', 'O', 'U', 'Y':
+		return true
+	default:
+		return false
+	}
+}
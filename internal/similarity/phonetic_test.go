@@ -0,0 +1,75 @@
+package similarity
+
+import "testing"
+
+func TestPhoneticCodes(t *testing.T) {
+	tests := []struct {
+		encode func(string) string
+		input  string
+		want   string
+	}{
+		{Soundex, "Robert", "R163"},
+		{Soundex, "Rupert", "R163"},
+		{Soundex, "Ashcraft", "A261"},
+		{Soundex, "Pfister", "P236"},
+		{Soundex, "Smyth", "S530"},
+		{NYSIIS, "Knight", "NAGT"},
+		{NYSIIS, "Bishop", "BASAP"},
+		{NYSIIS, "Mitchell", "MATCAL"},
+		{Soundex, "123", ""},
+	}
+	for _, tt := range tests {
+		if got := tt.encode(tt.input); got != tt.want {
+			t.Errorf("%s: expected %q got %q", tt.input, tt.want, got)
+		}
+	}
+
+	for _, name := range []string{"Catherine", "Kathryn"} {
+		primary, alternate := DoubleMetaphone(name)
+		if primary != "K0RN" || alternate != "KTRN" {
+			t.Errorf("%s: expected K0RN/KTRN got %s/%s", name, primary, alternate)
+		}
+	}
+	if primary, alternate := DoubleMetaphone("Schmidt"); primary != "XMT" || alternate != "SMT" {
+		t.Errorf("Schmidt: expected XMT/SMT got %s/%s", primary, alternate)
+	}
+}
+
+func TestPhoneticSimilarity(t *testing.T) {
+	reg := NewRegistry()
+	tests := []struct {
+		function string
+		a, b     string
+		want     float64
+	}{
+		{"soundex", "John Smith", "Jon Smyth", 1.0},
+		{"metaphone", "Catherine Jones", "Kathryn Jones", 1.0},
+		{"nysiis", "Knight", "Night", 1.0},
+		{"soundex", "Smith", "Jones", 0.0},
+		{"metaphone", "Smith Holdings", "Smyth", 2.0 / 3},
+	}
+	for _, tt := range tests {
+		if score := reg.GetByName(tt.function).Compare(tt.a, tt.b); score != tt.want {
+			t.Errorf("%s: %s vs %s expected %.2f got %.2f", tt.function, tt.a, tt.b, tt.want, score)
+		}
+	}
+}
+
+func TestPhoneticNameSimilarity(t *testing.T) {
+	plain := NewNameSimilarity()
+	reg, err := NewRegistryWithOptions(RegistryOptions{NamePhonetic: "metaphone", NamePhoneticWeight: 0.3})
+	if err != nil {
+		t.Fatalf("NewRegistryWithOptions: %v", err)
+	}
+	phonetic := reg.Name()
+
+	for _, pair := range [][2]string{{"Smyth", "Smith"}, {"Catherine", "Kathryn"}} {
+		before, after := plain.Compare(pair[0], pair[1]), phonetic.Compare(pair[0], pair[1])
+		if after <= before {
+			t.Errorf("%s vs %s: expected the phonetic score %.2f to beat %.2f", pair[0], pair[1], after, before)
+		}
+	}
+	if score := phonetic.Compare("Smith", "Jones"); score > plain.Compare("Smith", "Jones") {
+		t.Errorf("unrelated names should not gain from phonetics, got %.2f", score)
+	}
+}
@@ -1,168 +1,452 @@
 package similarity
 
 import (
+	"fmt"
 	"strings"
+	"sync"
 )
 
-// Registry provides centralized access to different similarity functions for various field types
+// Registry provides centralized access to different similarity functions for various field
+// types. Comparators are looked up by name or alias, and field types map to comparator names.
 type Registry struct {
+	functions     map[string]Function // Comparators by lower-case name or alias
+	fieldTypes    map[string]string   // Field type to comparator name
+	fieldPatterns []FieldPattern      // Field name patterns, checked in order
+}
+
+// RegistryOptions customizes the comparators of a registry
+type RegistryOptions struct {
+	NamePhonetic         string            // Phonetic encoder folded into name similarity: soundex, metaphone or nysiis (default: none)
+	NamePhoneticWeight   float64           // Weight of the phonetic score in name similarity (default 0.2)
+	NameBlend            string            // Composite expression scoring preprocessed names (default DefaultNameBlend)
+	AddressBlend         string            // Composite expression scoring preprocessed addresses (default DefaultAddressBlend)
+	Composites           []CompositeSpec   // Comparators built from weighted blends of others, registered in order
+	FieldTypes           map[string]string // Field type aliases: field type to comparator name
+	FieldPatterns        []FieldPattern    // Field name patterns for fields without a type (default DefaultFieldPatterns)
+	NumericScale         float64           // Absolute difference at which numbers score 0 (default: relative difference only)
+	NumericRelativeScale float64           // Relative difference at which numbers score 0 (default DefaultNumericRelativeScale)
+	DateToleranceDays    int               // Days apart at which dates score 0 (default DefaultDateToleranceDays)
+	DateYearTolerance    int               // Years apart at which dates without a day score 0 (default DefaultDateYearTolerance)
+	DateDayFirst         bool              // Read numeric dates with the year last as day first, like 04/03/1985
+	YearTolerance        int               // Years apart at which years and ages score 0 (default DefaultYearTolerance)
+}
+
+// CompositeSpec declares a composite comparator
+type CompositeSpec struct {
+	Name       string
+	Expression string // For example "0.6*jarowinkler + 0.4*jaccard after lowercase"
+}
+
+// FieldPattern chooses a comparator for fields whose name contains one of the substrings
+type FieldPattern struct {
+	Function string
+	Contains []string
+}
+
+// DefaultFieldPatterns are the field name patterns used when none are configured
+var DefaultFieldPatterns = []FieldPattern{
+	{Function: "name", Contains: []string{"name", "company", "business", "organization"}},
+	{Function: "address", Contains: []string{"address", "street"}},
+	{Function: "phone", Contains: []string{"phone", "tel", "mobile", "cell", "fax"}},
+	{Function: "email", Contains: []string{"email"}},
+	{Function: "zipcode", Contains: []string{"zip", "postal"}},
+}
+
+// builtinFieldTypes maps the built-in field types to comparator names
+var builtinFieldTypes = map[string]string{
+	"name":            "name",
+	"business_name":   "name",
+	"person_name":     "name",
+	"company":         "name",
+	"organization":    "name",
+	"address":         "address",
+	"street":          "address",
+	"street_address":  "address",
+	"mailing_address": "address",
+	"phone":           "phone",
+	"phone_number":    "phone",
+	"telephone":       "phone",
+	"mobile":          "phone",
+	"cell":            "phone",
+	"fax":             "phone",
+	"email":           "email",
+	"email_address":   "email",
+	"zip":             "zipcode",
+	"zipcode":         "zipcode",
+	"postal_code":     "zipcode",
+	"postal":          "zipcode",
+	"date":            "date",
+	"birth_date":      "date",
+	"date_of_birth":   "date",
+	"dob":             "date",
+	"number":          "numeric",
+	"numeric":         "numeric",
+	"amount":          "numeric",
+	"currency":        "numeric",
+	"revenue":         "numeric",
+	"price":           "numeric",
+	"year":            "year",
+	"birth_year":      "year",
+	"age":             "year",
+	"identifier":      "exact",
+	"id":              "exact",
+	"exact":           "exact",
+}
+
+// custom holds the comparators and field types registered at runtime
+var (
+	customMutex      sync.RWMutex
+	customFunctions  = make(map[string]Function)
+	customFieldTypes = make(map[string]string)
+)
+
+// Register makes a custom comparator available under name to every registry created
+// afterwards, as a field type target, a composite term or a field pattern function.
+// Registering a built-in name or a name twice panics.
+func Register(name string, fn Function) {
+	name = strings.ToLower(name)
+	if _, ok := builtinFunctions()[name]; ok {
+		panic(fmt.Sprintf("similarity: cannot register built-in function %s", name))
+	}
+
+	customMutex.Lock()
+	defer customMutex.Unlock()
+	if _, ok := customFunctions[name]; ok {
+		panic(fmt.Sprintf("similarity: function %s registered twice", name))
+	}
+	customFunctions[name] = fn
+}
+
+// RegisterFieldType maps a field type to a comparator name in every registry created
+// afterwards, replacing any built-in mapping
+func RegisterFieldType(fieldType, name string) {
+	customMutex.Lock()
+	defer customMutex.Unlock()
+	customFieldTypes[strings.ToLower(fieldType)] = strings.ToLower(name)
+}
+
+// builtinFunctions returns the built-in comparators by name and alias
+func builtinFunctions() map[string]Function {
+	functions := genericFunctions()
+	add := func(fn Function, names ...string) {
+		for _, name := range names {
+			functions[name] = fn
+		}
+	}
+
 	// Field-specific comparators
-	name    Function
-	address Function
-	phone   Function
-	email   Function
-	zipCode Function
+	add(NewNameSimilarity(), "name", "namesimilarity")
+	add(NewAddressSimilarity(), "address", "addresssimilarity")
+	add(NewPhoneSimilarity(), "phone", "phonesimilarity", "phonenumber")
+	add(NewEmailSimilarity(), "email", "emailsimilarity")
+	add(NewZipCodeSimilarity(), "zipcode", "postalcode", "zip")
+
+	// Typed comparators
+	add(NewDateSimilarity(), "date", "datesimilarity")
+	add(NewNumericSimilarity(), "numeric", "number", "numericsimilarity")
+	add(NewYearSimilarity(), "year", "age", "yearsimilarity")
+
+	return functions
+}
+
+// genericFunctions returns the comparators that are not specific to a field type, which
+// the default name and address blends are built from
+func genericFunctions() map[string]Function {
+	functions := make(map[string]Function)
+	add := func(fn Function, names ...string) {
+		for _, name := range names {
+			functions[name] = fn
+		}
+	}
 
 	// Generic comparators
-	text        Function
-	exactMatch  Function
-	caseMatch   Function
-	jaroWinkler Function
-	levenshtein Function
-	jaccard     Function
-	cosine      Function
-	containedIn Function
+	add(NewJaroWinkler(), "text", "default") // Default text comparator
+	add(&ExactMatch{}, "exact", "exactmatch")
+	add(&CaseInsensitiveMatch{}, "case", "caseinsensitive", "caseinsensitivematch")
+	add(NewJaroWinkler(), "jaro", "jarowinkler")
+	add(&Levenshtein{}, "levenshtein", "editdistance")
+	add(&Jaccard{}, "jaccard", "token")
+	add(&Cosine{}, "cosine", "cosinesimilarity")
+	add(&ContainedIn{IgnoreCase: true}, "contains", "containedin")
+
+	// Phonetic comparators
+	add(SoundexSimilarity{}, "soundex")
+	add(DoubleMetaphoneSimilarity{}, "metaphone", "doublemetaphone", "double_metaphone")
+	add(NYSIISSimilarity{}, "nysiis")
+
+	// Hybrid comparators for multi-token values; "mongeelkan(<name>)" picks another inner comparator
+	add(NewMongeElkan(NewJaroWinkler()), "mongeelkan", "monge_elkan")
+	add(NewSmithWaterman(), "smithwaterman", "smith_waterman")
+
+	return functions
 }
 
 // NewRegistry creates a new registry with all supported similarity functions
 func NewRegistry() *Registry {
-	return &Registry{
-		// Field-specific comparators
-		name:    NewNameSimilarity(),
-		address: NewAddressSimilarity(),
-		phone:   NewPhoneSimilarity(),
-		email:   NewEmailSimilarity(),
-		zipCode: NewZipCodeSimilarity(),
-
-		// Generic comparators
-		text:        NewJaroWinkler(), // Default text comparator
-		exactMatch:  &ExactMatch{},
-		caseMatch:   &CaseInsensitiveMatch{},
-		jaroWinkler: NewJaroWinkler(),
-		levenshtein: &Levenshtein{},
-		jaccard:     &Jaccard{},
-		cosine:      &Cosine{},
-		containedIn: &ContainedIn{IgnoreCase: true},
+	r, err := NewRegistryWithOptions(RegistryOptions{})
+	if err != nil {
+		// The default options always build
+		panic(err)
+	}
+	return r
+}
+
+// NewRegistryWithOptions creates a new registry with all supported similarity functions,
+// customized by opts. The name and address blends may use built-in and registered
+// comparators; composites may also use the composites declared before them.
+func NewRegistryWithOptions(opts RegistryOptions) (*Registry, error) {
+	r := &Registry{
+		functions:     builtinFunctions(),
+		fieldTypes:    make(map[string]string, len(builtinFieldTypes)),
+		fieldPatterns: opts.FieldPatterns,
+	}
+	for fieldType, name := range builtinFieldTypes {
+		r.fieldTypes[fieldType] = name
+	}
+
+	// Add the comparators and field types registered at runtime
+	customMutex.RLock()
+	for name, fn := range customFunctions {
+		r.functions[name] = fn
+	}
+	for fieldType, name := range customFieldTypes {
+		r.fieldTypes[fieldType] = name
+	}
+	customMutex.RUnlock()
+
+	// Customize name and address similarity
+	name := NewNameSimilarity()
+	if opts.NameBlend != "" {
+		blend, err := r.compile("name blend", opts.NameBlend)
+		if err != nil {
+			return nil, err
+		}
+		name.blend = blend
+	}
+	if phonetic := NewPhoneticSimilarity(opts.NamePhonetic); phonetic != nil {
+		name.phonetic = phonetic
+		name.phoneticWeight = phoneticWeight(opts.NamePhoneticWeight)
+	}
+	address := NewAddressSimilarity()
+	if opts.AddressBlend != "" {
+		blend, err := r.compile("address blend", opts.AddressBlend)
+		if err != nil {
+			return nil, err
+		}
+		address.blend = blend
 	}
+	r.functions["name"], r.functions["namesimilarity"] = name, name
+	r.functions["address"], r.functions["addresssimilarity"] = address, address
+
+	// Customize the typed comparators
+	date := NewDateSimilarity()
+	if opts.DateToleranceDays > 0 {
+		date.ToleranceDays = opts.DateToleranceDays
+	}
+	if opts.DateYearTolerance > 0 {
+		date.YearTolerance = opts.DateYearTolerance
+	}
+	date.DayFirst = opts.DateDayFirst
+	numeric := NewNumericSimilarity()
+	numeric.Scale = opts.NumericScale
+	if opts.NumericRelativeScale > 0 {
+		numeric.RelativeScale = opts.NumericRelativeScale
+	}
+	year := NewYearSimilarity()
+	if opts.YearTolerance > 0 {
+		year.Tolerance = opts.YearTolerance
+	}
+	year.DayFirst = opts.DateDayFirst
+	r.functions["date"], r.functions["datesimilarity"] = date, date
+	r.functions["numeric"], r.functions["number"], r.functions["numericsimilarity"] = numeric, numeric, numeric
+	r.functions["year"], r.functions["age"], r.functions["yearsimilarity"] = year, year, year
+
+	// Register the composites
+	for _, spec := range opts.Composites {
+		if spec.Name == "" {
+			return nil, fmt.Errorf("composite similarity function %q has no name", spec.Expression)
+		}
+		composite, err := r.compile(spec.Name, spec.Expression)
+		if err != nil {
+			return nil, err
+		}
+		r.functions[strings.ToLower(spec.Name)] = composite
+	}
+
+	// Add the field type aliases and check every target exists
+	for fieldType, name := range opts.FieldTypes {
+		r.fieldTypes[strings.ToLower(fieldType)] = strings.ToLower(name)
+	}
+	for fieldType, name := range r.fieldTypes {
+		if _, ok := r.Lookup(name); !ok {
+			return nil, fmt.Errorf("field type %s uses unknown similarity function %s", fieldType, name)
+		}
+	}
+	for _, pattern := range r.fieldPatterns {
+		if _, ok := r.Lookup(pattern.Function); !ok {
+			return nil, fmt.Errorf("field pattern %v uses unknown similarity function %s", pattern.Contains, pattern.Function)
+		}
+	}
+
+	return r, nil
+}
+
+// compile parses a composite expression against the comparators registered so far
+func (r *Registry) compile(name, expression string) (*Composite, error) {
+	parsed, err := ParseExpression(expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s expression: %w", name, err)
+	}
+	return NewComposite(name, parsed, r.Lookup)
+}
+
+// Lookup returns the comparator registered under a name or alias, or a Monge-Elkan
+// comparator over a registered inner comparator named as "mongeelkan(<name>)"
+func (r *Registry) Lookup(name string) (Function, bool) {
+	return lookupFunction(r.functions, name)
 }
 
 // GetByName returns a similarity function by name
 func (r *Registry) GetByName(name string) Function {
-	name = strings.ToLower(name)
-	switch name {
-	case "name", "namesimilarity":
-		return r.name
-	case "address", "addresssimilarity":
-		return r.address
-	case "phone", "phonesimilarity", "phonenumber":
-		return r.phone
-	case "email", "emailsimilarity":
-		return r.email
-	case "zipcode", "postalcode", "zip":
-		return r.zipCode
-	case "text", "default":
-		return r.text
-	case "exact", "exactmatch":
-		return r.exactMatch
-	case "case", "caseinsensitive", "caseinsensitivematch":
-		return r.caseMatch
-	case "jaro", "jarowinkler":
-		return r.jaroWinkler
-	case "levenshtein", "editdistance":
-		return r.levenshtein
-	case "jaccard", "token":
-		return r.jaccard
-	case "cosine", "cosinesimilarity":
-		return r.cosine
-	case "contains", "containedin":
-		return r.containedIn
-	default:
-		// Default to text similarity
-		return r.text
-	}
-}
-
-// GetByFieldType returns the appropriate similarity function for a field type
+	if fn, ok := r.Lookup(name); ok {
+		return fn
+	}
+	// Default to text similarity
+	return r.Text()
+}
+
+// GetByFieldType returns the appropriate similarity function for a field type. A field
+// type that names a comparator uses it directly.
 func (r *Registry) GetByFieldType(fieldType string) Function {
 	fieldType = strings.ToLower(fieldType)
-	switch fieldType {
-	case "name", "business_name", "person_name", "company", "organization":
-		return r.name
-	case "address", "street", "street_address", "mailing_address":
-		return r.address
-	case "phone", "phone_number", "telephone", "mobile", "cell", "fax":
-		return r.phone
-	case "email", "email_address":
-		return r.email
-	case "zip", "zipcode", "postal_code", "postal":
-		return r.zipCode
-	default:
-		// Default to text similarity
-		return r.text
+	if name, ok := r.fieldTypes[fieldType]; ok {
+		return r.GetByName(name)
 	}
+	return r.GetByName(fieldType)
+}
+
+// GetByFieldName infers the similarity function for a field without a type from its name,
+// using the first field pattern whose substring the name contains
+func (r *Registry) GetByFieldName(fieldName string) Function {
+	patterns := r.fieldPatterns
+	if len(patterns) == 0 {
+		patterns = DefaultFieldPatterns
+	}
+
+	fieldName = strings.ToLower(fieldName)
+	for _, pattern := range patterns {
+		for _, substring := range pattern.Contains {
+			if substring != "" && strings.Contains(fieldName, strings.ToLower(substring)) {
+				return r.GetByName(pattern.Function)
+			}
+		}
+	}
+
+	// Default to generic text similarity
+	return r.Text()
 }
 
 // Name returns the name similarity function
 func (r *Registry) Name() Function {
-	return r.name
+	return r.functions["name"]
 }
 
 // Address returns the address similarity function
 func (r *Registry) Address() Function {
-	return r.address
+	return r.functions["address"]
 }
 
 // Phone returns the phone similarity function
 func (r *Registry) Phone() Function {
-	return r.phone
+	return r.functions["phone"]
 }
 
 // Email returns the email similarity function
 func (r *Registry) Email() Function {
-	return r.email
+	return r.functions["email"]
 }
 
 // ZipCode returns the zip code similarity function
 func (r *Registry) ZipCode() Function {
-	return r.zipCode
+	return r.functions["zipcode"]
 }
 
 // Text returns the generic text similarity function
 func (r *Registry) Text() Function {
-	return r.text
+	return r.functions["text"]
 }
 
 // ExactMatch returns the exact match function
 func (r *Registry) ExactMatch() Function {
-	return r.exactMatch
+	return r.functions["exact"]
 }
 
 // CaseInsensitiveMatch returns the case insensitive match function
 func (r *Registry) CaseInsensitiveMatch() Function {
-	return r.caseMatch
+	return r.functions["case"]
 }
 
 // JaroWinkler returns the Jaro-Winkler similarity function
 func (r *Registry) JaroWinkler() Function {
-	return r.jaroWinkler
+	return r.functions["jarowinkler"]
 }
 
 // Levenshtein returns the Levenshtein similarity function
 func (r *Registry) Levenshtein() Function {
-	return r.levenshtein
+	return r.functions["levenshtein"]
 }
 
 // Jaccard returns the Jaccard similarity function
 func (r *Registry) Jaccard() Function {
-	return r.jaccard
+	return r.functions["jaccard"]
 }
 
 // Cosine returns the Cosine similarity function
 func (r *Registry) Cosine() Function {
-	return r.cosine
+	return r.functions["cosine"]
 }
 
 // ContainedIn returns the ContainedIn similarity function
 func (r *Registry) ContainedIn() Function {
-	return r.containedIn
+	return r.functions["contains"]
+}
+
+// Soundex returns the Soundex similarity function
+func (r *Registry) Soundex() Function {
+	return r.functions["soundex"]
+}
+
+// DoubleMetaphone returns the Double Metaphone similarity function
+func (r *Registry) DoubleMetaphone() Function {
+	return r.functions["metaphone"]
+}
+
+// NYSIIS returns the NYSIIS similarity function
+func (r *Registry) NYSIIS() Function {
+	return r.functions["nysiis"]
+}
+
+// Date returns the date similarity function
+func (r *Registry) Date() Function {
+	return r.functions["date"]
+}
+
+// Numeric returns the numeric similarity function
+func (r *Registry) Numeric() Function {
+	return r.functions["numeric"]
+}
+
+// Year returns the year and age similarity function
+func (r *Registry) Year() Function {
+	return r.functions["year"]
+}
+
+// MongeElkan returns the Monge-Elkan similarity function over Jaro-Winkler
+func (r *Registry) MongeElkan() Function {
+	return r.functions["mongeelkan"]
+}
+
+// SmithWaterman returns the Smith-Waterman similarity function
+func (r *Registry) SmithWaterman() Function {
+	return r.functions["smithwaterman"]
 }
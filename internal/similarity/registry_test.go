@@ -0,0 +1,112 @@
+package similarity
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// firstLetter scores 1 when two values start with the same letter
+type firstLetter struct{}
+
+func (firstLetter) Compare(a, b string) float64 {
+	if a != "" && b != "" && strings.EqualFold(a[:1], b[:1]) {
+		return 1
+	}
+	return 0
+}
+
+func (firstLetter) Name() string {
+	return "FirstLetter"
+}
+
+func TestRegisterAndFieldTypes(t *testing.T) {
+	Register("test_first_letter", firstLetter{})
+
+	reg, err := NewRegistryWithOptions(RegistryOptions{
+		FieldTypes:    map[string]string{"ticker": "test_first_letter", "legal_name": "name"},
+		FieldPatterns: []FieldPattern{{Function: "exact", Contains: []string{"code"}}},
+	})
+	if err != nil {
+		t.Fatalf("NewRegistryWithOptions: %v", err)
+	}
+	if got := reg.GetByName("TEST_FIRST_LETTER").Name(); got != "FirstLetter" {
+		t.Errorf("expected the registered function by name, got %s", got)
+	}
+	if got := reg.GetByFieldType("ticker").Name(); got != "FirstLetter" {
+		t.Errorf("expected the field type alias to use the registered function, got %s", got)
+	}
+	if got := reg.GetByFieldType("legal_name").Name(); got != "NameSimilarity" {
+		t.Errorf("expected the legal_name alias to use name similarity, got %s", got)
+	}
+	if got := reg.GetByFieldType("soundex").Name(); got != "Soundex" {
+		t.Errorf("expected a field type naming a function to use it, got %s", got)
+	}
+	if got := reg.GetByFieldName("country_code").Name(); got != "ExactMatch" {
+		t.Errorf("expected the configured field pattern, got %s", got)
+	}
+	if got := reg.GetByFieldName("company_name").Name(); got != "JaroWinkler" {
+		t.Errorf("configured patterns replace the defaults, got %s", got)
+	}
+	if got := NewRegistry().GetByFieldName("company_name").Name(); got != "NameSimilarity" {
+		t.Errorf("expected the default name pattern, got %s", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected registering a built-in name to panic")
+		}
+	}()
+	Register("jaccard", firstLetter{})
+}
+
+func TestCompositeExpressions(t *testing.T) {
+	parsed, err := ParseExpression("0.6*JaroWinkler + 0.4 * jaccard after lowercase, collapse_whitespace")
+	if err != nil {
+		t.Fatalf("ParseExpression: %v", err)
+	}
+	if len(parsed.Terms) != 2 || parsed.Terms[1] != (Term{Weight: 0.4, Function: "jaccard"}) || len(parsed.Preprocess) != 2 {
+		t.Errorf("unexpected expression: %+v", parsed)
+	}
+	for _, invalid := range []string{"", "x*jaccard", "-1*jaccard", "0.5*", "jaccard after shouting"} {
+		if _, err := ParseExpression(invalid); err == nil {
+			t.Errorf("expected an error for %q", invalid)
+		}
+	}
+
+	reg, err := NewRegistryWithOptions(RegistryOptions{
+		Composites: []CompositeSpec{
+			{Name: "loose", Expression: "0.5*exact + 0.5*jaccard after lowercase, strip_punctuation"},
+			{Name: "looser", Expression: "loose + contains"},
+		},
+		NameBlend: "jaccard",
+	})
+	if err != nil {
+		t.Fatalf("NewRegistryWithOptions: %v", err)
+	}
+	if score := reg.GetByName("loose").Compare("Acme, Corp", "acme corp"); score != 1 {
+		t.Errorf("expected preprocessing to equalize the values, got %.2f", score)
+	}
+	if score := reg.GetByName("loose").Compare("acme corp", "acme widgets"); math.Abs(score-1.0/6) > 1e-9 {
+		t.Errorf("expected half of the token overlap 1/3, got %.4f", score)
+	}
+	if got := reg.GetByFieldType("looser").Name(); got != "looser" {
+		t.Errorf("expected composites to be usable as field types, got %s", got)
+	}
+
+	// The name blend replaces the default weights
+	if score := reg.Name().Compare("acme widgets", "acme tools"); math.Abs(score-1.0/3) > 1e-9 {
+		t.Errorf("expected the Jaccard name blend, got %.4f", score)
+	}
+	defaults := NewRegistry().Name().Compare("acme widgets", "acme tools")
+	if blended := NewNameSimilarity().Compare("acme widgets", "acme tools"); blended != defaults {
+		t.Errorf("expected the default blend in the default registry, got %.4f and %.4f", blended, defaults)
+	}
+
+	if _, err := NewRegistryWithOptions(RegistryOptions{Composites: []CompositeSpec{{Name: "bad", Expression: "later"}, {Name: "later", Expression: "jaccard"}}}); err == nil {
+		t.Error("expected an error for a composite using one declared after it")
+	}
+	if _, err := NewRegistryWithOptions(RegistryOptions{FieldTypes: map[string]string{"ticker": "missing"}}); err == nil {
+		t.Error("expected an error for an alias of an unknown function")
+	}
+}
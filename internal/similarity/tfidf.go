@@ -0,0 +1,205 @@
+package similarity
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"github.com/TFMV/resolve/internal/fileutil"
+)
+
+// TFIDFVersion is the current version of the TF-IDF statistics format
+const TFIDFVersion = 1
+
+// DefaultSoftTFIDFThreshold is the Jaro-Winkler similarity at which two tokens count as the same
+const DefaultSoftTFIDFThreshold = 0.9
+
+// FieldCorpus holds the document frequencies of the tokens of one field
+type FieldCorpus struct {
+	Documents   int            `json:"documents"`   // Number of records with a value for the field
+	Frequencies map[string]int `json:"frequencies"` // Number of those records containing each token
+}
+
+// TFIDFStats holds the document frequencies of each field, built from a corpus of records
+type TFIDFStats struct {
+	Version   int                     `json:"version"`
+	CreatedAt time.Time               `json:"created_at"`
+	Fields    map[string]*FieldCorpus `json:"fields"`
+}
+
+// BuildTFIDFStats counts, for each field, how many records contain each token. Records map
+// field names to values; records without a value for a field do not count for it.
+func BuildTFIDFStats(records []map[string]string, fields []string) *TFIDFStats {
+	stats := &TFIDFStats{
+		Version:   TFIDFVersion,
+		CreatedAt: time.Now().UTC(),
+		Fields:    make(map[string]*FieldCorpus, len(fields)),
+	}
+	for _, field := range fields {
+		corpus := &FieldCorpus{Frequencies: make(map[string]int)}
+		for _, record := range records {
+			tokens := uniqueTokens(tokenize(record[field]))
+			if len(tokens) == 0 {
+				continue
+			}
+			corpus.Documents++
+			for _, token := range tokens {
+				corpus.Frequencies[token]++
+			}
+		}
+		stats.Fields[field] = corpus
+	}
+	return stats
+}
+
+// IDF returns the smoothed inverse document frequency of a token. Unseen tokens weigh the
+// most and tokens in every record the least, but never nothing.
+func (c *FieldCorpus) IDF(token string) float64 {
+	return math.Log(float64(c.Documents+1)/float64(c.Frequencies[token]+1)) + 1
+}
+
+// Function returns a soft TF-IDF comparator weighting tokens by the field's document
+// frequencies, or nil if the statistics do not cover the field
+func (s *TFIDFStats) Function(field string, threshold float64) Function {
+	if s == nil {
+		return nil
+	}
+	corpus, ok := s.Fields[field]
+	if !ok || corpus.Documents == 0 {
+		return nil
+	}
+	return NewSoftTFIDF(corpus, threshold)
+}
+
+// Save writes the statistics as JSON atomically
+func (s *TFIDFStats) Save(path string) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode TF-IDF statistics: %w", err)
+	}
+	if err := fileutil.WriteFile(path, data); err != nil {
+		return fmt.Errorf("failed to write TF-IDF statistics: %w", err)
+	}
+	return nil
+}
+
+// LoadTFIDFStats reads statistics written by Save. A missing file holds no statistics.
+func LoadTFIDFStats(path string) (*TFIDFStats, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TF-IDF statistics: %w", err)
+	}
+
+	var stats TFIDFStats
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return nil, fmt.Errorf("failed to parse TF-IDF statistics: %w", err)
+	}
+	if stats.Version != TFIDFVersion {
+		return nil, fmt.Errorf("unsupported TF-IDF statistics version %d (expected %d)", stats.Version, TFIDFVersion)
+	}
+	return &stats, nil
+}
+
+// SoftTFIDF is the soft TF-IDF similarity of Cohen, Ravikumar and Fienberg: the cosine of
+// the TF-IDF token vectors, where tokens also match close spellings by Jaro-Winkler. Rare
+// tokens carry most of the weight, so "acme" decides a match more than "corp" does.
+type SoftTFIDF struct {
+	corpus      *FieldCorpus
+	threshold   float64
+	jaroWinkler JaroWinkler
+}
+
+// NewSoftTFIDF creates a soft TF-IDF comparator. Tokens match when their Jaro-Winkler
+// similarity reaches the threshold (DefaultSoftTFIDFThreshold if outside (0, 1]); a
+// threshold of 1 gives plain TF-IDF cosine similarity.
+func NewSoftTFIDF(corpus *FieldCorpus, threshold float64) *SoftTFIDF {
+	if threshold <= 0 || threshold > 1 {
+		threshold = DefaultSoftTFIDFThreshold
+	}
+	return &SoftTFIDF{corpus: corpus, threshold: threshold, jaroWinkler: NewJaroWinkler()}
+}
+
+// Compare returns the soft TF-IDF similarity averaged over both directions, since each
+// token of one value is matched to its closest token of the other
+func (f *SoftTFIDF) Compare(a, b string) float64 {
+	tokensA, tokensB := tokenize(a), tokenize(b)
+	if len(tokensA) == 0 && len(tokensB) == 0 {
+		return 1.0
+	}
+	if len(tokensA) == 0 || len(tokensB) == 0 {
+		return 0.0
+	}
+
+	weightsA, weightsB := f.weights(tokensA), f.weights(tokensB)
+	score := (f.directed(weightsA, weightsB) + f.directed(weightsB, weightsA)) / 2
+	return math.Min(score, 1.0)
+}
+
+func (f *SoftTFIDF) Name() string {
+	return "SoftTFIDF"
+}
+
+// tokenWeight is a token with its normalized TF-IDF weight
+type tokenWeight struct {
+	token  string
+	weight float64
+}
+
+// weights returns the unit-length TF-IDF vector of the tokens, in order of first appearance
+func (f *SoftTFIDF) weights(tokens []string) []tokenWeight {
+	counts := make(map[string]int, len(tokens))
+	for _, token := range tokens {
+		counts[token]++
+	}
+
+	var norm float64
+	vector := make([]tokenWeight, 0, len(counts))
+	for _, token := range uniqueTokens(tokens) {
+		weight := float64(counts[token]) * f.corpus.IDF(token)
+		vector = append(vector, tokenWeight{token: token, weight: weight})
+		norm += weight * weight
+	}
+	norm = math.Sqrt(norm)
+	for i := range vector {
+		vector[i].weight /= norm
+	}
+	return vector
+}
+
+// directed sums, over the tokens of a, the product of their weight, the weight of the
+// closest token of b and the closeness of the two
+func (f *SoftTFIDF) directed(a, b []tokenWeight) float64 {
+	var score float64
+	for _, x := range a {
+		best, bestWeight := 0.0, 0.0
+		for _, y := range b {
+			closeness := 1.0
+			if x.token != y.token {
+				closeness = f.jaroWinkler.Compare(x.token, y.token)
+			}
+			if closeness >= f.threshold && closeness > best {
+				best, bestWeight = closeness, y.weight
+			}
+		}
+		score += x.weight * bestWeight * best
+	}
+	return score
+}
+
+// uniqueTokens returns the distinct tokens in order of first appearance
+func uniqueTokens(tokens []string) []string {
+	seen := make(map[string]bool, len(tokens))
+	unique := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		if !seen[token] {
+			seen[token] = true
+			unique = append(unique, token)
+		}
+	}
+	return unique
+}
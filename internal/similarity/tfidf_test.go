@@ -0,0 +1,70 @@
+package similarity
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSoftTFIDF(t *testing.T) {
+	records := []map[string]string{
+		{"name": "acme corp"},
+		{"name": "globex corp"},
+		{"name": "initech corp"},
+		{"name": "umbrella corp"},
+		{"name": "the acme widget corp"},
+		{"name": ""},
+	}
+	stats := BuildTFIDFStats(records, []string{"name"})
+	corpus := stats.Fields["name"]
+	if corpus.Documents != 5 || corpus.Frequencies["corp"] != 5 || corpus.Frequencies["acme"] != 2 {
+		t.Fatalf("unexpected corpus: %+v", corpus)
+	}
+	if corpus.IDF("corp") >= corpus.IDF("acme") || corpus.IDF("acme") >= corpus.IDF("unseen") {
+		t.Error("expected rarer tokens to weigh more")
+	}
+
+	f := stats.Function("name", 0)
+	if f == nil || stats.Function("address", 0) != nil {
+		t.Fatal("expected a comparator for the name field only")
+	}
+
+	// A shared rare token counts for more than a shared common one
+	rare := f.Compare("acme corp", "acme holdings")
+	common := f.Compare("acme corp", "globex corp")
+	if rare <= common {
+		t.Errorf("expected acme to outweigh corp: %.2f vs %.2f", rare, common)
+	}
+	if plain := (&Jaccard{}).Compare("acme corp", "globex corp"); common >= plain {
+		t.Errorf("expected the common token to count for less than under Jaccard: %.2f vs %.2f", common, plain)
+	}
+
+	// Close spellings match softly, but not under plain TF-IDF
+	if score := f.Compare("acmee corp", "acme corp"); score < 0.9 {
+		t.Errorf("expected a typo in a token to still match, got %.2f", score)
+	}
+	if score := stats.Function("name", 1).Compare("acmee corp", "acme corp"); score > 0.5 {
+		t.Errorf("expected plain TF-IDF to miss the typo, got %.2f", score)
+	}
+	if f.Compare("acme corp", "acme corp") < 0.999 || f.Compare("acme", "") != 0 {
+		t.Error("unexpected scores for equal and empty values")
+	}
+	if f.Compare("the acme widget corp", "acme corp") != f.Compare("acme corp", "the acme widget corp") {
+		t.Error("expected a symmetric score")
+	}
+
+	// Statistics survive a round trip to disk
+	path := filepath.Join(t.TempDir(), "tfidf.json")
+	if err := stats.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := LoadTFIDFStats(path)
+	if err != nil {
+		t.Fatalf("LoadTFIDFStats: %v", err)
+	}
+	if loaded.Fields["name"].Frequencies["acme"] != 2 {
+		t.Errorf("unexpected loaded statistics: %+v", loaded.Fields["name"])
+	}
+	if missing, err := LoadTFIDFStats(filepath.Join(t.TempDir(), "missing.json")); missing != nil || err != nil {
+		t.Errorf("expected no statistics for a missing file, got %v, %v", missing, err)
+	}
+}
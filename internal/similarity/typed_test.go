@@ -0,0 +1,133 @@
+package similarity
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseDateAndNumber(t *testing.T) {
+	want := Date{Year: 1985, Month: 3, Day: 4}
+	for _, value := range []string{"1985-03-04", "03/04/1985", "3/4/85", "19850304", "March 4, 1985", "march 4 1985", "4 Mar 1985", "1985-03-04T10:00:00Z"} {
+		if got, ok := ParseDate(value, false); !ok || got != want {
+			t.Errorf("ParseDate(%q) = %v, %v; want %v", value, got, ok, want)
+		}
+	}
+	if got, ok := ParseDate("04/03/1985", true); !ok || got != want {
+		t.Errorf("expected day-first parsing, got %v", got)
+	}
+	if got, ok := ParseDate("25/12/1985", false); !ok || got != (Date{Year: 1985, Month: 12, Day: 25}) {
+		t.Errorf("expected a first number above 12 to be the day, got %v", got)
+	}
+	if got, ok := ParseDate("March 1985", false); !ok || got != (Date{Year: 1985, Month: 3}) {
+		t.Errorf("expected a month without a day, got %v", got)
+	}
+	if _, ok := ParseDate("1985-02-30", false); ok {
+		t.Error("expected an impossible date not to parse")
+	}
+
+	numbers := map[string]float64{
+		"1,200,000":    1200000,
+		"1.2M":         1200000,
+		"$1.2 million": 1200000,
+		"-15k":         -15000,
+		"1 500":        1500,
+		"3.5e3":        3500,
+		"1,234.5":      1234.5,
+	}
+	for value, want := range numbers {
+		if got, ok := ParseNumber(value); !ok || math.Abs(got-want) > 1e-6 {
+			t.Errorf("ParseNumber(%q) = %f, %v; want %f", value, got, ok, want)
+		}
+	}
+	if _, ok := ParseNumber("twelve"); ok {
+		t.Error("expected words not to parse as a number")
+	}
+	for _, value := range []string{"1,5", "12,34", "1,2345", "1,000,00", ",100"} {
+		if got, ok := ParseNumber(value); ok {
+			t.Errorf("expected commas outside groups of three digits to be rejected, ParseNumber(%q) = %f", value, got)
+		}
+	}
+}
+
+func TestDateSimilarity(t *testing.T) {
+	date := NewDateSimilarity()
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"1985-03-04", "03/04/1985", 1},
+		{"1985-03-04", "March 4, 1985", 1},
+		{"1985-03-04", "1985-04-03", DefaultTransposedScore},
+		{"1985-03-04", "1985-03-14", 1 - 10.0/31},
+		{"1985-03-04", "1986-03-04", 0},
+		{"1985", "1985-03-04", 1},
+		{"1985", "1986-07-01", 0.5},
+		{"1985", "1990", 0},
+		{"unknown", "UNKNOWN", 1},
+		{"unknown", "1985", 0},
+	}
+	for _, tt := range tests {
+		if got := date.Compare(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Compare(%q, %q) = %f, want %f", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestNumericAndYearSimilarity(t *testing.T) {
+	numeric := NewNumericSimilarity()
+	if got := numeric.Compare("1,200,000", "1.2M"); got != 1 {
+		t.Errorf("expected equal numbers in different formats to score 1, got %f", got)
+	}
+	if got := numeric.Compare("1,000,000", "1.2M"); math.Abs(got-(1-(0.2/1.2)/0.5)) > 1e-9 {
+		t.Errorf("unexpected relative score %f", got)
+	}
+	if got := numeric.Compare("100", "300"); got != 0 {
+		t.Errorf("expected distant numbers to score 0, got %f", got)
+	}
+	absolute := NumericSimilarity{Scale: 10}
+	if got := absolute.Compare("3", "5"); math.Abs(got-0.8) > 1e-9 {
+		t.Errorf("expected the absolute scale to score 0.8, got %f", got)
+	}
+
+	year := NewYearSimilarity()
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"1985", "1985-03-04", 1},
+		{"1985", "1986", 1 - 1.0/3},
+		{"42", "44", 1 - 2.0/3},
+		{"42", "45", 0},
+	}
+	for _, tt := range tests {
+		if got := year.Compare(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Compare(%q, %q) = %f, want %f", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestTypedFieldTypes(t *testing.T) {
+	reg, err := NewRegistryWithOptions(RegistryOptions{NumericScale: 10, DateDayFirst: true, YearTolerance: 5})
+	if err != nil {
+		t.Fatalf("NewRegistryWithOptions: %v", err)
+	}
+
+	if got := reg.GetByFieldType("date_of_birth").Name(); got != "DateSimilarity" {
+		t.Errorf("expected date similarity for date_of_birth, got %s", got)
+	}
+	if got := reg.GetByFieldType("revenue").Name(); got != "NumericSimilarity" {
+		t.Errorf("expected numeric similarity for revenue, got %s", got)
+	}
+	if got := reg.GetByFieldType("age").Name(); got != "YearSimilarity" {
+		t.Errorf("expected year similarity for age, got %s", got)
+	}
+	if got := reg.GetByFieldType("date").Compare("04/03/1985", "1985-03-04"); got != 1 {
+		t.Errorf("expected the configured day-first parsing, got %f", got)
+	}
+	if got := reg.GetByFieldType("number").Compare("3", "5"); math.Abs(got-0.8) > 1e-9 {
+		t.Errorf("expected the configured absolute scale, got %f", got)
+	}
+	if got := reg.GetByFieldType("year").Compare("1980", "1985"); got <= 0 {
+		t.Errorf("expected the configured year tolerance, got %f", got)
+	}
+}
@@ -0,0 +1,536 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MemoryStore is an in-process VectorStore that performs brute-force cosine search.
+// It is intended for tests, local experiments and small datasets.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	entities map[string]*EntityRecord
+	order    []string // Insertion order, used for stable pagination
+}
+
+// NewMemoryStore creates a new empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entities: make(map[string]*EntityRecord),
+	}
+}
+
+// Health always reports the in-memory store as healthy
+func (m *MemoryStore) Health(ctx context.Context) (bool, error) {
+	return true, nil
+}
+
+// AddEntity adds a new entity to the store
+func (m *MemoryStore) AddEntity(ctx context.Context, entity *EntityRecord) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.insert(entity, time.Now().Unix())
+	return entity.ID, nil
+}
+
+// BatchAddEntities adds multiple entities to the store
+func (m *MemoryStore) BatchAddEntities(ctx context.Context, entities []*EntityRecord) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().Unix()
+	ids := make([]string, len(entities))
+	for i, entity := range entities {
+		m.insert(entity, now)
+		ids[i] = entity.ID
+	}
+
+	return ids, nil
+}
+
+// insert stores a copy of the entity, assigning an ID and timestamps. Callers must hold the write lock.
+func (m *MemoryStore) insert(entity *EntityRecord, now int64) {
+	// Generate ID if not provided
+	if entity.ID == "" {
+		entity.ID = uuid.New().String()
+	}
+
+	// Set timestamps
+	if entity.CreatedAt == 0 {
+		entity.CreatedAt = now
+	}
+	entity.UpdatedAt = now
+
+	if _, exists := m.entities[entity.ID]; !exists {
+		m.order = append(m.order, entity.ID)
+	}
+	m.entities[entity.ID] = copyRecord(entity)
+}
+
+// SearchEntities returns the entities closest to the vector by cosine distance
+func (m *MemoryStore) SearchEntities(ctx context.Context, vector []float32, limit int, filterParams map[string]string) ([]*EntityRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	type candidate struct {
+		entity   *EntityRecord
+		distance float64
+	}
+
+	candidates := make([]candidate, 0, len(m.entities))
+	for _, id := range m.order {
+		entity := m.entities[id]
+		if !matchesFilters(entity, filterParams) {
+			continue
+		}
+		candidates = append(candidates, candidate{
+			entity:   entity,
+			distance: cosineDistance(vector, entity.Vector),
+		})
+	}
+
+	// Closest first, ties broken by ID for deterministic results
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].entity.ID < candidates[j].entity.ID
+	})
+
+	if limit > 0 && len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+
+	results := make([]*EntityRecord, len(candidates))
+	for i, c := range candidates {
+		entity := copyRecord(c.entity)
+		if entity.Metadata == nil {
+			entity.Metadata = make(map[string]interface{})
+		}
+		// Store distance in metadata, mirroring the Weaviate client
+		entity.Metadata["distance"] = c.distance
+		results[i] = entity
+	}
+
+	return results, nil
+}
+
+// GetEntity retrieves an entity by ID
+func (m *MemoryStore) GetEntity(ctx context.Context, id string) (*EntityRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entity, ok := m.entities[id]
+	if !ok {
+		return nil, fmt.Errorf("entity not found with ID: %s", id)
+	}
+
+	return copyRecord(entity), nil
+}
+
+// UpdateEntity updates an existing entity
+func (m *MemoryStore) UpdateEntity(ctx context.Context, entity *EntityRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Ensure ID is provided
+	if entity.ID == "" {
+		return fmt.Errorf("entity ID is required for updates")
+	}
+
+	existing, ok := m.entities[entity.ID]
+	if !ok {
+		return fmt.Errorf("failed to update entity: entity not found with ID: %s", entity.ID)
+	}
+
+	m.update(existing, entity, time.Now().Unix())
+	return nil
+}
+
+// BatchUpdateEntities updates multiple entities, adding any that do not exist yet
+func (m *MemoryStore) BatchUpdateEntities(ctx context.Context, entities []*EntityRecord) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().Unix()
+	ids := make([]string, len(entities))
+	for i, entity := range entities {
+		if existing, ok := m.entities[entity.ID]; ok {
+			m.update(existing, entity, now)
+		} else {
+			m.insert(entity, now)
+		}
+		ids[i] = entity.ID
+	}
+
+	return ids, nil
+}
+
+// update replaces a stored record, keeping its creation time and vector when the
+// update does not carry them. Callers must hold the write lock.
+func (m *MemoryStore) update(existing, entity *EntityRecord, now int64) {
+	entity.UpdatedAt = now
+	if entity.CreatedAt == 0 {
+		entity.CreatedAt = existing.CreatedAt
+	}
+
+	updated := copyRecord(entity)
+	if len(updated.Vector) == 0 {
+		updated.Vector = existing.Vector
+	}
+	m.entities[entity.ID] = updated
+}
+
+// DeleteEntity deletes an entity by ID
+func (m *MemoryStore) DeleteEntity(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.entities[id]; !ok {
+		return fmt.Errorf("failed to delete entity: entity not found with ID: %s", id)
+	}
+
+	delete(m.entities, id)
+	for i, existingID := range m.order {
+		if existingID == id {
+			m.order = append(m.order[:i], m.order[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+// ListEntities retrieves a page of entities in insertion order
+func (m *MemoryStore) ListEntities(ctx context.Context, offset int, limit int) ([]*EntityRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entities := make([]*EntityRecord, 0)
+	if offset >= len(m.order) {
+		return entities, nil
+	}
+
+	end := len(m.order)
+	if limit > 0 && offset+limit < end {
+		end = offset + limit
+	}
+
+	for _, id := range m.order[offset:end] {
+		entities = append(entities, copyRecord(m.entities[id]))
+	}
+
+	return entities, nil
+}
+
+// QueryEntities retrieves a page of entities matching the list options.
+// Without a sort order entities are returned in insertion order.
+func (m *MemoryStore) QueryEntities(ctx context.Context, opts ListOptions) ([]*EntityRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if opts.After != "" && (len(opts.Filters) > 0 || len(opts.Sort) > 0) {
+		return nil, fmt.Errorf("cursor pagination cannot be combined with filters or sorting")
+	}
+
+	// Apply filters
+	matched := make([]*EntityRecord, 0)
+	for _, id := range m.order {
+		entity := m.entities[id]
+		ok, err := matchesQuery(entity, opts.Filters)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, entity)
+		}
+	}
+
+	// Apply sort order
+	if len(opts.Sort) > 0 {
+		sort.SliceStable(matched, func(i, j int) bool {
+			for _, field := range opts.Sort {
+				cmp := compareValues(propertyValue(matched[i], field.Path), propertyValue(matched[j], field.Path))
+				if cmp == 0 {
+					continue
+				}
+				if field.Descending {
+					return cmp > 0
+				}
+				return cmp < 0
+			}
+			return false
+		})
+	}
+
+	// Skip to the cursor or offset
+	start := opts.Offset
+	if opts.After != "" {
+		start = -1
+		for i, entity := range matched {
+			if entity.ID == opts.After {
+				start = i + 1
+				break
+			}
+		}
+		if start < 0 {
+			return nil, fmt.Errorf("cursor entity not found with ID: %s", opts.After)
+		}
+	}
+
+	entities := make([]*EntityRecord, 0)
+	if start >= len(matched) {
+		return entities, nil
+	}
+
+	end := len(matched)
+	if opts.Limit > 0 && start+opts.Limit < end {
+		end = start + opts.Limit
+	}
+
+	for _, entity := range matched[start:end] {
+		entities = append(entities, projectRecord(entity, opts))
+	}
+
+	return entities, nil
+}
+
+// GetCount gets the total count of entities
+func (m *MemoryStore) GetCount(ctx context.Context) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return len(m.entities), nil
+}
+
+// matchesFilters reports whether the entity equals every filter value.
+// Paths of the form "metadata.<key>" are looked up in the entity metadata.
+func matchesFilters(entity *EntityRecord, filterParams map[string]string) bool {
+	for path, want := range filterParams {
+		if !valueMatches(propertyValue(entity, path), want) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesQuery reports whether the entity satisfies every query filter
+func matchesQuery(entity *EntityRecord, queryFilters []Filter) (bool, error) {
+	for _, filter := range queryFilters {
+		value := propertyValue(entity, filter.Path)
+
+		var ok bool
+		switch filter.Operator {
+		case OpEqual, "":
+			ok = valueMatches(value, filter.Value)
+		case OpNotEqual:
+			ok = !valueMatches(value, filter.Value)
+		case OpGreaterThan:
+			ok = value != nil && compareValues(value, filter.Value) > 0
+		case OpGreaterThanOrEqual:
+			ok = value != nil && compareValues(value, filter.Value) >= 0
+		case OpLessThan:
+			ok = value != nil && compareValues(value, filter.Value) < 0
+		case OpLessThanOrEqual:
+			ok = value != nil && compareValues(value, filter.Value) <= 0
+		case OpLike:
+			pattern, err := likePattern(filter.Value)
+			if err != nil {
+				return false, err
+			}
+			ok = value != nil && pattern.MatchString(fmt.Sprint(value))
+		default:
+			return false, fmt.Errorf("unsupported filter operator: %s", filter.Operator)
+		}
+
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// compareValues orders two property values, numerically when both are numbers.
+// Missing values sort before present ones.
+func compareValues(a, b interface{}) int {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return -1
+		default:
+			return 1
+		}
+	}
+
+	fa, aNumeric := toFloat(a)
+	fb, bNumeric := toFloat(b)
+	if aNumeric && bNumeric {
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		default:
+			return 0
+		}
+	}
+
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// toFloat converts numeric values and numeric strings to float64
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// likePattern converts a Weaviate-style wildcard pattern to a regular expression
+func likePattern(pattern string) (*regexp.Regexp, error) {
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.ReplaceAll(expr, `\*`, ".*")
+	expr = strings.ReplaceAll(expr, `\?`, ".")
+	return regexp.Compile("^" + expr + "$")
+}
+
+// valueMatches compares a stored value against a filter value. Array values
+// match when any element equals the filter value, as in Weaviate.
+func valueMatches(value interface{}, want string) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case string:
+		return v == want
+	case []string:
+		for _, item := range v {
+			if item == want {
+				return true
+			}
+		}
+		return false
+	case []interface{}:
+		for _, item := range v {
+			if valueMatches(item, want) {
+				return true
+			}
+		}
+		return false
+	default:
+		return fmt.Sprint(v) == want
+	}
+}
+
+// propertyValue returns the value of a named property of the entity
+func propertyValue(entity *EntityRecord, path string) interface{} {
+	if key, ok := strings.CutPrefix(path, "metadata."); ok {
+		if entity.Metadata == nil {
+			return nil
+		}
+		return entity.Metadata[key]
+	}
+
+	switch path {
+	case "id":
+		return entity.ID
+	case "created_at":
+		return entity.CreatedAt
+	case "updated_at":
+		return entity.UpdatedAt
+	default:
+		if value, ok := entity.Fields[path]; ok {
+			return value
+		}
+		return nil
+	}
+}
+
+// cosineDistance returns 1 - cosine similarity, matching Weaviate's cosine distance
+func cosineDistance(a, b []float32) float64 {
+	return 1.0 - CosineSimilarity(a, b)
+}
+
+// CosineSimilarity returns the cosine similarity of two vectors, or 0 if either is empty,
+// zero or of a different length
+func CosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+
+	var dot, magA, magB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		magA += float64(a[i]) * float64(a[i])
+		magB += float64(b[i]) * float64(b[i])
+	}
+	if magA == 0 || magB == 0 {
+		return 0
+	}
+
+	return dot / (math.Sqrt(magA) * math.Sqrt(magB))
+}
+
+// projectRecord copies the record, keeping only the fields requested in the list options
+func projectRecord(entity *EntityRecord, opts ListOptions) *EntityRecord {
+	c := copyRecord(entity)
+	if !opts.IncludeVector {
+		c.Vector = nil
+	}
+	if len(opts.Fields) > 0 {
+		fields := make(map[string]string, len(opts.Fields)*2)
+		for _, name := range opts.Fields {
+			if value, ok := c.Fields[name]; ok {
+				fields[name] = value
+			}
+			if value, ok := c.Fields[name+"_normalized"]; ok {
+				fields[name+"_normalized"] = value
+			}
+		}
+		c.Fields = fields
+	}
+	return c
+}
+
+// copyRecord returns a copy of the record so callers cannot mutate stored state
+func copyRecord(entity *EntityRecord) *EntityRecord {
+	c := *entity
+	if entity.Fields != nil {
+		c.Fields = make(map[string]string, len(entity.Fields))
+		for k, v := range entity.Fields {
+			c.Fields[k] = v
+		}
+	}
+	if entity.Vector != nil {
+		c.Vector = append([]float32(nil), entity.Vector...)
+	}
+	if entity.Metadata != nil {
+		c.Metadata = make(map[string]interface{}, len(entity.Metadata))
+		for k, v := range entity.Metadata {
+			c.Metadata[k] = v
+		}
+	}
+	return &c
+}
@@ -0,0 +1,178 @@
+package store
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMemoryStoreSearch(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+
+	entities := []*EntityRecord{
+		{ID: "a", Fields: map[string]string{"name": "Acme"}, Vector: []float32{1, 0}, Metadata: map[string]interface{}{"cluster_id": "c1"}},
+		{ID: "b", Fields: map[string]string{"name": "Globex"}, Vector: []float32{0, 1}, Metadata: map[string]interface{}{"cluster_id": "c2"}},
+		{ID: "c", Fields: map[string]string{"name": "Acme West"}, Vector: []float32{0.9, 0.1}, Metadata: map[string]interface{}{"cluster_id": "c1"}},
+	}
+	if _, err := m.BatchAddEntities(ctx, entities); err != nil {
+		t.Fatalf("BatchAddEntities: %v", err)
+	}
+
+	results, err := m.SearchEntities(ctx, []float32{1, 0}, 2, nil)
+	if err != nil {
+		t.Fatalf("SearchEntities: %v", err)
+	}
+	if len(results) != 2 || results[0].ID != "a" || results[1].ID != "c" {
+		t.Fatalf("unexpected search order: %+v", results)
+	}
+	if d, ok := results[0].Metadata["distance"].(float64); !ok || d > 1e-9 {
+		t.Errorf("expected zero distance for identical vector, got %v", results[0].Metadata["distance"])
+	}
+
+	filtered, err := m.SearchEntities(ctx, []float32{0, 1}, 10, map[string]string{"metadata.cluster_id": "c1"})
+	if err != nil {
+		t.Fatalf("SearchEntities with filter: %v", err)
+	}
+	if len(filtered) != 2 {
+		t.Errorf("expected 2 entities in cluster c1, got %d", len(filtered))
+	}
+
+	// Search results must not alias stored records
+	results[0].Metadata["cluster_id"] = "mutated"
+	stored, _ := m.GetEntity(ctx, "a")
+	if stored.Metadata["cluster_id"] != "c1" {
+		t.Errorf("stored record was mutated through a search result")
+	}
+}
+
+func TestMemoryStoreCRUD(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+
+	id, err := m.AddEntity(ctx, &EntityRecord{Fields: map[string]string{"name": "Acme"}, Vector: []float32{1, 0}})
+	if err != nil || id == "" {
+		t.Fatalf("AddEntity: id=%q err=%v", id, err)
+	}
+
+	if err := m.UpdateEntity(ctx, &EntityRecord{ID: id, Fields: map[string]string{"name": "Acme Corp"}}); err != nil {
+		t.Fatalf("UpdateEntity: %v", err)
+	}
+	got, err := m.GetEntity(ctx, id)
+	if err != nil {
+		t.Fatalf("GetEntity: %v", err)
+	}
+	if got.Fields["name"] != "Acme Corp" || len(got.Vector) != 2 {
+		t.Errorf("update lost data: %+v", got)
+	}
+
+	page, _ := m.ListEntities(ctx, 0, 10)
+	if len(page) != 1 {
+		t.Errorf("expected 1 listed entity, got %d", len(page))
+	}
+
+	if err := m.DeleteEntity(ctx, id); err != nil {
+		t.Fatalf("DeleteEntity: %v", err)
+	}
+	if count, _ := m.GetCount(ctx); count != 0 {
+		t.Errorf("expected empty store, got %d", count)
+	}
+	if _, err := m.GetEntity(ctx, id); err == nil {
+		t.Errorf("expected error for deleted entity")
+	}
+}
+
+func TestMemoryStoreQueryEntities(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+
+	for _, e := range []*EntityRecord{
+		{ID: "1", Fields: map[string]string{"name": "Acme", "zip": "10001"}, Vector: []float32{1}, Metadata: map[string]interface{}{"source": "crm"}},
+		{ID: "2", Fields: map[string]string{"name": "Globex", "zip": "94105"}, Metadata: map[string]interface{}{"source": "erp"}},
+		{ID: "3", Fields: map[string]string{"name": "Initech", "zip": "73301"}, Metadata: map[string]interface{}{"source": "crm"}},
+	} {
+		if _, err := m.AddEntity(ctx, e); err != nil {
+			t.Fatalf("AddEntity: %v", err)
+		}
+	}
+
+	// Cursor pagination follows insertion order
+	page, err := m.QueryEntities(ctx, ListOptions{Limit: 2})
+	if err != nil || len(page) != 2 || page[1].ID != "2" {
+		t.Fatalf("first page: %v %v", page, err)
+	}
+	if page[0].Vector != nil {
+		t.Errorf("vector returned without IncludeVector")
+	}
+	page, err = m.QueryEntities(ctx, ListOptions{After: page[1].ID, Limit: 2})
+	if err != nil || len(page) != 1 || page[0].ID != "3" {
+		t.Fatalf("second page: %v %v", page, err)
+	}
+
+	// Filters, sorting and projection
+	page, err = m.QueryEntities(ctx, ListOptions{
+		Filters: []Filter{{Path: "metadata.source", Operator: OpEqual, Value: "crm"}, {Path: "zip", Operator: OpGreaterThan, Value: "20000"}},
+		Sort:    []SortField{{Path: "name", Descending: true}},
+		Fields:  []string{"name"},
+	})
+	if err != nil || len(page) != 1 || page[0].ID != "3" {
+		t.Fatalf("filtered query: %v %v", page, err)
+	}
+	if _, ok := page[0].Fields["zip"]; ok {
+		t.Errorf("projection returned unrequested field: %v", page[0].Fields)
+	}
+
+	page, _ = m.QueryEntities(ctx, ListOptions{Filters: []Filter{{Path: "name", Operator: OpLike, Value: "*tech"}}})
+	if len(page) != 1 || page[0].ID != "3" {
+		t.Errorf("like filter: %v", page)
+	}
+
+	if _, err := m.QueryEntities(ctx, ListOptions{After: "1", Sort: []SortField{{Path: "name"}}}); err == nil {
+		t.Errorf("expected error combining cursor with sorting")
+	}
+}
+
+// offsetCappedStore rejects offset paging past a limit, like Weaviate's QUERY_MAXIMUM_RESULTS
+type offsetCappedStore struct {
+	*MemoryStore
+	maxResults int
+}
+
+func (s offsetCappedStore) ListEntities(ctx context.Context, offset int, limit int) ([]*EntityRecord, error) {
+	if offset+limit > s.maxResults {
+		return nil, nil
+	}
+	return s.MemoryStore.ListEntities(ctx, offset, limit)
+}
+
+func (s offsetCappedStore) QueryEntities(ctx context.Context, opts ListOptions) ([]*EntityRecord, error) {
+	if opts.Offset+opts.Limit > s.maxResults {
+		return nil, nil
+	}
+	return s.MemoryStore.QueryEntities(ctx, opts)
+}
+
+func TestScanEntities(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+	for i := 0; i < 25; i++ {
+		if _, err := m.AddEntity(ctx, &EntityRecord{Fields: map[string]string{"name": "Acme"}, Vector: []float32{1, 0}}); err != nil {
+			t.Fatalf("AddEntity: %v", err)
+		}
+	}
+
+	var scanned, pages int
+	err := ScanEntities(ctx, offsetCappedStore{MemoryStore: m, maxResults: 10}, ListOptions{Limit: 10, IncludeVector: true}, func(page []*EntityRecord) error {
+		pages++
+		scanned += len(page)
+		if len(page[0].Vector) == 0 {
+			t.Error("expected scanned entities to include vectors")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ScanEntities: %v", err)
+	}
+	if scanned != 25 || pages != 3 {
+		t.Errorf("expected 25 entities in 3 pages past the offset cap, got %d in %d", scanned, pages)
+	}
+}
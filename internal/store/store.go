@@ -0,0 +1,135 @@
+package store
+
+import (
+	"context"
+	"errors"
+)
+
+// EntityRecord represents an entity to be stored in the vector database.
+// Fields holds the raw value of every schema field together with its
+// normalized form under the "<field>_normalized" key.
+type EntityRecord struct {
+	ID        string                 `json:"id,omitempty"`
+	Fields    map[string]string      `json:"fields,omitempty"`
+	CreatedAt int64                  `json:"created_at,omitempty"`
+	UpdatedAt int64                  `json:"updated_at,omitempty"`
+	Vector    []float32              `json:"vector,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// Filter operators supported by QueryEntities
+const (
+	OpEqual              = "eq"
+	OpNotEqual           = "ne"
+	OpGreaterThan        = "gt"
+	OpGreaterThanOrEqual = "gte"
+	OpLessThan           = "lt"
+	OpLessThanOrEqual    = "lte"
+	OpLike               = "like" // Wildcard match, "*" matches any sequence of characters
+)
+
+// ValidOperator reports whether op is a supported filter operator
+func ValidOperator(op string) bool {
+	switch op {
+	case OpEqual, OpNotEqual, OpGreaterThan, OpGreaterThanOrEqual, OpLessThan, OpLessThanOrEqual, OpLike:
+		return true
+	default:
+		return false
+	}
+}
+
+// ErrUnsupportedFilter is returned by stores that cannot filter or sort on a path
+var ErrUnsupportedFilter = errors.New("unsupported filter")
+
+// BlockMetadataPrefix prefixes the metadata keys holding each blocking rule's key
+const BlockMetadataPrefix = "block_"
+
+// FilterableMetadataKeys are the metadata keys every store can filter on, together with
+// the blocking rule keys. The memory store filters on any metadata key; stores that keep
+// metadata as an opaque object, such as Weaviate, index only these keys as separate
+// properties and reject filters on other keys with ErrUnsupportedFilter.
+var FilterableMetadataKeys = []string{"cluster_id", "canopy_ids", "canopy_center", "lsh_buckets", "resolved_id", "source"}
+
+// Filter restricts a query to entities whose property at Path compares to Value.
+// Paths are schema field names, "id", "created_at", "updated_at" or "metadata.<key>";
+// see FilterableMetadataKeys for the metadata keys every store supports.
+type Filter struct {
+	Path     string
+	Operator string
+	Value    string
+}
+
+// SortField orders query results by a property
+type SortField struct {
+	Path       string
+	Descending bool
+}
+
+// ListOptions controls QueryEntities. After and Offset are alternative ways of
+// paging: After returns entities following the given ID and is only supported
+// without Filters and Sort.
+type ListOptions struct {
+	After         string      // Cursor: return entities after this ID
+	Offset        int         // Number of matching entities to skip
+	Limit         int         // Maximum number of entities to return
+	Filters       []Filter    // All filters must match
+	Sort          []SortField // Sort order, applied in sequence
+	Fields        []string    // Schema fields to return, empty returns all
+	IncludeVector bool        // Whether to return entity vectors
+}
+
+// VectorStore defines the storage operations used by the matching and clustering services.
+// Implementations must store the search distance under the "distance" metadata key of
+// every record returned by SearchEntities.
+type VectorStore interface {
+	// Health checks the connection to the store
+	Health(ctx context.Context) (bool, error)
+	// AddEntity adds a new entity and returns its ID
+	AddEntity(ctx context.Context, entity *EntityRecord) (string, error)
+	// BatchAddEntities adds multiple entities and returns their IDs
+	BatchAddEntities(ctx context.Context, entities []*EntityRecord) ([]string, error)
+	// SearchEntities returns the entities closest to the vector, restricted to
+	// records whose properties equal every value in filterParams
+	SearchEntities(ctx context.Context, vector []float32, limit int, filterParams map[string]string) ([]*EntityRecord, error)
+	// GetEntity retrieves an entity by ID
+	GetEntity(ctx context.Context, id string) (*EntityRecord, error)
+	// UpdateEntity updates an existing entity
+	UpdateEntity(ctx context.Context, entity *EntityRecord) error
+	// BatchUpdateEntities updates multiple entities and returns their IDs
+	BatchUpdateEntities(ctx context.Context, entities []*EntityRecord) ([]string, error)
+	// DeleteEntity deletes an entity by ID
+	DeleteEntity(ctx context.Context, id string) error
+	// ListEntities retrieves a page of entities
+	ListEntities(ctx context.Context, offset int, limit int) ([]*EntityRecord, error)
+	// QueryEntities retrieves a page of entities matching the list options
+	QueryEntities(ctx context.Context, opts ListOptions) ([]*EntityRecord, error)
+	// GetCount gets the total count of entities
+	GetCount(ctx context.Context) (int, error)
+}
+
+// ScanEntities pages through every stored entity and calls fn with each page. It follows
+// the After cursor, which unlike offset paging is not capped by stores such as Weaviate.
+// opts.Limit sets the page size (default 100); opts.After and opts.Offset are ignored, and
+// Filters and Sort cannot be combined with the cursor.
+func ScanEntities(ctx context.Context, s VectorStore, opts ListOptions, fn func([]*EntityRecord) error) error {
+	if opts.Limit <= 0 {
+		opts.Limit = 100
+	}
+	opts.After, opts.Offset = "", 0
+
+	for {
+		page, err := s.QueryEntities(ctx, opts)
+		if err != nil {
+			return err
+		}
+		if len(page) > 0 {
+			if err := fn(page); err != nil {
+				return err
+			}
+		}
+		if len(page) < opts.Limit {
+			return nil
+		}
+		opts.After = page[len(page)-1].ID
+	}
+}
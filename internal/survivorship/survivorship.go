@@ -0,0 +1,268 @@
+package survivorship
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Built-in survivorship rules
+const (
+	MostRecent     = "most_recent"     // Value of the most recently updated record
+	SourcePriority = "source_priority" // Value of the record from the most trusted source
+	Longest        = "longest"         // Longest value
+	MostComplete   = "most_complete"   // Value of the record with the most populated fields
+	MostFrequent   = "most_frequent"   // Most common value after normalization
+)
+
+// Record is a member record of a group
+type Record struct {
+	ID        string
+	Fields    map[string]string // Raw values, with normalized values under "<field>_normalized"
+	Source    string            // Source system, ranked by the source priority list
+	UpdatedAt int64             // Last update time in Unix seconds
+}
+
+// Candidate is a non-empty value of a field offered by a record
+type Candidate struct {
+	Record     *Record
+	Value      string // Raw value
+	Normalized string // Normalized value, used to decide which values agree
+}
+
+// Aggregator is a custom survivorship rule. It returns the surviving value of a field and
+// the IDs of the records it was taken from; ok is false when no value survives.
+type Aggregator func(field string, candidates []Candidate) (value string, sources []string, ok bool)
+
+// aggregators holds the registered custom rules
+var (
+	aggregatorsMutex sync.RWMutex
+	aggregators      = make(map[string]Aggregator)
+)
+
+// Register makes a custom aggregator available as a survivorship rule under name.
+// Registering a built-in rule name or a name twice panics.
+func Register(name string, aggregator Aggregator) {
+	if isBuiltIn(name) {
+		panic(fmt.Sprintf("survivorship: cannot register built-in rule %s", name))
+	}
+
+	aggregatorsMutex.Lock()
+	defer aggregatorsMutex.Unlock()
+	if _, ok := aggregators[name]; ok {
+		panic(fmt.Sprintf("survivorship: rule %s registered twice", name))
+	}
+	aggregators[name] = aggregator
+}
+
+// GoldenField is the surviving value of a field with its provenance
+type GoldenField struct {
+	Value   string   `json:"value"`
+	Rule    string   `json:"rule"`    // Rule that selected the value
+	Sources []string `json:"sources"` // IDs of the records contributing the value
+}
+
+// GoldenRecord is the best version of a group of records
+type GoldenRecord struct {
+	Fields  map[string]GoldenField `json:"fields"`
+	Records []string               `json:"records"` // IDs of every record in the group
+}
+
+// Options configures a survivorship engine
+type Options struct {
+	DefaultRule    string            // Rule for fields without their own rule (defaults to most_frequent)
+	Rules          map[string]string // Rule per field
+	SourcePriority []string          // Sources, most trusted first; unlisted sources rank last
+}
+
+// Engine builds golden records by applying a survivorship rule to every field
+type Engine struct {
+	defaultRule string
+	rules       map[string]string
+	priority    map[string]int
+}
+
+// NewEngine creates a survivorship engine
+func NewEngine(opts Options) *Engine {
+	if opts.DefaultRule == "" {
+		opts.DefaultRule = MostFrequent
+	}
+	priority := make(map[string]int, len(opts.SourcePriority))
+	for i, source := range opts.SourcePriority {
+		if _, ok := priority[source]; !ok {
+			priority[source] = i
+		}
+	}
+	return &Engine{defaultRule: opts.DefaultRule, rules: opts.Rules, priority: priority}
+}
+
+// Rule returns the survivorship rule applied to a field
+func (e *Engine) Rule(field string) string {
+	if rule, ok := e.rules[field]; ok && rule != "" {
+		return rule
+	}
+	return e.defaultRule
+}
+
+// Build builds the golden record of a group. Fields without a non-empty value in any
+// record are left out.
+func (e *Engine) Build(records []Record, fields []string) (*GoldenRecord, error) {
+	golden := &GoldenRecord{
+		Fields:  make(map[string]GoldenField, len(fields)),
+		Records: make([]string, len(records)),
+	}
+	for i := range records {
+		golden.Records[i] = records[i].ID
+	}
+
+	// Count populated fields per record for the most_complete rule
+	populated := make(map[*Record]int, len(records))
+	for i := range records {
+		for _, field := range fields {
+			if strings.TrimSpace(records[i].Fields[field]) != "" {
+				populated[&records[i]]++
+			}
+		}
+	}
+
+	for _, field := range fields {
+		candidates := Candidates(records, field)
+		if len(candidates) == 0 {
+			continue
+		}
+
+		rule := e.Rule(field)
+		var value string
+		var sources []string
+		if isBuiltIn(rule) {
+			value, sources = e.survive(rule, candidates, populated)
+		} else {
+			aggregatorsMutex.RLock()
+			aggregator, ok := aggregators[rule]
+			aggregatorsMutex.RUnlock()
+			if !ok {
+				return nil, fmt.Errorf("unknown survivorship rule for field %s: %s", field, rule)
+			}
+			var survived bool
+			if value, sources, survived = aggregator(field, candidates); !survived {
+				continue
+			}
+		}
+
+		golden.Fields[field] = GoldenField{Value: value, Rule: rule, Sources: sources}
+	}
+
+	return golden, nil
+}
+
+// Candidates returns the non-empty values of a field, in record order
+func Candidates(records []Record, field string) []Candidate {
+	var candidates []Candidate
+	for i := range records {
+		value := strings.TrimSpace(records[i].Fields[field])
+		if value == "" {
+			continue
+		}
+		normalized := records[i].Fields[field+"_normalized"]
+		if normalized == "" {
+			normalized = strings.ToLower(strings.Join(strings.Fields(value), " "))
+		}
+		candidates = append(candidates, Candidate{Record: &records[i], Value: value, Normalized: normalized})
+	}
+	return candidates
+}
+
+// survive applies a built-in rule. Ties are broken by how many records agree on the value,
+// then by recency, then by record order. The sources are every record agreeing with the
+// surviving value after normalization.
+func (e *Engine) survive(rule string, candidates []Candidate, populated map[*Record]int) (string, []string) {
+	frequency := make(map[string]int)
+	for _, candidate := range candidates {
+		frequency[candidate.Normalized]++
+	}
+
+	// Rank the candidates by the rule
+	ranked := make([]int, len(candidates))
+	for i := range ranked {
+		ranked[i] = i
+	}
+	sort.SliceStable(ranked, func(x, y int) bool {
+		a, b := candidates[ranked[x]], candidates[ranked[y]]
+		switch rule {
+		case MostRecent:
+			if a.Record.UpdatedAt != b.Record.UpdatedAt {
+				return a.Record.UpdatedAt > b.Record.UpdatedAt
+			}
+		case SourcePriority:
+			if pa, pb := e.sourceRank(a.Record.Source), e.sourceRank(b.Record.Source); pa != pb {
+				return pa < pb
+			}
+		case Longest:
+			if la, lb := utf8.RuneCountInString(a.Value), utf8.RuneCountInString(b.Value); la != lb {
+				return la > lb
+			}
+		case MostComplete:
+			if pa, pb := populated[a.Record], populated[b.Record]; pa != pb {
+				return pa > pb
+			}
+		}
+		if frequency[a.Normalized] != frequency[b.Normalized] {
+			return frequency[a.Normalized] > frequency[b.Normalized]
+		}
+		return a.Record.UpdatedAt > b.Record.UpdatedAt
+	})
+	winner := candidates[ranked[0]]
+
+	// Attribute the value to every agreeing record
+	var sources []string
+	for _, candidate := range candidates {
+		if candidate.Normalized == winner.Normalized {
+			sources = append(sources, candidate.Record.ID)
+		}
+	}
+
+	// The most common spelling represents a normalized value
+	value := winner.Value
+	if rule == MostFrequent {
+		value = mostCommonValue(candidates, winner.Normalized)
+	}
+
+	return value, sources
+}
+
+// sourceRank returns the position of a source in the priority list; unlisted sources rank last
+func (e *Engine) sourceRank(source string) int {
+	if rank, ok := e.priority[source]; ok {
+		return rank
+	}
+	return len(e.priority)
+}
+
+// mostCommonValue returns the most common raw value among candidates with a normalized
+// value, preferring the first seen on ties
+func mostCommonValue(candidates []Candidate, normalized string) string {
+	counts := make(map[string]int)
+	var best string
+	for _, candidate := range candidates {
+		if candidate.Normalized != normalized {
+			continue
+		}
+		counts[candidate.Value]++
+		if counts[candidate.Value] > counts[best] {
+			best = candidate.Value
+		}
+	}
+	return best
+}
+
+// isBuiltIn reports whether a rule is built in
+func isBuiltIn(rule string) bool {
+	switch rule {
+	case MostRecent, SourcePriority, Longest, MostComplete, MostFrequent:
+		return true
+	default:
+		return false
+	}
+}
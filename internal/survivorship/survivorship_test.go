@@ -0,0 +1,72 @@
+package survivorship
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testRecords = []Record{
+	{ID: "crm-1", Source: "crm", UpdatedAt: 100, Fields: map[string]string{"name": "Acme Corp", "name_normalized": "acme", "phone": "555-1234"}},
+	{ID: "erp-1", Source: "erp", UpdatedAt: 300, Fields: map[string]string{"name": "ACME", "name_normalized": "acme"}},
+	{ID: "web-1", Source: "web", UpdatedAt: 200, Fields: map[string]string{"name": "Acme Corporation Ltd", "name_normalized": "acme corporation", "phone": "555-9999", "email": "info@acme.com"}},
+}
+
+func TestBuiltInRules(t *testing.T) {
+	tests := []struct {
+		rule    string
+		value   string
+		sources []string
+	}{
+		// Normalized values agree, so both acme records count for "Acme Corp"
+		{MostFrequent, "Acme Corp", []string{"crm-1", "erp-1"}},
+		{MostRecent, "ACME", []string{"crm-1", "erp-1"}},
+		{SourcePriority, "Acme Corporation Ltd", []string{"web-1"}},
+		{Longest, "Acme Corporation Ltd", []string{"web-1"}},
+		{MostComplete, "Acme Corporation Ltd", []string{"web-1"}},
+	}
+
+	for _, tt := range tests {
+		engine := NewEngine(Options{Rules: map[string]string{"name": tt.rule}, SourcePriority: []string{"web", "crm"}})
+		golden, err := engine.Build(testRecords, []string{"name", "phone", "email"})
+		if err != nil {
+			t.Fatalf("%s: Build: %v", tt.rule, err)
+		}
+		got := golden.Fields["name"]
+		if got.Value != tt.value || got.Rule != tt.rule || !reflect.DeepEqual(got.Sources, tt.sources) {
+			t.Errorf("%s: expected %q from %v, got %+v", tt.rule, tt.value, tt.sources, got)
+		}
+
+		// Fields use the default rule and fields without values are left out
+		if golden.Fields["email"].Value != "info@acme.com" || golden.Fields["email"].Rule != MostFrequent {
+			t.Errorf("%s: unexpected email: %+v", tt.rule, golden.Fields["email"])
+		}
+		if len(golden.Records) != 3 {
+			t.Errorf("%s: expected 3 records, got %v", tt.rule, golden.Records)
+		}
+	}
+}
+
+func TestCustomAggregator(t *testing.T) {
+	Register("test_concat", func(field string, candidates []Candidate) (string, []string, bool) {
+		values := make([]string, len(candidates))
+		sources := make([]string, len(candidates))
+		for i, candidate := range candidates {
+			values[i], sources[i] = candidate.Value, candidate.Record.ID
+		}
+		return strings.Join(values, "; "), sources, true
+	})
+
+	golden, err := NewEngine(Options{Rules: map[string]string{"phone": "test_concat"}}).Build(testRecords, []string{"phone"})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	want := GoldenField{Value: "555-1234; 555-9999", Rule: "test_concat", Sources: []string{"crm-1", "web-1"}}
+	if !reflect.DeepEqual(golden.Fields["phone"], want) {
+		t.Errorf("expected %+v, got %+v", want, golden.Fields["phone"])
+	}
+
+	if _, err := NewEngine(Options{DefaultRule: "unknown"}).Build(testRecords, []string{"name"}); err == nil {
+		t.Error("expected an error for an unknown rule")
+	}
+}
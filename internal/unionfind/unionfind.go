@@ -0,0 +1,88 @@
+package unionfind
+
+import "sort"
+
+// UnionFind tracks which IDs belong to the same set
+type UnionFind struct {
+	parent map[string]string
+	rank   map[string]int
+}
+
+// New creates an empty union-find structure
+func New() *UnionFind {
+	return &UnionFind{
+		parent: make(map[string]string),
+		rank:   make(map[string]int),
+	}
+}
+
+// Add registers an ID as a singleton set if it is not already known
+func (u *UnionFind) Add(id string) {
+	if _, ok := u.parent[id]; !ok {
+		u.parent[id] = id
+	}
+}
+
+// Find returns the representative of the set containing id, adding it if needed
+func (u *UnionFind) Find(id string) string {
+	u.Add(id)
+
+	// Find the root
+	root := id
+	for u.parent[root] != root {
+		root = u.parent[root]
+	}
+
+	// Compress the path
+	for id != root {
+		next := u.parent[id]
+		u.parent[id] = root
+		id = next
+	}
+
+	return root
+}
+
+// Union merges the sets containing a and b
+func (u *UnionFind) Union(a, b string) {
+	rootA, rootB := u.Find(a), u.Find(b)
+	if rootA == rootB {
+		return
+	}
+
+	// Attach the shorter tree under the taller one
+	switch {
+	case u.rank[rootA] < u.rank[rootB]:
+		u.parent[rootA] = rootB
+	case u.rank[rootA] > u.rank[rootB]:
+		u.parent[rootB] = rootA
+	default:
+		u.parent[rootB] = rootA
+		u.rank[rootA]++
+	}
+}
+
+// Connected reports whether a and b are in the same set
+func (u *UnionFind) Connected(a, b string) bool {
+	return u.Find(a) == u.Find(b)
+}
+
+// Groups returns every set as a sorted list of IDs, ordered by their first ID
+func (u *UnionFind) Groups() [][]string {
+	byRoot := make(map[string][]string)
+	for id := range u.parent {
+		root := u.Find(id)
+		byRoot[root] = append(byRoot[root], id)
+	}
+
+	groups := make([][]string, 0, len(byRoot))
+	for _, members := range byRoot {
+		sort.Strings(members)
+		groups = append(groups, members)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i][0] < groups[j][0]
+	})
+
+	return groups
+}
@@ -0,0 +1,26 @@
+package unionfind
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnionFind(t *testing.T) {
+	u := New()
+	u.Union("a", "b")
+	u.Union("c", "d")
+	u.Union("b", "d")
+	u.Add("e")
+
+	if !u.Connected("a", "c") {
+		t.Errorf("expected a and c to be connected")
+	}
+	if u.Connected("a", "e") {
+		t.Errorf("expected e to be a singleton")
+	}
+
+	want := [][]string{{"a", "b", "c", "d"}, {"e"}}
+	if got := u.Groups(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Groups() = %v, want %v", got, want)
+	}
+}
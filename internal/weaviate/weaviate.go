@@ -3,9 +3,13 @@ package weaviate
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/TFMV/resolve/internal/config"
+	"github.com/TFMV/resolve/internal/store"
 	"github.com/go-openapi/strfmt"
 	"github.com/google/uuid"
 	"github.com/weaviate/weaviate-go-client/v4/weaviate"
@@ -21,35 +25,21 @@ type Client struct {
 	cfg            *config.Config
 	className      string
 	embeddingDim   int
+	fields         []config.FieldConfig
+	metadataKeys   []string // Metadata keys indexed as filterable properties
 	schemaInitDone bool
 }
 
-// EntityRecord represents an entity to be stored in the vector database
-type EntityRecord struct {
-	ID                string                 `json:"id,omitempty"`
-	Name              string                 `json:"name,omitempty"`
-	NameNormalized    string                 `json:"name_normalized,omitempty"`
-	Address           string                 `json:"address,omitempty"`
-	AddressNormalized string                 `json:"address_normalized,omitempty"`
-	City              string                 `json:"city,omitempty"`
-	CityNormalized    string                 `json:"city_normalized,omitempty"`
-	State             string                 `json:"state,omitempty"`
-	StateNormalized   string                 `json:"state_normalized,omitempty"`
-	Zip               string                 `json:"zip,omitempty"`
-	ZipNormalized     string                 `json:"zip_normalized,omitempty"`
-	Phone             string                 `json:"phone,omitempty"`
-	PhoneNormalized   string                 `json:"phone_normalized,omitempty"`
-	Email             string                 `json:"email,omitempty"`
-	EmailNormalized   string                 `json:"email_normalized,omitempty"`
-	CreatedAt         int64                  `json:"created_at,omitempty"`
-	UpdatedAt         int64                  `json:"updated_at,omitempty"`
-	Vector            []float32              `json:"vector,omitempty"`
-	Metadata          map[string]interface{} `json:"metadata,omitempty"`
-}
+// metadataPropertyPrefix prefixes the properties indexing filterable metadata keys, since
+// Weaviate cannot filter on the keys of the metadata object
+const metadataPropertyPrefix = "meta_"
+
+// Ensure Client satisfies the VectorStore interface
+var _ store.VectorStore = (*Client)(nil)
 
 // MatchResult represents a match result with score and explanation
 type MatchResult struct {
-	EntityRecord *EntityRecord          `json:"entity"`
+	EntityRecord *store.EntityRecord    `json:"entity"`
 	Score        float64                `json:"score"`
 	Distance     float64                `json:"distance"`
 	MatchID      string                 `json:"match_id"`
@@ -85,9 +75,22 @@ func NewClient(cfg *config.Config, embeddingDim int) (*Client, error) {
 		cfg:          cfg,
 		className:    cfg.Weaviate.ClassName,
 		embeddingDim: embeddingDim,
+		fields:       cfg.SchemaFields(),
+		metadataKeys: filterableMetadataKeys(cfg),
 	}, nil
 }
 
+// filterableMetadataKeys returns the metadata keys indexed as properties: the common
+// filterable keys and the key of every configured blocking rule
+func filterableMetadataKeys(cfg *config.Config) []string {
+	keys := append([]string(nil), store.FilterableMetadataKeys...)
+	for _, rule := range cfg.Clustering.Rules {
+		keys = append(keys, store.BlockMetadataPrefix+rule.Name)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // Health checks the connection to Weaviate
 func (c *Client) Health(ctx context.Context) (bool, error) {
 	liveChecker := c.client.Misc().LiveChecker()
@@ -98,15 +101,19 @@ func (c *Client) Health(ctx context.Context) (bool, error) {
 	return result, nil
 }
 
-// InitSchema initializes the schema for storing entities
+// InitSchema initializes the schema for storing entities.
+// If the class already exists, properties for newly declared schema fields are added to it.
 func (c *Client) InitSchema(ctx context.Context) error {
 	// Check if schema already exists
-	exists, err := c.classExists(ctx, c.className)
+	class, err := c.getClass(ctx, c.className)
 	if err != nil {
 		return fmt.Errorf("failed to check if class exists: %w", err)
 	}
 
-	if exists {
+	if class != nil {
+		if err := c.addMissingProperties(ctx, class); err != nil {
+			return err
+		}
 		c.schemaInitDone = true
 		return nil
 	}
@@ -115,26 +122,8 @@ func (c *Client) InitSchema(ctx context.Context) error {
 	entityClass := &models.Class{
 		Class:       c.className,
 		Description: fmt.Sprintf("Entity class for Resolve entity matching, created at %s", time.Now().Format(time.RFC3339)),
-		Properties: []*models.Property{
-			{Name: "name", DataType: []string{"text"}, Description: "Entity name"},
-			{Name: "name_normalized", DataType: []string{"text"}, Description: "Normalized entity name"},
-			{Name: "address", DataType: []string{"text"}, Description: "Entity address"},
-			{Name: "address_normalized", DataType: []string{"text"}, Description: "Normalized entity address"},
-			{Name: "city", DataType: []string{"text"}, Description: "Entity city"},
-			{Name: "city_normalized", DataType: []string{"text"}, Description: "Normalized entity city"},
-			{Name: "state", DataType: []string{"text"}, Description: "Entity state"},
-			{Name: "state_normalized", DataType: []string{"text"}, Description: "Normalized entity state"},
-			{Name: "zip", DataType: []string{"text"}, Description: "Entity ZIP code"},
-			{Name: "zip_normalized", DataType: []string{"text"}, Description: "Normalized entity ZIP code"},
-			{Name: "phone", DataType: []string{"text"}, Description: "Entity phone"},
-			{Name: "phone_normalized", DataType: []string{"text"}, Description: "Normalized entity phone"},
-			{Name: "email", DataType: []string{"text"}, Description: "Entity email"},
-			{Name: "email_normalized", DataType: []string{"text"}, Description: "Normalized entity email"},
-			{Name: "created_at", DataType: []string{"int"}, Description: "Creation timestamp"},
-			{Name: "updated_at", DataType: []string{"int"}, Description: "Update timestamp"},
-			{Name: "metadata", DataType: []string{"object"}, Description: "Additional metadata"},
-		},
-		Vectorizer: "none", // We'll provide our own vectors
+		Properties:  c.schemaProperties(),
+		Vectorizer:  "none", // We'll provide our own vectors
 		VectorIndexConfig: map[string]interface{}{
 			"distance": "cosine",
 		},
@@ -150,24 +139,175 @@ func (c *Client) InitSchema(ctx context.Context) error {
 	return nil
 }
 
-// classExists checks if a class exists in the schema
-func (c *Client) classExists(ctx context.Context, className string) (bool, error) {
+// schemaProperties returns the class properties for the configured entity schema
+func (c *Client) schemaProperties() []*models.Property {
+	properties := make([]*models.Property, 0, len(c.fields)*2+3)
+	for _, field := range c.fields {
+		description := field.Description
+		if description == "" {
+			description = "Entity " + field.Name
+		}
+		properties = append(properties,
+			&models.Property{Name: field.Name, DataType: []string{"text"}, Description: description},
+			&models.Property{Name: field.Name + "_normalized", DataType: []string{"text"}, Description: "Normalized " + description},
+		)
+	}
+
+	properties = append(properties,
+		&models.Property{Name: "created_at", DataType: []string{"int"}, Description: "Creation timestamp"},
+		&models.Property{Name: "updated_at", DataType: []string{"int"}, Description: "Update timestamp"},
+		&models.Property{Name: "metadata", DataType: []string{"object"}, Description: "Additional metadata"},
+	)
+	for _, key := range c.metadataKeys {
+		properties = append(properties,
+			&models.Property{Name: metadataPropertyPrefix + key, DataType: []string{"text[]"}, Description: "Filterable metadata " + key},
+		)
+	}
+	return properties
+}
+
+// addMissingProperties adds properties for schema fields that the existing class does not have yet
+func (c *Client) addMissingProperties(ctx context.Context, class *models.Class) error {
+	existing := make(map[string]bool, len(class.Properties))
+	for _, property := range class.Properties {
+		existing[property.Name] = true
+	}
+
+	for _, property := range c.schemaProperties() {
+		if existing[property.Name] {
+			continue
+		}
+		err := c.client.Schema().PropertyCreator().
+			WithClassName(c.className).
+			WithProperty(property).
+			Do(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to add property %s to schema: %w", property.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// getClass returns the class definition, or nil if the class does not exist
+func (c *Client) getClass(ctx context.Context, className string) (*models.Class, error) {
 	schema, err := c.client.Schema().Getter().Do(ctx)
 	if err != nil {
-		return false, fmt.Errorf("failed to get schema: %w", err)
+		return nil, fmt.Errorf("failed to get schema: %w", err)
 	}
 
 	for _, class := range schema.Classes {
 		if class.Class == className {
-			return true, nil
+			return class, nil
 		}
 	}
 
-	return false, nil
+	return nil, nil
+}
+
+// entityProperties converts an entity into object properties (excluding the vector)
+func (c *Client) entityProperties(entity *store.EntityRecord, includeCreatedAt bool) map[string]interface{} {
+	objProperties := make(map[string]interface{}, len(c.fields)*2+3)
+	for _, field := range c.fields {
+		objProperties[field.Name] = entity.Fields[field.Name]
+		objProperties[field.Name+"_normalized"] = entity.Fields[field.Name+"_normalized"]
+	}
+
+	if includeCreatedAt {
+		objProperties["created_at"] = entity.CreatedAt
+	}
+	objProperties["updated_at"] = entity.UpdatedAt
+
+	// Add metadata if provided, indexing the filterable keys
+	if entity.Metadata != nil {
+		objProperties["metadata"] = entity.Metadata
+		for _, key := range c.metadataKeys {
+			if value, ok := entity.Metadata[key]; ok && value != nil {
+				objProperties[metadataPropertyPrefix+key] = metadataTexts(value)
+			}
+		}
+	}
+
+	return objProperties
+}
+
+// metadataTexts converts a metadata value into the text list of its filterable property
+func metadataTexts(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []interface{}:
+		texts := make([]string, len(v))
+		for i, item := range v {
+			texts[i] = fmt.Sprint(item)
+		}
+		return texts
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
+
+// propertyPath maps a store filter or sort path to a Weaviate property. Metadata keys map
+// to their indexed property, and other metadata keys cannot be filtered on.
+func (c *Client) propertyPath(path string) (string, bool, error) {
+	key, ok := strings.CutPrefix(path, "metadata.")
+	if !ok {
+		return path, false, nil
+	}
+	for _, indexed := range c.metadataKeys {
+		if key == indexed {
+			return metadataPropertyPrefix + key, true, nil
+		}
+	}
+	return "", false, fmt.Errorf("%w: the Weaviate store only filters on metadata keys %s", store.ErrUnsupportedFilter, strings.Join(c.metadataKeys, ", "))
+}
+
+// fieldSelection returns the GraphQL fields selected for entity queries
+func (c *Client) fieldSelection(additional ...string) []graphql.Field {
+	return c.selectFields(c.fields, additional...)
+}
+
+// selectFields returns the GraphQL selection for the given schema fields
+func (c *Client) selectFields(schemaFields []config.FieldConfig, additional ...string) []graphql.Field {
+	fields := make([]graphql.Field, 0, len(schemaFields)*2+4)
+	for _, field := range schemaFields {
+		fields = append(fields,
+			graphql.Field{Name: field.Name},
+			graphql.Field{Name: field.Name + "_normalized"},
+		)
+	}
+
+	additionalFields := []graphql.Field{{Name: "id"}}
+	for _, name := range additional {
+		additionalFields = append(additionalFields, graphql.Field{Name: name})
+	}
+
+	return append(fields,
+		graphql.Field{Name: "created_at"},
+		graphql.Field{Name: "updated_at"},
+		graphql.Field{Name: "metadata"},
+		graphql.Field{Name: "_additional", Fields: additionalFields},
+	)
+}
+
+// parseFields extracts the schema field values from object properties
+func (c *Client) parseFields(props map[string]interface{}) map[string]string {
+	fields := make(map[string]string, len(c.fields)*2)
+	for _, field := range c.fields {
+		if value, ok := props[field.Name].(string); ok {
+			fields[field.Name] = value
+		}
+		if value, ok := props[field.Name+"_normalized"].(string); ok && value != "" {
+			fields[field.Name+"_normalized"] = value
+		}
+	}
+	return fields
 }
 
 // AddEntity adds a new entity to the vector database
-func (c *Client) AddEntity(ctx context.Context, entity *EntityRecord) (string, error) {
+func (c *Client) AddEntity(ctx context.Context, entity *store.EntityRecord) (string, error) {
 	if !c.schemaInitDone {
 		if err := c.InitSchema(ctx); err != nil {
 			return "", err
@@ -187,29 +327,7 @@ func (c *Client) AddEntity(ctx context.Context, entity *EntityRecord) (string, e
 	entity.UpdatedAt = now
 
 	// Prepare object properties (excluding vector)
-	objProperties := map[string]interface{}{
-		"name":               entity.Name,
-		"name_normalized":    entity.NameNormalized,
-		"address":            entity.Address,
-		"address_normalized": entity.AddressNormalized,
-		"city":               entity.City,
-		"city_normalized":    entity.CityNormalized,
-		"state":              entity.State,
-		"state_normalized":   entity.StateNormalized,
-		"zip":                entity.Zip,
-		"zip_normalized":     entity.ZipNormalized,
-		"phone":              entity.Phone,
-		"phone_normalized":   entity.PhoneNormalized,
-		"email":              entity.Email,
-		"email_normalized":   entity.EmailNormalized,
-		"created_at":         entity.CreatedAt,
-		"updated_at":         entity.UpdatedAt,
-	}
-
-	// Add metadata if provided
-	if entity.Metadata != nil {
-		objProperties["metadata"] = entity.Metadata
-	}
+	objProperties := c.entityProperties(entity, true)
 
 	// Add object to Weaviate
 	_, err := c.client.Data().Creator().
@@ -227,7 +345,7 @@ func (c *Client) AddEntity(ctx context.Context, entity *EntityRecord) (string, e
 }
 
 // BatchAddEntities adds multiple entities in a batch
-func (c *Client) BatchAddEntities(ctx context.Context, entities []*EntityRecord) ([]string, error) {
+func (c *Client) BatchAddEntities(ctx context.Context, entities []*store.EntityRecord) ([]string, error) {
 	if !c.schemaInitDone {
 		if err := c.InitSchema(ctx); err != nil {
 			return nil, err
@@ -253,29 +371,7 @@ func (c *Client) BatchAddEntities(ctx context.Context, entities []*EntityRecord)
 		entity.UpdatedAt = now
 
 		// Prepare object properties
-		objProperties := map[string]interface{}{
-			"name":               entity.Name,
-			"name_normalized":    entity.NameNormalized,
-			"address":            entity.Address,
-			"address_normalized": entity.AddressNormalized,
-			"city":               entity.City,
-			"city_normalized":    entity.CityNormalized,
-			"state":              entity.State,
-			"state_normalized":   entity.StateNormalized,
-			"zip":                entity.Zip,
-			"zip_normalized":     entity.ZipNormalized,
-			"phone":              entity.Phone,
-			"phone_normalized":   entity.PhoneNormalized,
-			"email":              entity.Email,
-			"email_normalized":   entity.EmailNormalized,
-			"created_at":         entity.CreatedAt,
-			"updated_at":         entity.UpdatedAt,
-		}
-
-		// Add metadata if provided
-		if entity.Metadata != nil {
-			objProperties["metadata"] = entity.Metadata
-		}
+		objProperties := c.entityProperties(entity, true)
 
 		// Add to batch
 		batcher = batcher.WithObjects(&models.Object{
@@ -301,7 +397,7 @@ func (c *Client) BatchAddEntities(ctx context.Context, entities []*EntityRecord)
 }
 
 // SearchEntities searches for entities by vector similarity
-func (c *Client) SearchEntities(ctx context.Context, vector []float32, limit int, filterParams map[string]string) ([]*EntityRecord, error) {
+func (c *Client) SearchEntities(ctx context.Context, vector []float32, limit int, filterParams map[string]string) ([]*store.EntityRecord, error) {
 	if !c.schemaInitDone {
 		if err := c.InitSchema(ctx); err != nil {
 			return nil, err
@@ -318,10 +414,10 @@ func (c *Client) SearchEntities(ctx context.Context, vector []float32, limit int
 		// Create a filter for each parameter
 		var whereFilters []*filters.WhereBuilder
 		for field, value := range filterParams {
-			whereFilter := filters.Where().
-				WithPath([]string{field}).
-				WithOperator(filters.Equal).
-				WithValueString(value)
+			whereFilter, err := c.buildWhereFilter(store.Filter{Path: field, Operator: store.OpEqual, Value: value})
+			if err != nil {
+				return nil, err
+			}
 			whereFilters = append(whereFilters, whereFilter)
 		}
 
@@ -336,30 +432,7 @@ func (c *Client) SearchEntities(ctx context.Context, vector []float32, limit int
 	}
 
 	// Build field selection
-	fields := []graphql.Field{
-		{Name: "name"},
-		{Name: "name_normalized"},
-		{Name: "address"},
-		{Name: "address_normalized"},
-		{Name: "city"},
-		{Name: "city_normalized"},
-		{Name: "state"},
-		{Name: "state_normalized"},
-		{Name: "zip"},
-		{Name: "zip_normalized"},
-		{Name: "phone"},
-		{Name: "phone_normalized"},
-		{Name: "email"},
-		{Name: "email_normalized"},
-		{Name: "created_at"},
-		{Name: "updated_at"},
-		{Name: "metadata"},
-		{Name: "_additional", Fields: []graphql.Field{
-			{Name: "id"},
-			{Name: "distance"},
-			{Name: "vector"},
-		}},
-	}
+	fields := c.fieldSelection("distance", "vector")
 
 	// Execute search
 	query := c.client.GraphQL().Get().
@@ -380,7 +453,7 @@ func (c *Client) SearchEntities(ctx context.Context, vector []float32, limit int
 	}
 
 	// Parse results
-	entities := make([]*EntityRecord, 0)
+	entities := make([]*store.EntityRecord, 0)
 	if len(result.Data["Get"].(map[string]interface{})[c.className].([]interface{})) == 0 {
 		return entities, nil
 	}
@@ -394,7 +467,7 @@ func (c *Client) SearchEntities(ctx context.Context, vector []float32, limit int
 }
 
 // FindMatches finds entity matches based on a query entity with custom scoring and explanation
-func (c *Client) FindMatches(ctx context.Context, queryEntity *EntityRecord, threshold float64, limit int) ([]*MatchResult, error) {
+func (c *Client) FindMatches(ctx context.Context, queryEntity *store.EntityRecord, threshold float64, limit int) ([]*MatchResult, error) {
 	// Search for similar entities using the vector similarity
 	entities, err := c.SearchEntities(ctx, queryEntity.Vector, limit, nil)
 	if err != nil {
@@ -452,7 +525,7 @@ func (c *Client) FindMatches(ctx context.Context, queryEntity *EntityRecord, thr
 }
 
 // GetEntity retrieves an entity by ID
-func (c *Client) GetEntity(ctx context.Context, id string) (*EntityRecord, error) {
+func (c *Client) GetEntity(ctx context.Context, id string) (*store.EntityRecord, error) {
 	if !c.schemaInitDone {
 		if err := c.InitSchema(ctx); err != nil {
 			return nil, err
@@ -478,55 +551,14 @@ func (c *Client) GetEntity(ctx context.Context, id string) (*EntityRecord, error
 	result := objects[0]
 
 	// Convert to EntityRecord
-	entity := &EntityRecord{
+	entity := &store.EntityRecord{
 		ID:     id,
 		Vector: result.Vector,
 	}
 
 	// Extract properties
 	if props, ok := result.Properties.(map[string]interface{}); ok {
-		if name, ok := props["name"].(string); ok {
-			entity.Name = name
-		}
-		if normalizedName, ok := props["name_normalized"].(string); ok {
-			entity.NameNormalized = normalizedName
-		}
-		if address, ok := props["address"].(string); ok {
-			entity.Address = address
-		}
-		if normalizedAddress, ok := props["address_normalized"].(string); ok {
-			entity.AddressNormalized = normalizedAddress
-		}
-		if city, ok := props["city"].(string); ok {
-			entity.City = city
-		}
-		if normalizedCity, ok := props["city_normalized"].(string); ok {
-			entity.CityNormalized = normalizedCity
-		}
-		if state, ok := props["state"].(string); ok {
-			entity.State = state
-		}
-		if normalizedState, ok := props["state_normalized"].(string); ok {
-			entity.StateNormalized = normalizedState
-		}
-		if zip, ok := props["zip"].(string); ok {
-			entity.Zip = zip
-		}
-		if normalizedZip, ok := props["zip_normalized"].(string); ok {
-			entity.ZipNormalized = normalizedZip
-		}
-		if phone, ok := props["phone"].(string); ok {
-			entity.Phone = phone
-		}
-		if normalizedPhone, ok := props["phone_normalized"].(string); ok {
-			entity.PhoneNormalized = normalizedPhone
-		}
-		if email, ok := props["email"].(string); ok {
-			entity.Email = email
-		}
-		if normalizedEmail, ok := props["email_normalized"].(string); ok {
-			entity.EmailNormalized = normalizedEmail
-		}
+		entity.Fields = c.parseFields(props)
 		if createdAt, ok := props["created_at"].(int64); ok {
 			entity.CreatedAt = createdAt
 		} else if createdAt, ok := props["created_at"].(float64); ok {
@@ -546,7 +578,7 @@ func (c *Client) GetEntity(ctx context.Context, id string) (*EntityRecord, error
 }
 
 // UpdateEntity updates an existing entity
-func (c *Client) UpdateEntity(ctx context.Context, entity *EntityRecord) error {
+func (c *Client) UpdateEntity(ctx context.Context, entity *store.EntityRecord) error {
 	if !c.schemaInitDone {
 		if err := c.InitSchema(ctx); err != nil {
 			return err
@@ -562,28 +594,7 @@ func (c *Client) UpdateEntity(ctx context.Context, entity *EntityRecord) error {
 	entity.UpdatedAt = time.Now().Unix()
 
 	// Prepare object properties
-	objProperties := map[string]interface{}{
-		"name":               entity.Name,
-		"name_normalized":    entity.NameNormalized,
-		"address":            entity.Address,
-		"address_normalized": entity.AddressNormalized,
-		"city":               entity.City,
-		"city_normalized":    entity.CityNormalized,
-		"state":              entity.State,
-		"state_normalized":   entity.StateNormalized,
-		"zip":                entity.Zip,
-		"zip_normalized":     entity.ZipNormalized,
-		"phone":              entity.Phone,
-		"phone_normalized":   entity.PhoneNormalized,
-		"email":              entity.Email,
-		"email_normalized":   entity.EmailNormalized,
-		"updated_at":         entity.UpdatedAt,
-	}
-
-	// Add metadata if provided
-	if entity.Metadata != nil {
-		objProperties["metadata"] = entity.Metadata
-	}
+	objProperties := c.entityProperties(entity, false)
 
 	// Update object
 	err := c.client.Data().Updater().
@@ -661,8 +672,8 @@ func (c *Client) GetCount(ctx context.Context) (int, error) {
 }
 
 // parseEntityFromResult converts a GraphQL result into an EntityRecord
-func (c *Client) parseEntityFromResult(obj map[string]interface{}) *EntityRecord {
-	entity := &EntityRecord{}
+func (c *Client) parseEntityFromResult(obj map[string]interface{}) *store.EntityRecord {
+	entity := &store.EntityRecord{}
 
 	// Extract additional properties
 	if additional, ok := obj["_additional"].(map[string]interface{}); ok {
@@ -686,49 +697,8 @@ func (c *Client) parseEntityFromResult(obj map[string]interface{}) *EntityRecord
 		}
 	}
 
-	// Extract standard properties
-	if name, ok := obj["name"].(string); ok {
-		entity.Name = name
-	}
-	if normalizedName, ok := obj["name_normalized"].(string); ok {
-		entity.NameNormalized = normalizedName
-	}
-	if address, ok := obj["address"].(string); ok {
-		entity.Address = address
-	}
-	if normalizedAddress, ok := obj["address_normalized"].(string); ok {
-		entity.AddressNormalized = normalizedAddress
-	}
-	if city, ok := obj["city"].(string); ok {
-		entity.City = city
-	}
-	if normalizedCity, ok := obj["city_normalized"].(string); ok {
-		entity.CityNormalized = normalizedCity
-	}
-	if state, ok := obj["state"].(string); ok {
-		entity.State = state
-	}
-	if normalizedState, ok := obj["state_normalized"].(string); ok {
-		entity.StateNormalized = normalizedState
-	}
-	if zip, ok := obj["zip"].(string); ok {
-		entity.Zip = zip
-	}
-	if normalizedZip, ok := obj["zip_normalized"].(string); ok {
-		entity.ZipNormalized = normalizedZip
-	}
-	if phone, ok := obj["phone"].(string); ok {
-		entity.Phone = phone
-	}
-	if normalizedPhone, ok := obj["phone_normalized"].(string); ok {
-		entity.PhoneNormalized = normalizedPhone
-	}
-	if email, ok := obj["email"].(string); ok {
-		entity.Email = email
-	}
-	if normalizedEmail, ok := obj["email_normalized"].(string); ok {
-		entity.EmailNormalized = normalizedEmail
-	}
+	// Extract schema fields
+	entity.Fields = c.parseFields(obj)
 	if createdAt, ok := obj["created_at"].(float64); ok {
 		entity.CreatedAt = int64(createdAt)
 	}
@@ -750,7 +720,7 @@ func (c *Client) parseEntityFromResult(obj map[string]interface{}) *EntityRecord
 }
 
 // ListEntities retrieves a paginated list of entities from Weaviate
-func (c *Client) ListEntities(ctx context.Context, offset int, limit int) ([]*EntityRecord, error) {
+func (c *Client) ListEntities(ctx context.Context, offset int, limit int) ([]*store.EntityRecord, error) {
 	if !c.schemaInitDone {
 		if err := c.InitSchema(ctx); err != nil {
 			return nil, err
@@ -758,29 +728,7 @@ func (c *Client) ListEntities(ctx context.Context, offset int, limit int) ([]*En
 	}
 
 	// Build field selection
-	fields := []graphql.Field{
-		{Name: "name"},
-		{Name: "name_normalized"},
-		{Name: "address"},
-		{Name: "address_normalized"},
-		{Name: "city"},
-		{Name: "city_normalized"},
-		{Name: "state"},
-		{Name: "state_normalized"},
-		{Name: "zip"},
-		{Name: "zip_normalized"},
-		{Name: "phone"},
-		{Name: "phone_normalized"},
-		{Name: "email"},
-		{Name: "email_normalized"},
-		{Name: "created_at"},
-		{Name: "updated_at"},
-		{Name: "metadata"},
-		{Name: "_additional", Fields: []graphql.Field{
-			{Name: "id"},
-			{Name: "vector"},
-		}},
-	}
+	fields := c.fieldSelection("vector")
 
 	// Execute query
 	query := c.client.GraphQL().Get().
@@ -796,7 +744,7 @@ func (c *Client) ListEntities(ctx context.Context, offset int, limit int) ([]*En
 	}
 
 	// Parse results
-	entities := make([]*EntityRecord, 0)
+	entities := make([]*store.EntityRecord, 0)
 	if len(result.Data["Get"].(map[string]interface{})[c.className].([]interface{})) == 0 {
 		return entities, nil
 	}
@@ -809,8 +757,158 @@ func (c *Client) ListEntities(ctx context.Context, offset int, limit int) ([]*En
 	return entities, nil
 }
 
+// QueryEntities retrieves a page of entities matching the list options.
+// Weaviate only supports the After cursor without filters and sorting.
+func (c *Client) QueryEntities(ctx context.Context, opts store.ListOptions) ([]*store.EntityRecord, error) {
+	if !c.schemaInitDone {
+		if err := c.InitSchema(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.After != "" && (len(opts.Filters) > 0 || len(opts.Sort) > 0) {
+		return nil, fmt.Errorf("cursor pagination cannot be combined with filters or sorting")
+	}
+
+	// Build field selection, restricted to the projected fields
+	fieldConfigs := c.fields
+	if len(opts.Fields) > 0 {
+		requested := make(map[string]bool, len(opts.Fields))
+		for _, name := range opts.Fields {
+			requested[name] = true
+		}
+		fieldConfigs = make([]config.FieldConfig, 0, len(opts.Fields))
+		for _, field := range c.fields {
+			if requested[field.Name] {
+				fieldConfigs = append(fieldConfigs, field)
+			}
+		}
+	}
+	var additional []string
+	if opts.IncludeVector {
+		additional = append(additional, "vector")
+	}
+	fields := c.selectFields(fieldConfigs, additional...)
+
+	query := c.client.GraphQL().Get().
+		WithClassName(c.className).
+		WithFields(fields...)
+	if opts.Limit > 0 {
+		query = query.WithLimit(opts.Limit)
+	}
+
+	// Add cursor or offset
+	if opts.After != "" {
+		query = query.WithAfter(opts.After)
+	} else if opts.Offset > 0 {
+		query = query.WithOffset(opts.Offset)
+	}
+
+	// Add filters
+	if len(opts.Filters) > 0 {
+		whereFilters := make([]*filters.WhereBuilder, 0, len(opts.Filters))
+		for _, filter := range opts.Filters {
+			whereFilter, err := c.buildWhereFilter(filter)
+			if err != nil {
+				return nil, err
+			}
+			whereFilters = append(whereFilters, whereFilter)
+		}
+
+		if len(whereFilters) > 1 {
+			query = query.WithWhere(filters.Where().
+				WithOperator(filters.And).
+				WithOperands(whereFilters))
+		} else {
+			query = query.WithWhere(whereFilters[0])
+		}
+	}
+
+	// Add sort order
+	if len(opts.Sort) > 0 {
+		sorts := make([]graphql.Sort, len(opts.Sort))
+		for i, field := range opts.Sort {
+			if strings.HasPrefix(field.Path, "metadata.") {
+				return nil, fmt.Errorf("%w: the Weaviate store cannot sort on metadata", store.ErrUnsupportedFilter)
+			}
+			order := graphql.Asc
+			if field.Descending {
+				order = graphql.Desc
+			}
+			sorts[i] = graphql.Sort{Path: []string{field.Path}, Order: order}
+		}
+		query = query.WithSort(sorts...)
+	}
+
+	// Execute query
+	result, err := query.Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		return nil, fmt.Errorf("failed to execute query: %s", result.Errors[0].Message)
+	}
+
+	// Parse results
+	entities := make([]*store.EntityRecord, 0)
+	get, ok := result.Data["Get"].(map[string]interface{})
+	if !ok {
+		return entities, nil
+	}
+	objects, _ := get[c.className].([]interface{})
+	for _, obj := range objects {
+		entities = append(entities, c.parseEntityFromResult(obj.(map[string]interface{})))
+	}
+
+	return entities, nil
+}
+
+// buildWhereFilter converts a store filter into a Weaviate where filter
+func (c *Client) buildWhereFilter(filter store.Filter) (*filters.WhereBuilder, error) {
+	path, metadata, err := c.propertyPath(filter.Path)
+	if err != nil {
+		return nil, err
+	}
+	where := filters.Where().WithPath([]string{path})
+
+	switch filter.Operator {
+	case store.OpEqual, "":
+		where = where.WithOperator(filters.Equal)
+	case store.OpNotEqual:
+		where = where.WithOperator(filters.NotEqual)
+	case store.OpGreaterThan:
+		where = where.WithOperator(filters.GreaterThan)
+	case store.OpGreaterThanOrEqual:
+		where = where.WithOperator(filters.GreaterThanEqual)
+	case store.OpLessThan:
+		where = where.WithOperator(filters.LessThan)
+	case store.OpLessThanOrEqual:
+		where = where.WithOperator(filters.LessThanEqual)
+	case store.OpLike:
+		return where.WithOperator(filters.Like).WithValueText(filter.Value), nil
+	default:
+		return nil, fmt.Errorf("unsupported filter operator: %s", filter.Operator)
+	}
+
+	// Indexed metadata is stored as text lists, which match when any element does
+	if metadata {
+		return where.WithValueText(filter.Value), nil
+	}
+
+	// Timestamps are stored as integers
+	if filter.Path == "created_at" || filter.Path == "updated_at" {
+		value, err := strconv.ParseInt(filter.Value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s filter value: %s", filter.Path, filter.Value)
+		}
+		return where.WithValueInt(value), nil
+	}
+
+	return where.WithValueString(filter.Value), nil
+}
+
 // BatchUpdateEntities updates multiple entities in a batch
-func (c *Client) BatchUpdateEntities(ctx context.Context, entities []*EntityRecord) ([]string, error) {
+func (c *Client) BatchUpdateEntities(ctx context.Context, entities []*store.EntityRecord) ([]string, error) {
 	if !c.schemaInitDone {
 		if err := c.InitSchema(ctx); err != nil {
 			return nil, err
@@ -833,29 +931,7 @@ func (c *Client) BatchUpdateEntities(ctx context.Context, entities []*EntityReco
 		entity.UpdatedAt = now
 
 		// Prepare object properties
-		objProperties := map[string]interface{}{
-			"name":               entity.Name,
-			"name_normalized":    entity.NameNormalized,
-			"address":            entity.Address,
-			"address_normalized": entity.AddressNormalized,
-			"city":               entity.City,
-			"city_normalized":    entity.CityNormalized,
-			"state":              entity.State,
-			"state_normalized":   entity.StateNormalized,
-			"zip":                entity.Zip,
-			"zip_normalized":     entity.ZipNormalized,
-			"phone":              entity.Phone,
-			"phone_normalized":   entity.PhoneNormalized,
-			"email":              entity.Email,
-			"email_normalized":   entity.EmailNormalized,
-			"created_at":         entity.CreatedAt,
-			"updated_at":         entity.UpdatedAt,
-		}
-
-		// Add metadata if provided
-		if entity.Metadata != nil {
-			objProperties["metadata"] = entity.Metadata
-		}
+		objProperties := c.entityProperties(entity, true)
 
 		// Add to batch
 		batcher = batcher.WithObjects(&models.Object{
@@ -0,0 +1,49 @@
+package weaviate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/TFMV/resolve/internal/config"
+	"github.com/TFMV/resolve/internal/store"
+)
+
+func TestFilterableMetadata(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Clustering.Rules = []config.BlockingRule{{Name: "zip"}}
+	c := &Client{metadataKeys: filterableMetadataKeys(cfg)}
+
+	path, metadata, err := c.propertyPath("metadata.cluster_id")
+	if err != nil || !metadata || path != "meta_cluster_id" {
+		t.Errorf("expected cluster_id to be indexed, got %q, %v, %v", path, metadata, err)
+	}
+	if path, _, err := c.propertyPath("metadata.resolved_id"); err != nil || path != "meta_resolved_id" {
+		t.Errorf("expected resolved_id to be indexed, got %q, %v", path, err)
+	}
+	if path, _, err := c.propertyPath("metadata.block_zip"); err != nil || path != "meta_block_zip" {
+		t.Errorf("expected the blocking rule key to be indexed, got %q, %v", path, err)
+	}
+	if path, metadata, err := c.propertyPath("name"); err != nil || metadata || path != "name" {
+		t.Errorf("expected schema fields to map to themselves, got %q, %v, %v", path, metadata, err)
+	}
+	if _, _, err := c.propertyPath("metadata.corruptions"); !errors.Is(err, store.ErrUnsupportedFilter) {
+		t.Errorf("expected unindexed metadata to be rejected, got %v", err)
+	}
+
+	entity := &store.EntityRecord{Metadata: map[string]interface{}{
+		"cluster_id":  "c-1",
+		"lsh_buckets": []interface{}{"b1", "b2"},
+		"corruptions": []string{"typo"},
+	}}
+	props := c.entityProperties(entity, false)
+	if got := props["meta_cluster_id"]; !reflect.DeepEqual(got, []string{"c-1"}) {
+		t.Errorf("unexpected cluster_id property %v", got)
+	}
+	if got := props["meta_lsh_buckets"]; !reflect.DeepEqual(got, []string{"b1", "b2"}) {
+		t.Errorf("unexpected lsh_buckets property %v", got)
+	}
+	if _, ok := props["meta_corruptions"]; ok {
+		t.Error("expected unindexed metadata not to get a property")
+	}
+}